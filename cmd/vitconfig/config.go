@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Flags that are not part of the config file settings
+var configSkip = map[string]bool{
+	"config":  true,
+	"version": true,
+}
+
+// loadConfigFile sets the flags not provided on the command line from a YAML/JSON config file.
+// The config file keys are the flags names, list flags (ex: bft-leader-secret-key) accept a list of values.
+func loadConfigFile(fs *flag.FlagSet, file string) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	// JSON is valid YAML, so one decoder is enough for both
+	settings := make(map[string]interface{})
+	err = yaml.Unmarshal(data, &settings)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	// flags provided on the command line override the config file
+	cmdLine := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		cmdLine[f.Name] = true
+	})
+
+	for name, value := range settings {
+		if fs.Lookup(name) == nil || configSkip[name] {
+			return fmt.Errorf("%s: [%s] - unknown setting", file, name)
+		}
+		if cmdLine[name] || value == nil {
+			continue
+		}
+
+		values, ok := value.([]interface{})
+		if !ok {
+			values = []interface{}{value}
+		}
+		for _, v := range values {
+			err = fs.Set(name, fmt.Sprint(v))
+			if err != nil {
+				return fmt.Errorf("%s: [%s] - %w", file, name, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testFlags struct {
+	fs      *flag.FlagSet
+	port    *int
+	host    *string
+	debug   *bool
+	leaders sliceFlag
+}
+
+func newTestFlags() *testFlags {
+	tf := &testFlags{fs: flag.NewFlagSet("test", flag.ContinueOnError)}
+	tf.port = tf.fs.Int("port", 8000, "")
+	tf.host = tf.fs.String("host", "0.0.0.0", "")
+	tf.debug = tf.fs.Bool("debug", false, "")
+	tf.fs.Var(&tf.leaders, "bft-leader-secret-key", "")
+	tf.fs.String("config", "", "")
+	tf.fs.Bool("version", false, "")
+	return tf
+}
+
+func writeTestConfig(t *testing.T, name string, content string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		args    []string
+		port    int
+		host    string
+		debug   bool
+		leaders []string
+	}{
+		{
+			name:    "yaml",
+			file:    "config.yaml",
+			content: "port: 9000\nhost: 127.0.0.1\ndebug: true\nbft-leader-secret-key:\n  - a.sk\n  - b.sk\n",
+			port:    9000,
+			host:    "127.0.0.1",
+			debug:   true,
+			leaders: []string{"a.sk", "b.sk"},
+		},
+		{
+			name:    "json",
+			file:    "config.json",
+			content: `{"port": 9001, "bft-leader-secret-key": "a.sk"}`,
+			port:    9001,
+			host:    "0.0.0.0",
+			leaders: []string{"a.sk"},
+		},
+		{
+			name:    "command line overrides",
+			file:    "config.yaml",
+			content: "port: 9000\nhost: 127.0.0.1\nbft-leader-secret-key: [a.sk, b.sk]\n",
+			args:    []string{"-port", "7000", "-bft-leader-secret-key", "c.sk"},
+			port:    7000,
+			host:    "127.0.0.1",
+			leaders: []string{"c.sk"},
+		},
+		{
+			name:    "empty values ignored",
+			file:    "config.yaml",
+			content: "port:\nhost: 127.0.0.1\n",
+			port:    8000,
+			host:    "127.0.0.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tf := newTestFlags()
+			if err := tf.fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			err := loadConfigFile(tf.fs, writeTestConfig(t, tt.file, tt.content))
+			if err != nil {
+				t.Fatalf("loadConfigFile: %v", err)
+			}
+			if *tf.port != tt.port || *tf.host != tt.host || *tf.debug != tt.debug {
+				t.Errorf("flags: got (%d, %s, %v), want (%d, %s, %v)", *tf.port, *tf.host, *tf.debug, tt.port, tt.host, tt.debug)
+			}
+			if !reflect.DeepEqual([]string(tf.leaders), tt.leaders) {
+				t.Errorf("leaders: got %v, want %v", tf.leaders, tt.leaders)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		errMsg  string
+	}{
+		{"unknown setting", "prot: 9000\n", "[prot] - unknown setting"},
+		{"config not a setting", "config: other.yaml\n", "[config] - unknown setting"},
+		{"version not a setting", "version: true\n", "[version] - unknown setting"},
+		{"wrong value", "port: http\n", "[port]"},
+		{"not a map", "- port\n", "config.yaml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tf := newTestFlags()
+			err := loadConfigFile(tf.fs, writeTestConfig(t, "config.yaml", tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Fatalf("loadConfigFile: got %v, want error containing %q", err, tt.errMsg)
+			}
+		})
+	}
+
+	if err := loadConfigFile(newTestFlags().fs, filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("loadConfigFile: expected error on missing file")
+	}
+}
@@ -3,11 +3,9 @@
 package main
 
 import (
-	"encoding/hex"
-	"encoding/json"
+	"context"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
 	"os/signal"
@@ -18,17 +16,9 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/input-output-hk/jorvit/pkg/vcli"
-	"github.com/input-output-hk/jorvit/pkg/vstation"
-
-	"github.com/gocarina/gocsv"
-	"github.com/input-output-hk/jorvit/internal/datastore"
 	"github.com/input-output-hk/jorvit/internal/kit"
-	"github.com/input-output-hk/jorvit/internal/loader"
-	"github.com/input-output-hk/jorvit/internal/webproxy"
+	"github.com/input-output-hk/jorvit/pkg/vitenv"
 	"github.com/rinor/jorcli/jcli"
-	"github.com/rinor/jorcli/jnode"
-	"golang.org/x/crypto/blake2b"
 )
 
 var (
@@ -36,186 +26,192 @@ var (
 	Version    = "dev"
 	CommitHash = "none"
 	BuildDate  = "unknown"
-
-	// memory processing stores
-	proposals datastore.ProposalsStore
-	funds     datastore.FundsStore
 )
 
-type bftLeader struct {
-	sk      string
-	pk      string
-	acc     string
-	skFile  string
-	cfgFile string
-}
-
-type jcliProposal struct {
-	ExternalID  string `json:"external_id"`
-	Options     uint8  `json:"options"`
-	Action      string `json:"action"`
-	ChallengeID uint32 `json:"challenge_id"`
-}
-
-type jcliVotePlan struct {
-	Payload                   string         `json:"payload_type"`
-	VoteStart                 ChainTime      `json:"vote_start"`
-	VoteEnd                   ChainTime      `json:"vote_end"`
-	CommitteeEnd              ChainTime      `json:"committee_end"`
-	Proposals                 []jcliProposal `json:"proposals"`
-	CommitteeMemberPublicKeys []string       `json:"committee_member_public_keys"` // privacy encyption keys
-	VotePlanID                string         `json:"-"`
-	Certificate               string         `json:"-"`
-}
+type sliceFlag []string
 
-type ChainTime struct {
-	Epoch  int64 `json:"epoch"`
-	SlotID int64 `json:"slot_id"`
+func (sf *sliceFlag) String() string {
+	return strings.Join(*sf, ",")
 }
 
-func (ct ChainTime) String() string {
-	return strconv.FormatInt(ct.Epoch, 10) + "." + strconv.FormatInt(ct.SlotID, 10)
+func (sf *sliceFlag) Set(val string) error {
+	*sf = append(*sf, val)
+	return nil
 }
 
-func ToChainTime(block0Time int64, SlotDuration uint8, SlotsPerEpoch uint32, dataTime int64) ChainTime {
-	slotsTotal := (dataTime - block0Time) / int64(SlotDuration)
-	epoch := slotsTotal / int64(SlotsPerEpoch)
-	slot := slotsTotal % int64(SlotsPerEpoch)
+type float64sFlag []float64
 
-	return ChainTime{
-		Epoch:  epoch,
-		SlotID: slot,
+func (ff *float64sFlag) String() string {
+	values := make([]string, len(*ff))
+	for i, v := range *ff {
+		values[i] = strconv.FormatFloat(v, 'f', -1, 64)
 	}
+	return strings.Join(values, ",")
 }
 
-func timeTrack(start time.Time, name string) {
-	elapsed := time.Since(start)
-	log.Printf("%s took %s", name, elapsed)
-}
-
-func loadProposals(file string) error {
-	defer timeTrack(time.Now(), "Proposals File load")
-	proposals = &datastore.Proposals{}
-	return proposals.Initialize(file)
-}
-
-func loadFundInfo(file string) error {
-	defer timeTrack(time.Now(), "Fund File load")
-	funds = &datastore.Funds{}
-	return funds.Initialize(file)
-}
-
-func votePlansNeeded(proposalsTot int, max int) int {
-	votePlansNeeded, more := proposalsTot/max, proposalsTot%max
-	if more > 0 {
-		votePlansNeeded = votePlansNeeded + 1
+func (ff *float64sFlag) Set(val string) error {
+	var values []float64
+	for _, s := range strings.Split(val, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return err
+		}
+		if v < 0 {
+			return fmt.Errorf("[%s] - wrong value, expected >= 0", s)
+		}
+		values = append(values, v)
 	}
-	return votePlansNeeded
+	*ff = values
+	return nil
 }
 
-type sliceFlag []string
+type uint32Flag uint32
 
-func (sf *sliceFlag) String() string {
-	return strings.Join(*sf, ",")
+func (uf *uint32Flag) String() string {
+	return strconv.FormatUint(uint64(*uf), 10)
 }
 
-func (sf *sliceFlag) Set(val string) error {
-	*sf = append(*sf, val)
+func (uf *uint32Flag) Set(val string) error {
+	v, err := strconv.ParseUint(val, 10, 32)
+	if err != nil {
+		return err
+	}
+	*uf = uint32Flag(v)
 	return nil
 }
 
 func main() {
+	// subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "resume":
+			resumeCmd(os.Args[2:])
+			return
+		case "tally":
+			tallyCmd("tally", os.Args[2:], (*vitenv.Environment).TallyPublic)
+			return
+		case "tally-private":
+			tallyCmd("tally-private", os.Args[2:], (*vitenv.Environment).TallyPrivate)
+			return
+		case "vote-load":
+			voteLoadCmd(os.Args[2:])
+			return
+		}
+	}
+
 	var (
 		err error
 
-		// BFT Leaders
-		bftLeadersSecretKeys sliceFlag
-		bftLeadersPublicKeys sliceFlag
-
-		// Committee auth + privacy members
-		committeeAuthPublicKeys    sliceFlag
-		committeePrivacyPublicKeys sliceFlag
-
-		// max proposals included within one voteplan - hard limit
-		votePlanProposalsMax uint
-
-		// Lovelace amount for Bft Leader and Committee Auth members
-		bftLeaderFund uint64
-		committeeFund uint64
+		cfg = vitenv.DefaultConfig()
+		def = vitenv.DefaultConfig()
 	)
 
 	// node settings
-	proxyAddrPort := flag.String("proxy", "0.0.0.0:8000", "Address where REST api PROXY should listen in IP:PORT format")
-	restAddrPort := flag.String("rest", "0.0.0.0:8001", "Address where Jörmungandr REST api should listen in IP:PORT format")
-	nodeAddrPort := flag.String("node", "127.0.0.1:9001", "Address where Jörmungandr node should listen in IP:PORT format")
-	explorerEnabled := flag.Bool("explorer", false, "Enable/Disable explorer")
-	restCorsAllowed := flag.String("cors", "http://127.0.0.1,http://localhost", "Comma separated list of CORS allowed origins")
-	skipBootstrap := flag.Bool("skip-bootstrap", true, "Skip node bootstrap, in case of first/single genesis leader (default true)")
-	nodeLogLevel := flag.String("node-log-level", "warn", "Jörmungandr node log level, [off, critical, error, warn, info, debug, trace]")
+	flag.StringVar(&cfg.Proxy, "proxy", def.Proxy, "Address where REST api PROXY should listen in IP:PORT format")
+	flag.StringVar(&cfg.Rest, "rest", def.Rest, "Address where Jörmungandr REST api should listen in IP:PORT format")
+	flag.StringVar(&cfg.Node, "node", def.Node, "Address where Jörmungandr node should listen in IP:PORT format")
+	flag.BoolVar(&cfg.Explorer, "explorer", def.Explorer, "Enable/Disable explorer")
+	flag.StringVar(&cfg.Cors, "cors", def.Cors, "Comma separated list of CORS allowed origins")
+	flag.BoolVar(&cfg.SkipBootstrap, "skip-bootstrap", def.SkipBootstrap, "Skip node bootstrap, in case of first/single genesis leader (default true)")
+	flag.StringVar(&cfg.NodeLogLevel, "node-log-level", def.NodeLogLevel, "Jörmungandr node log level, [off, critical, error, warn, info, debug, trace]")
 	// extra node
-	allowNodeRestart := flag.Bool("allow-node-restart", true, "Allows to stop the node started from the service and restart it manually")
-	shutdownNode := flag.Bool("shutdown-node", true, "When exiting try node shutdown in case the node was restarted manually")
-	startNode := flag.Bool("start-node", false, "Start jörmungandr node. When false only config will be generated")
+	flag.BoolVar(&cfg.AllowNodeRestart, "allow-node-restart", def.AllowNodeRestart, "Allows to stop the node started from the service and restart it manually")
+	flag.BoolVar(&cfg.ShutdownNode, "shutdown-node", def.ShutdownNode, "When exiting try node shutdown in case the node was restarted manually")
+	flag.BoolVar(&cfg.StartNode, "start-node", def.StartNode, "Start jörmungandr node. When false only config will be generated")
+	// multi node network
+	flag.BoolVar(&cfg.MultiNode, "multi-node", def.MultiNode, "One node per BFT leader (with secret key), each with own storage, REST/P2P ports (+node index) and the others as trusted peers")
+	flag.UintVar(&cfg.ProxyNode, "proxy-node", def.ProxyNode, "Index of the node the REST api PROXY targets (multi-node)")
 
 	// vit service station settings
-	vitAddrPort := flag.String("vit-station", "0.0.0.0:3030", "Address where vit-servicing-station-server should listen in IP:PORT format")
-	vitLogLevel := flag.String("vit-log-level", "warn", "vit-servicing-station-server log level, [off, critical, error, warn, info, debug, trace]")
+	flag.StringVar(&cfg.VitStation, "vit-station", def.VitStation, "Address where vit-servicing-station-server should listen in IP:PORT format")
+	flag.StringVar(&cfg.VitLogLevel, "vit-log-level", def.VitLogLevel, "vit-servicing-station-server log level, [off, critical, error, warn, info, debug, trace]")
 	// extra vit
-	startVit := flag.Bool("start-vit", false, "Start vit-servicing-station-server. When false only config will be generated")
+	flag.BoolVar(&cfg.StartVit, "start-vit", def.StartVit, "Start vit-servicing-station-server. When false only config will be generated")
 
 	// external proposal data
-	proposalsPath := flag.String("proposals", "."+string(os.PathSeparator)+"assets"+string(os.PathSeparator)+"proposals.csv", "CSV full path (filename) to load PROPOSALS from")
-	fundsPath := flag.String("fund", "."+string(os.PathSeparator)+"assets"+string(os.PathSeparator)+"fund.csv", "CSV full path (filename) to load FUND info from")
-	challengesPath := flag.String("challenges", "."+string(os.PathSeparator)+"assets"+string(os.PathSeparator)+"challenges.csv", "CSV full path (filename) to load CHALLENGES info from")
-	genesisExtraDataPath := flag.String("genesis-extra-data", "."+string(os.PathSeparator)+"assets"+string(os.PathSeparator)+"extra_genesis_data.yaml", "YAML full path (filename) to load extra genesis funds from")
+	flag.StringVar(&cfg.Proposals, "proposals", def.Proposals, "CSV full path (filename) to load PROPOSALS from")
+	flag.StringVar(&cfg.Fund, "fund", def.Fund, "CSV full path (filename) to load FUND info from")
+	flag.StringVar(&cfg.Challenges, "challenges", def.Challenges, "CSV full path (filename) to load CHALLENGES info from")
+	flag.StringVar(&cfg.GenesisExtraData, "genesis-extra-data", def.GenesisExtraData, "YAML full path (filename) to load extra genesis funds from")
+	flag.StringVar(&cfg.VotingPowerSnapshot, "voting-power-snapshot", def.VotingPowerSnapshot, "CSV or JSON (.json) full path (filename) to load the voting power snapshot (address, stake) from, as block0 funds")
 
 	// vote and committee related timing
-	voteStartFlag := flag.String("vote-start", "", "Vote start time in '2006-01-02T15:04:05Z07:00' RFC3339 format. If not set 'genesis-time' will be used")
-	voteEndFlag := flag.String("vote-end", "", "Vote end time in '2006-01-02T15:04:05Z07:00' RFC3339 format. If not set 'vote-duration' will be used")
-	committeeEndFlag := flag.String("committee-end", "", "Committee end time in '2006-01-02T15:04:05Z07:00' RFC3339 format. If not set 'committee-duration' will be used")
+	flag.StringVar(&cfg.VoteStart, "vote-start", def.VoteStart, "Vote start time in '2006-01-02T15:04:05Z07:00' RFC3339 format. If not set 'genesis-time' will be used")
+	flag.StringVar(&cfg.VoteEnd, "vote-end", def.VoteEnd, "Vote end time in '2006-01-02T15:04:05Z07:00' RFC3339 format. If not set 'vote-duration' will be used")
+	flag.StringVar(&cfg.CommitteeEnd, "committee-end", def.CommitteeEnd, "Committee end time in '2006-01-02T15:04:05Z07:00' RFC3339 format. If not set 'committee-duration' will be used")
 
-	voteDurationFlag := flag.String("vote-duration", "144h", "Voting period duration. Ignored if 'vote-end' is set")
-	committeeDurationFlag := flag.String("committee-duration", "24h", "Committee period duration. Ignored if 'committee-end' is set")
+	flag.StringVar(&cfg.VoteDuration, "vote-duration", def.VoteDuration, "Voting period duration. Ignored if 'vote-end' is set")
+	flag.StringVar(&cfg.CommitteeDuration, "committee-duration", def.CommitteeDuration, "Committee period duration. Ignored if 'committee-end' is set")
 
-	flag.UintVar(&votePlanProposalsMax, "voteplan-proposals-max", 255, "Max number of proposals per voteplan [1-256]")
+	flag.UintVar(&cfg.VotePlanProposalsMax, "voteplan-proposals-max", def.VotePlanProposalsMax, "Max number of proposals per voteplan [1-256]")
 
-	block0Voteplans := flag.Bool("block0-voteplan", false, "Enable/Disable inclusion of proposals/voteplans signed certificate on block0")
+	flag.BoolVar(&cfg.Block0VotePlan, "block0-voteplan", def.Block0VotePlan, "Enable/Disable inclusion of proposals/voteplans signed certificate on block0")
+	flag.BoolVar(&cfg.SubmitVotePlans, "submit-voteplans", def.SubmitVotePlans, "With \"block0-voteplan\" disabled, submit the voteplans (signed by a BFT leader) as transactions to the \"proxy-node\" node once it is up")
+	flag.BoolVar(&cfg.AutoTally, "auto-tally", def.AutoTally, "Once the vote period ends, submit the public voteplans tally (signed by a BFT leader) to the \"proxy-node\" node")
 
 	// genesis (block0) settings
-	genesisTimeFlag := flag.String("genesis-time", "", "Genesis time in '2006-01-02T15:04:05Z07:00' RFC3339 format (default \"Now()\")")
-	slotDurFlag := flag.String("slot-duration", "20s", "Slot period duration. 1s-255s")
-	epochDurFlag := flag.String("epoch-duration", "24h", "Epoch period duration")
+	flag.StringVar(&cfg.GenesisTime, "genesis-time", def.GenesisTime, "Genesis time in '2006-01-02T15:04:05Z07:00' RFC3339 format (default \"Now()\")")
+	flag.StringVar(&cfg.SlotDuration, "slot-duration", def.SlotDuration, "Slot period duration. 1s-255s")
+	flag.StringVar(&cfg.EpochDuration, "epoch-duration", def.EpochDuration, "Epoch period duration")
+	flag.Var((*uint32Flag)(&cfg.EpochStabilityDepth), "epoch-stability-depth", "Epoch stability depth (blocks)")
+	flag.StringVar(&cfg.Discrimination, "discrimination", def.Discrimination, "Address discrimination, [production, testing]. Used for the generated accounts (ca1.../ta1...) and checked on the extra genesis data")
+
+	// consensus
+	flag.StringVar(&cfg.Consensus, "consensus", def.Consensus, "Consensus, [bft, genesis_praos]. With genesis_praos \"stake-pools\" stake pools are generated and registered in block0")
+	flag.UintVar(&cfg.StakePools, "stake-pools", def.StakePools, "Number of stake pools to generate (genesis_praos)")
+	flag.Uint64Var(&cfg.StakePoolFund, "stake-pool-fund", def.StakePoolFund, "Lovelace amount to fund each stake pool owner account, delegated to its pool (genesis_praos)")
+	flag.Float64Var(&cfg.PraosActiveSlotCoeff, "praos-active-slot-coeff", def.PraosActiveSlotCoeff, "Genesis praos active slot coefficient [0.001 - 1.000] (genesis_praos)")
+	flag.Var((*uint32Flag)(&cfg.KesUpdateSpeed), "kes-update-speed", "KES update speed in seconds [60 - 31536000] (genesis_praos)")
+
+	// generated test voters wallets
+	flag.UintVar(&cfg.Voters, "voters", def.Voters, "Number of test voter wallets (secret key and account) to generate, funded in block0 and exported to voters.csv/voters.json")
+	flag.Uint64Var(&cfg.VoterFund, "voter-fund", def.VoterFund, "Lovelace amount to fund each generated voter account")
+	flag.Uint64Var(&cfg.VoterFundMax, "voter-fund-max", def.VoterFundMax, "When > \"voter-fund\", each generated voter account is funded with a random amount in [\"voter-fund\" - \"voter-fund-max\"]")
 
 	// BFT Leaders - also promoted to Global Committee members
-	bftLeaderTot := flag.Uint("bft-leader-min", 1, "Minimun number of BFT Leaders. NEW SK/PK key pair(s) will be autogenerated if > \"bft-leader-secret-key\" + \"bft-leader-public-key\". min: 1")
-	flag.Var(&bftLeadersSecretKeys, "bft-leader-secret-key", "File containing SK (secret key) to be used as BFT leader")
-	flag.Var(&bftLeadersPublicKeys, "bft-leader-public-key", "PK (public key) to be used as BFT leader. No config file will be generated for this (since don't have the SK). ex: ed25519_pk15f7p4nzektlrj6muvvmn0hatzekg7yf0qjx54pg72qq2zgjjzdzqwhm8rz")
+	flag.UintVar(&cfg.BftLeaderMin, "bft-leader-min", def.BftLeaderMin, "Minimun number of BFT Leaders. NEW SK/PK key pair(s) will be autogenerated if > \"bft-leader-secret-key\" + \"bft-leader-public-key\". min: 1")
+	flag.Var((*sliceFlag)(&cfg.BftLeaderSecretKeys), "bft-leader-secret-key", "File containing SK (secret key) to be used as BFT leader")
+	flag.Var((*sliceFlag)(&cfg.BftLeaderPublicKeys), "bft-leader-public-key", "PK (public key) to be used as BFT leader. No config file will be generated for this (since don't have the SK). ex: ed25519_pk15f7p4nzektlrj6muvvmn0hatzekg7yf0qjx54pg72qq2zgjjzdzqwhm8rz")
 
 	// Global Committee auth members public keys
-	flag.Var(&committeeAuthPublicKeys, "committee-auth-public-key", "Global committee member public key. ex: ed25519_pk15f7p4nzektlrj6muvvmn0hatzekg7yf0qjx54pg72qq2zgjjzdzqwhm8rz")
+	flag.Var((*sliceFlag)(&cfg.CommitteeAuthPublicKeys), "committee-auth-public-key", "Global committee member public key. ex: ed25519_pk15f7p4nzektlrj6muvvmn0hatzekg7yf0qjx54pg72qq2zgjjzdzqwhm8rz")
 	// Voteplan Committee privacy members public keys
-	flag.Var(&committeePrivacyPublicKeys, "committee-privacy-public-key", "Privacy committee member public key used to build encyption key, hex encoded")
+	flag.Var((*sliceFlag)(&cfg.CommitteePrivacyPublicKeys), "committee-privacy-public-key", "Privacy committee member public key used to build encyption key, hex encoded")
+	flag.UintVar(&cfg.CommitteePrivacyMembers, "committee-privacy-members", def.CommitteePrivacyMembers, "Number of privacy committee members to generate when no \"committee-privacy-public-key\" is provided [1-255]")
+	flag.UintVar(&cfg.CommitteePrivacyThreshold, "committee-privacy-threshold", def.CommitteePrivacyThreshold, "Number of privacy committee members needed to decrypt the tally [1-\"committee-privacy-members\"]")
 
 	// (bug) - 0 fees is ignored from the jorcli lib (needs fixing)
 	// fees
-	feesCertificate := flag.Uint64("fees-certificate", 0, "Default certificate fee (lovelace)")
-	feesCoefficient := flag.Uint64("fees-coefficient", 0, "Coefficient fee")
-	feesConstant := flag.Uint64("fees-constant", 0, "Constant fee (lovelace)")
-	feesCertificatePoolRegistration := flag.Uint64("fees-certificate-pool-registration", 0, "Pool registration certificate fee (lovelace)")
-	feesCertificateStakeDelegation := flag.Uint64("fees-certificate-stake-delegation", 0, "Stake delegation certificate fee (lovelace)")
-	feesCertificateVotePlan := flag.Uint64("fees-certificate-vote-plan", 0, "VotePlan certificate fee (lovelace)")
-	feesCertificateVoteCast := flag.Uint64("fees-certificate-vote-cast", 0, "VoteCast certificate fee (lovelace)")
-	feesGoTo := flag.String("fees-go-to", "rewards", "Where to send the collected fees, rewards or treasury")
+	flag.Uint64Var(&cfg.FeesCertificate, "fees-certificate", def.FeesCertificate, "Default certificate fee (lovelace)")
+	flag.Uint64Var(&cfg.FeesCoefficient, "fees-coefficient", def.FeesCoefficient, "Coefficient fee")
+	flag.Uint64Var(&cfg.FeesConstant, "fees-constant", def.FeesConstant, "Constant fee (lovelace)")
+	flag.Uint64Var(&cfg.FeesCertificatePoolRegistration, "fees-certificate-pool-registration", def.FeesCertificatePoolRegistration, "Pool registration certificate fee (lovelace)")
+	flag.Uint64Var(&cfg.FeesCertificateStakeDelegation, "fees-certificate-stake-delegation", def.FeesCertificateStakeDelegation, "Stake delegation certificate fee (lovelace)")
+	flag.Uint64Var(&cfg.FeesCertificateVotePlan, "fees-certificate-vote-plan", def.FeesCertificateVotePlan, "VotePlan certificate fee (lovelace)")
+	flag.Uint64Var(&cfg.FeesCertificateVoteCast, "fees-certificate-vote-cast", def.FeesCertificateVoteCast, "VoteCast certificate fee (lovelace)")
+	flag.StringVar(&cfg.FeesGoTo, "fees-go-to", def.FeesGoTo, "Where to send the collected fees, rewards or treasury")
 
 	// in memory service only
-	dateTimeFormat := flag.String("time-format", time.RFC3339, "Date/Time format that will be used for display (go lang format), ex: \"2006-01-02 15:04:05 -0700 MST\"")
+	flag.StringVar(&cfg.TimeFormat, "time-format", def.TimeFormat, "Date/Time format that will be used for display (go lang format), ex: \"2006-01-02 15:04:05 -0700 MST\"")
 
 	// version info
 	version := flag.Bool("version", false, "Print current app version and build info")
 
+	// reproducible environment
+	flag.BoolVar(&cfg.Reproducible, "reproducible", def.Reproducible, "Reproducible mode, requires \"genesis-time\", \"output-dir\" and \"key-seed\" or \"key-dir\" so that block0 hash, voteplans ids and proposals ExternalID match across runs")
+	flag.StringVar(&cfg.OutputDir, "output-dir", def.OutputDir, "Fixed working directory (needs to be empty) to use instead of a new \"jnode_VIT_xxxxx\" one")
+	flag.StringVar(&cfg.KeySeed, "key-seed", def.KeySeed, "Seed (any string) to deterministically generate the BFT leaders and privacy committee keys")
+	flag.StringVar(&cfg.KeyDir, "key-dir", def.KeyDir, "Directory to load the BFT leaders and privacy committee keys from. Keys missing there are generated and stored for next runs")
+
+	// dry run
+	dryRun := flag.Bool("dry-run", false, "Validate the inputs and print the plan (voteplans, schedule, funded accounts, ports) without generating anything")
+
+	// config file
+	configFile := flag.String("config", "", "YAML/JSON config file with the settings, keys are the flags names. Flags provided on the command line override the file")
+
 	// fund each btf leader and/or committee auth account address
-	flag.Uint64Var(&bftLeaderFund, "bft-leader-fund", 0, "Lovelace amount to fund bft leader account")
-	flag.Uint64Var(&committeeFund, "committee-auth-fund", 0, "Lovelace amount to fund committee auth account")
+	flag.Uint64Var(&cfg.BftLeaderFund, "bft-leader-fund", def.BftLeaderFund, "Lovelace amount to fund bft leader account")
+	flag.Uint64Var(&cfg.CommitteeAuthFund, "committee-auth-fund", def.CommitteeAuthFund, "Lovelace amount to fund committee auth account")
 
 	flag.Parse()
 
@@ -226,927 +222,254 @@ func main() {
 		os.Exit(0)
 	}
 
-	if *nodeLogLevel == "" {
-		*nodeLogLevel = "warn"
-	}
-	if *vitLogLevel == "" {
-		*vitLogLevel = "warn"
+	if *configFile != "" {
+		err = loadConfigFile(flag.CommandLine, *configFile)
+		kit.FatalOn(err, "loadConfigFile")
 	}
 
-	// check if file exist - duplicate data check is performed later on
-	for i := range bftLeadersSecretKeys {
-		_, err = os.Stat(bftLeadersSecretKeys[i])
-		kit.FatalOn(err)
+	if *dryRun {
+		plan, err := vitenv.NewPlan(cfg)
+		kit.FatalOn(err, "vitenv.NewPlan")
+		plan.Print(os.Stdout)
+		os.Exit(0)
 	}
 
-	// set new value for bftLeaderTot if provided inputs are more
-	if len(bftLeadersSecretKeys) > 0 || len(bftLeadersPublicKeys) > 0 {
-		inputLeaders := uint(len(bftLeadersSecretKeys) + len(bftLeadersPublicKeys))
-		if inputLeaders > *bftLeaderTot {
-			*bftLeaderTot = inputLeaders
-		}
-	}
+	// working directory is created next to the binary
+	cfg.BaseDir, err = filepath.Abs(filepath.Dir(os.Args[0]))
+	kit.FatalOn(err)
 
-	if *dateTimeFormat == "" {
-		*dateTimeFormat = time.RFC3339
-	}
+	env, err := vitenv.Generate(context.Background(), cfg)
+	kit.FatalOn(err, "vitenv.Generate")
 
-	if *genesisTimeFlag == "" {
-		*genesisTimeFlag = time.Now().UTC().Format(time.RFC3339)
-	}
-	genesisTime, err := time.Parse(time.RFC3339, *genesisTimeFlag)
-	kit.FatalOn(err, "genesisTime")
-
-	slotDur, err := time.ParseDuration(*slotDurFlag)
-	kit.FatalOn(err, "slotDuration")
-	switch {
-	case slotDur == 0:
-		log.Fatalf("[%s] - cannot be 0", "slotDuration")
-	case slotDur%time.Second > 0:
-		log.Fatalf("[%s] - smallest unit is [1s]", "slotDuration")
-	case slotDur > 255*time.Second:
-		log.Fatalf("[%s] - max allowed value is [255s]", "slotDuration")
-	}
+	run(env)
+}
 
-	epochDur, err := time.ParseDuration(*epochDurFlag)
-	kit.FatalOn(err, "epochDuration")
-	switch {
-	case epochDur == 0:
-		log.Fatalf("[%s] - cannot be 0", "epochDuration")
-	case epochDur%time.Second > 0:
-		log.Fatalf("[%s] - smallest unit is [1s]", "epochDuration")
-	case epochDur%slotDur > 0:
-		log.Fatalf("[%s: %s] - should be multiple of [%s: %s].", "epochDuration", epochDur.String(), "SlotDuration", slotDur.String())
-	}
+// run the environment services (node, vit station and proxy), until stopped.
+func run(env *vitenv.Environment) {
+	err := env.Start()
+	kit.FatalOn(err, "env.Start")
 
-	voteDur, err := time.ParseDuration(*voteDurationFlag)
-	kit.FatalOn(err, "voteDuration")
-	switch {
-	case voteDur == 0:
-		log.Fatalf("[%s] - cannot be 0", "voteDuration")
-	case voteDur%time.Second > 0:
-		log.Fatalf("[%s] - smallest unit is [1s]", "voteDuration")
-	case voteDur%slotDur > 0:
-		log.Fatalf("[%s: %s] - should be multiple of [%s: %s].", "voteDuration", voteDur.String(), "SlotDuration", slotDur.String())
-	}
+	log.Println()
+	log.Printf("OS: %s, ARCH: %s", runtime.GOOS, runtime.GOARCH)
+	log.Println()
+	log.Printf("jcli: %s", env.JcliBin)
+	log.Printf("ver : %s", env.JcliVersion)
+	log.Println()
+	log.Printf("node: %s", env.JnodeBin)
+	log.Printf("ver : %s", env.JnodeVersion)
+	log.Println()
 
-	committeeDur, err := time.ParseDuration(*committeeDurationFlag)
-	kit.FatalOn(err, "committeeDuration")
-	switch {
-	case committeeDur == 0:
-		log.Fatalf("[%s] - cannot be 0", "committeeDuration")
-	case committeeDur%time.Second > 0:
-		log.Fatalf("[%s] - smallest unit is [1s]", "committeeDuration")
-	case committeeDur%slotDur > 0:
-		log.Fatalf("[%s: %s] - should be multiple of [%s: %s].", "committeeDuration", committeeDur.String(), "SlotDuration", slotDur.String())
+	log.Printf("VIT - BFT Genesis Hash: %s\n", env.Block0Hash)
+	log.Println()
+	log.Printf("VIT - BFT Genesis: %s - %d", "COMMITTEE", len(env.Committee)+len(env.Leaders))
+	log.Printf("VIT - BFT Genesis: %s - %d", "VOTEPLANS", len(env.VotePlans))
+	if len(env.StakePools) > 0 {
+		log.Printf("VIT - BFT Genesis: %s - %d", "STAKE POOLS", len(env.StakePools))
 	}
+	log.Printf("VIT - BFT Genesis: %s - %d", "PROPOSALS", env.Proposals.Total())
+	log.Println()
 
-	if *voteStartFlag == "" {
-		*voteStartFlag = *genesisTimeFlag
-	}
-	voteStartTime, err := time.Parse(time.RFC3339, *voteStartFlag)
-	kit.FatalOn(err, "voteStartTime")
-	switch {
-	case voteStartTime.Sub(genesisTime) < 0:
-		log.Fatalf("%s: [%s] can't be smaller than %s: [%s]", "voteStart", *voteStartFlag, "genesisTime", *genesisTimeFlag)
-	case voteStartTime.Sub(genesisTime)%slotDur != 0:
-		log.Fatalf("%s: [%s] needs to have %s: [%s] steps from %s: [%s]", "voteStart", *voteStartFlag, "SlotDuration", slotDur.String(), "genesisTime", *genesisTimeFlag)
+	for i, n := range env.Nodes {
+		if len(env.Nodes) > 1 {
+			log.Printf("JÖRMUNGANDR node_%d - leaders: %d", i, len(n.SecretConfigFiles))
+		}
+		log.Printf("JÖRMUNGANDR listening at: %s - %v", n.P2PListenAddress, env.Config.StartNode)
+		log.Printf("JÖRMUNGANDR Rest API available at: http://%s/api - %v", n.RestAddress, env.Config.StartNode)
+		log.Println()
 	}
+	log.Printf("VIT-STATION API available at: http://%s/api - %v", env.VitStationAddress, env.Config.StartVit)
+	log.Println()
+	log.Printf("APP - PROXY Rest API available at: http://%s/api - node_%d", env.ProxyAddress, env.Config.ProxyNode)
+	log.Println()
+	log.Println("VIT - BFT Genesis Node - Running...")
+	log.Println()
 
-	if *voteEndFlag == "" {
-		*voteEndFlag = voteStartTime.Add(voteDur).Format(time.RFC3339)
-	}
-	voteEndTime, err := time.Parse(time.RFC3339, *voteEndFlag)
-	kit.FatalOn(err, "voteEndTime")
-	switch {
-	case voteEndTime.Sub(voteStartTime) < 0:
-		log.Fatalf("%s: [%s] can't be smaller than %s: [%s]", "voteEnd", *voteEndFlag, "voteStart", *voteStartFlag)
-	case voteEndTime.Sub(genesisTime)%slotDur != 0:
-		log.Fatalf("%s: [%s] needs to have %s: [%s] steps from %s: [%s]", "voteEnd", *voteEndFlag, "SlotDuration", slotDur.String(), "genesisTime", *genesisTimeFlag)
+	if env.VstationBin != "" {
+		log.Printf("\t%s %s", env.VstationBin, strings.Join(env.Station.BuildCmdArg(), " "))
+		log.Println()
 	}
 
-	if *committeeEndFlag == "" {
-		*committeeEndFlag = voteEndTime.Add(committeeDur).Format(time.RFC3339)
-	}
-	committeeEndTime, err := time.Parse(time.RFC3339, *committeeEndFlag)
-	kit.FatalOn(err, "committeeEndTime")
-	switch {
-	case committeeEndTime.Sub(voteEndTime) < 0:
-		log.Fatalf("%s: [%s] can't be smaller than %s: [%s]", "committeeEnd", *committeeEndFlag, "voteEnd", *voteEndFlag)
-	case committeeEndTime.Sub(genesisTime)%slotDur != 0:
-		log.Fatalf("%s: [%s] needs to have %s: [%s] steps from %s: [%s]", "committeeEnd", *committeeEndFlag, "SlotDuration", slotDur.String(), "genesisTime", *genesisTimeFlag)
+	for _, n := range env.Nodes {
+		log.Printf("\t%s %s", env.JnodeBin, strings.Join(n.Node.BuildCmdArg(), " "))
+		log.Println()
 	}
 
-	voteStart := ToChainTime(
-		genesisTime.Unix(),
-		uint8(slotDur.Seconds()),
-		uint32(epochDur/slotDur),
-		voteStartTime.Unix(),
-	)
-
-	voteEnd := ToChainTime(
-		genesisTime.Unix(),
-		uint8(slotDur.Seconds()),
-		uint32(epochDur/slotDur),
-		voteEndTime.Unix(),
-	)
-
-	committeeEnd := ToChainTime(
-		genesisTime.Unix(),
-		uint8(slotDur.Seconds()),
-		uint32(epochDur/slotDur),
-		committeeEndTime.Unix(),
-	)
-
-	switch {
-	case *proposalsPath == "":
-		log.Fatalf("[%s] - not provided", "proposals file")
-	case *fundsPath == "":
-		log.Fatalf("[%s] - not provided", "fund file")
-	case *challengesPath == "":
-		log.Fatalf("[%s] - not provided", "challenges file")
-	case *bftLeaderTot == 0:
-		log.Fatalf("[%s: %d] - wrong value", "bftLeaderTot", *bftLeaderTot)
-
-	case *proxyAddrPort == "":
-		log.Fatalf("[%s] - not set", "proxy")
-	case *restAddrPort == "":
-		log.Fatalf("[%s] - not set", "rest")
-	case *nodeAddrPort == "":
-		log.Fatalf("[%s] - not set", "node")
-
-	case *vitAddrPort == "":
-		log.Fatalf("[%s] - not set", "vit-station")
-
-	case votePlanProposalsMax < 1:
-		log.Fatalf("[%s: %d] - wrong value, expected > 0", "votePlanProposalsMax", votePlanProposalsMax)
+	chainCtx, chainCancel := context.WithCancel(context.Background())
+	defer chainCancel()
+	if env.Config.SubmitVotePlans || env.Config.AutoTally {
+		go onChain(chainCtx, env)
 	}
 
-	nodeListen := strings.Split(*nodeAddrPort, ":")
-	nodeAddr := nodeListen[0]
-	nodePort, err := strconv.Atoi(nodeListen[1])
-	kit.FatalOn(err, "nodePort")
-
-	err = loadProposals(*proposalsPath)
-	kit.FatalOn(err, "loadProposals")
-
-	err = loadFundInfo(*fundsPath)
-	kit.FatalOn(err, "loadFundInfo")
-
-	var (
-		// Proxy
-		proxyAddress = *proxyAddrPort
-
-		// Rest
-		restAddress = *restAddrPort
-
-		// P2P
-		p2pIPver, p2pProto           = "ip4", "tcp"
-		p2pListenAddr, p2pListenPort = nodeAddr, nodePort
-		p2pListenAddress             = "/" + p2pIPver + "/" + p2pListenAddr + "/" + p2pProto + "/" + strconv.Itoa(p2pListenPort)
-
-		// General
-		consensus      = "bft" // bft or genesis_praos
-		discrimination = ""    // "" (empty defaults to "production")
-
-		// Directories within main working dir "jnode_VIT_xxxxx"
-		votePlanDir   = "vote_plans"
-		vitStationDir = "vit_station"
-	)
-
-	dir, err := filepath.Abs(filepath.Dir(os.Args[0]))
-	kit.FatalOn(err)
-
-	// Check for jcli binary. Local folder first (jor_bins), then PATH
-	jcliBin, err := kit.FindExecutable("jcli", "jor_bins")
-	kit.FatalOn(err, jcliBin)
-	jcli.BinName(jcliBin)
-
-	// get jcli version
-	jcliVersion, err := jcli.VersionFull()
-	kit.FatalOn(err, kit.B2S(jcliVersion))
-
-	/* Working directories */
-
-	// create a new working directory
-	workingDir, err := ioutil.TempDir(dir, "jnode_VIT_")
-	kit.FatalOn(err, "workingDir")
-	log.Printf("Working Directory: %s", workingDir)
-
-	// directory to dump the voteplan(s) config(s) and certificate(s)
-	votePlanDir = filepath.Join(workingDir, votePlanDir)
-	err = os.Mkdir(votePlanDir, 0755)
-	kit.FatalOn(err, "votePlanDir")
-
-	// directory to dump the vit servicing station configs
-	vitStationDir = filepath.Join(workingDir, vitStationDir)
-	err = os.Mkdir(vitStationDir, 0755)
-	kit.FatalOn(err, "vitStationDir")
-
-	/* BFT LEADER(s) */
-
-	leaders := make([]bftLeader, 0, *bftLeaderTot)
-	leadersPubKey := make(map[string]bool, *bftLeaderTot)
-
-	var (
-		bftFileIdx int
-		bftPkIdx   int
-	)
-	for i := 0; uint(i) < *bftLeaderTot; i++ {
-		var (
-			leaderSK      []byte
-			leaderPK      []byte
-			bftSecretFile string
-		)
+	env.Wait() // Wait for the started vit station and node to stop.
 
+	if env.Config.AllowNodeRestart || !env.Config.StartNode {
 		switch {
-
-		case len(bftLeadersSecretKeys)-bftFileIdx > 0:
-			leaderSK, err = ioutil.ReadFile(bftLeadersSecretKeys[bftFileIdx])
-			kit.FatalOn(err, kit.B2S(leaderSK))
-			leaderPK, err = jcli.KeyToPublic(leaderSK, "", "")
-			kit.FatalOn(err, kit.B2S(leaderPK))
-			bftFileIdx++
-
-		case len(bftLeadersPublicKeys)-bftPkIdx > 0:
-			leaderPK = []byte(bftLeadersPublicKeys[bftPkIdx])
-			bftPkIdx++
-
-		default:
-			leaderSK, err = jcli.KeyGenerate("", "Ed25519", "")
-			kit.FatalOn(err, kit.B2S(leaderSK))
-			leaderPK, err = jcli.KeyToPublic(leaderSK, "", "")
-			kit.FatalOn(err, kit.B2S(leaderPK))
-		}
-
-		if leadersPubKey[kit.B2S(leaderPK)] {
-			i-- // needed to reach bftLeaderTot, won't go below 0
-			log.Printf("***** Duplicate BFT Leader skip: %s *****", kit.B2S(leaderPK))
-			log.Println()
-			continue
-		}
-		leadersPubKey[kit.B2S(leaderPK)] = true
-
-		leaderACC, err := jcli.AddressAccount(kit.B2S(leaderPK), "", "")
-		kit.FatalOn(err, kit.B2S(leaderACC))
-
-		if len(leaderSK) > 0 {
-			// Needed later on to sign
-			bftSecretFile = filepath.Join(workingDir, strconv.Itoa(i)+"_bft_secret.key")
-			err = ioutil.WriteFile(bftSecretFile, leaderSK, 0744)
-			kit.FatalOn(err)
-		}
-
-		leaders = append(leaders, bftLeader{
-			sk:     kit.B2S(leaderSK),
-			pk:     kit.B2S(leaderPK),
-			acc:    kit.B2S(leaderACC),
-			skFile: bftSecretFile,
-		})
-	}
-
-	/////////////////////
-	//  block0 config  //
-	/////////////////////
-
-	block0cfg := jnode.NewBlock0Config()
-
-	block0Discrimination := "production"
-	if discrimination == "testing" {
-		block0Discrimination = "test"
-	}
-
-	// set/change config params
-	block0cfg.BlockchainConfiguration.Block0Date = genesisTime.Unix()
-	block0cfg.BlockchainConfiguration.Block0Consensus = consensus
-	block0cfg.BlockchainConfiguration.Discrimination = block0Discrimination
-
-	block0cfg.BlockchainConfiguration.SlotDuration = uint8(slotDur.Seconds())
-	block0cfg.BlockchainConfiguration.SlotsPerEpoch = uint32(epochDur / slotDur)
-
-	block0cfg.BlockchainConfiguration.LinearFees.Certificate = *feesCertificate
-	block0cfg.BlockchainConfiguration.LinearFees.Coefficient = *feesCoefficient
-	block0cfg.BlockchainConfiguration.LinearFees.Constant = *feesConstant
-
-	block0cfg.BlockchainConfiguration.LinearFees.PerCertificateFees.CertificatePoolRegistration = *feesCertificatePoolRegistration
-	block0cfg.BlockchainConfiguration.LinearFees.PerCertificateFees.CertificateStakeDelegation = *feesCertificateStakeDelegation
-
-	block0cfg.BlockchainConfiguration.LinearFees.PerVoteCertificateFees.CertificateVoteCast = *feesCertificateVoteCast
-	block0cfg.BlockchainConfiguration.LinearFees.PerVoteCertificateFees.CertificateVotePlan = *feesCertificateVotePlan
-
-	block0cfg.BlockchainConfiguration.FeesGoTo = *feesGoTo
-
-	// Bft Leader
-	for i := range leaders {
-		err = block0cfg.AddConsensusLeader(leaders[i].pk)
-		kit.FatalOn(err)
-
-		// add bft leader(s) accounts to block0 (with bftLeaderFund value > 0)
-		if bftLeaderFund > 0 {
-			err = block0cfg.AddInitialFund(leaders[i].acc, bftLeaderFund)
-			kit.FatalOn(err)
+		case !env.Config.StartNode:
+			log.Println("The node has to be started manually or issue SIGINT/SIGTERM again.")
+		case env.Config.AllowNodeRestart:
+			log.Println("The node has stopped. Please start the node manually and keep the same running config or issue SIGINT/SIGTERM again.")
 		}
 
-	}
-
-	// Global Committee Members list
-	if len(committeeAuthPublicKeys) > 0 {
-		committeePubAuth := make(map[string]bool, len(committeeAuthPublicKeys))
-		for i := range committeeAuthPublicKeys {
-			// Check if committee pk is on bft leaders
-			if leadersPubKey[committeeAuthPublicKeys[i]] {
-				log.Printf("***** Duplicate Committee member on BFT Leader, skip: %s *****", committeeAuthPublicKeys[i])
-				log.Println()
-				continue
-			}
+		// Listen for the service syscalls
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+		<-sigs
 
-			if committeePubAuth[committeeAuthPublicKeys[i]] {
-				log.Printf("***** Duplicate Committee member, skip: %s *****", committeeAuthPublicKeys[i])
-				log.Println()
-				continue
-			}
-			committeePubAuth[committeeAuthPublicKeys[i]] = true
-
-			pk, err := jcli.KeyToBytes([]byte(committeeAuthPublicKeys[i]), "", "")
-			kit.FatalOn(err, kit.B2S(pk))
-			block0cfg.AddCommittee(kit.B2S(pk))
-
-			// add committee accounts to block0 (with committeeFund value > 0)
-			if committeeFund > 0 {
-				comACC, err := jcli.AddressAccount(committeeAuthPublicKeys[i], "", "")
-				kit.FatalOn(err, kit.B2S(comACC))
-				err = block0cfg.AddInitialFund(kit.B2S(comACC), committeeFund)
-				kit.FatalOn(err)
+		if env.Config.ShutdownNode {
+			// Attempt node(s) shutdown in case the node was restarted manually again
+			for _, n := range env.Nodes {
+				_, _ = jcli.RestShutdown("http://"+n.RestAddress+"/api", "")
 			}
 		}
 	}
 
-	// Proposals list per payload type
-	payloadProposals := make(map[string][]*loader.ProposalData)
-	for _, p := range *proposals.All() {
-		payloadProposals[p.VoteType] = append(payloadProposals[p.VoteType], p)
-	}
-
-	// check if we have privacy committee members when we don't have private voteplans
-	if len(payloadProposals["private"]) == 0 && len(committeePrivacyPublicKeys) > 0 {
-		kit.FatalOn(fmt.Errorf(" %s provided, but no %s proposals found", "committee-privacy-public-key", "private"))
-	}
-
-	// check we have also privacy committee members when we have private voteplans
-	if len(payloadProposals["private"]) > 0 && len(committeePrivacyPublicKeys) == 0 {
-		log.Printf("%s proposals found, but no %s provided...building one for you in %s", "private", "committee-privacy-public-key", votePlanDir)
-
-		csr, err := jcli.VotesCRSGenerate("", filepath.Join(votePlanDir, "committee.csr"))
-		kit.FatalOn(err, "jcli.VotesCRSGenerate", kit.B2S(csr))
-
-		commSKFile := filepath.Join(votePlanDir, "committee_communication_key.sk")
-		commPKFile := filepath.Join(votePlanDir, "committee_communication_key.pk")
-
-		commSK, err := jcli.VotesCommitteeCommunicationKeyGenerate("", commSKFile)
-		kit.FatalOn(err, "jcli.VotesCommitteeCommunicationKeyGenerate", kit.B2S(commSK))
-		commPK, err := jcli.VotesCommitteeCommunicationKeyToPublic(nil, commSKFile, commPKFile)
-		kit.FatalOn(err, "jcli.VotesCommitteeCommunicationKeyGenerate", kit.B2S(commPK))
-
-		memberSKFile := filepath.Join(votePlanDir, "committee_member_key.sk")
-		memberPKFile := filepath.Join(votePlanDir, "committee_member_key.pk")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = env.Stop(ctx)
 
-		memberSK, err := jcli.VotesCommitteeMemberKeyGenerate(kit.B2S(csr), 1, []string{kit.B2S(commPK)}, 0, "", "" /* memberSKFile */)
-		kit.FatalOn(err, "jcli.VotesCommitteeMemberKeyGenerate", kit.B2S(memberSK))
-		memberPK, err := jcli.VotesCommitteeMemberKeyToPublic(memberSK, "", "")
-		kit.FatalOn(err, "jcli.VotesCommitteeMemberKeyToPublic", kit.B2S(memberPK))
-
-		cf, err := os.Create(memberSKFile)
-		kit.FatalOn(err, "memberSKFile CREATE")
-		_, err = cf.Write(memberSK)
-		kit.FatalOn(err, "memberSKFile WRITE")
-		err = cf.Close()
-		kit.FatalOn(err, "memberSKFile CLOSE")
-
-		cf, err = os.Create(memberPKFile)
-		kit.FatalOn(err, "memberPKFile CREATE")
-		_, err = cf.Write(memberPK)
-		kit.FatalOn(err, "memberPKFile WRITE")
-		err = cf.Close()
-		kit.FatalOn(err, "memberPKFile CLOSE")
-
-		committeePrivacyPublicKeys = append(committeePrivacyPublicKeys, kit.B2S(memberPK))
-		log.Println()
-	}
+	log.Println("...VIT - BFT Genesis Node - Done") // All done. Node has stopped.
+}
 
-	// save vote encryption key
+// resumeCmd starts again an existing working directory, on the existing node storage.
+func resumeCmd(args []string) {
 	var (
-		voteEncKeyFile string
-		voteEncKey     []byte
+		fs        = flag.NewFlagSet("resume", flag.ExitOnError)
+		startNode = fs.Bool("start-node", true, "Start jörmungandr node")
+		startVit  = fs.Bool("start-vit", true, "Start vit-servicing-station-server")
+		proxyNode = fs.Int("proxy-node", -1, "Index of the node the REST api PROXY targets. Defaults to the working directory config")
 	)
-
-	if len(payloadProposals["private"]) > 0 && len(committeePrivacyPublicKeys) > 0 {
-		voteEncKeyFile = filepath.Join(votePlanDir, "vote_encryption_key.pk")
-
-		voteEncKey, err = jcli.VotesEncryptingVoteKey(committeePrivacyPublicKeys, "" /* voteEncKeyFile */)
-		kit.FatalOn(err, "jcli.VotesEncryptingVoteKey", kit.B2S(voteEncKey))
-
-		cf, err := os.Create(voteEncKeyFile)
-		kit.FatalOn(err, "voteEncKeyFile CREATE")
-		_, err = cf.Write(voteEncKey)
-		kit.FatalOn(err, "voteEncKeyFile WRITE")
-		err = cf.Close()
-		kit.FatalOn(err, "voteEncKeyFile CLOSE")
-	}
-
-	// Calculate nr of needed voteplans since there is a limit of proposals a plan can have (255)
-	// Taking in consideration also payload
-	vpNeeded := 0
-	for _, vpp := range payloadProposals {
-		vpNeeded += votePlansNeeded(len(vpp), int(votePlanProposalsMax))
-	}
-
-	jcliVotePlans := make([]jcliVotePlan, vpNeeded)
-	funds.First().VotePlans = make([]loader.ChainVotePlan, vpNeeded)
-
-	jcliVotePlansCreated := 0
-	for pt := range payloadProposals {
-		vpi := 0
-		// Generate proposals hash and associate it to a voteplan
-		for i, proposal := range payloadProposals[pt] {
-
-			// tmp - hash the proposal (TODO: decide what to hash in production, file bytes ???)
-			externalID := blake2b.Sum256([]byte(proposal.Proposal.ID + strconv.FormatUint(proposal.InternalID, 10) + pt))
-			proposal.ChainProposal.ExternalID = hex.EncodeToString(externalID[:])
-
-			// retrieve the voteplan internal index based on the proposal index we are at
-			// taking in consideration also previous payloads voteplans created
-			vpi = (i / int(votePlanProposalsMax)) + jcliVotePlansCreated
-
-			// Set payload once
-			if jcliVotePlans[vpi].Payload == "" {
-				jcliVotePlans[vpi].Payload = pt
-			}
-
-			// add proposal hash to the respective voteplan internal container
-			jcliVotePlans[vpi].Proposals = append(
-				jcliVotePlans[vpi].Proposals,
-				jcliProposal{
-					ExternalID:  proposal.ChainProposal.ExternalID,
-					Options:     uint8(len(proposal.ChainProposal.VoteOptions)),
-					Action:      proposal.VoteAction,
-					ChallengeID: proposal.ChallengeID,
-				},
-			)
-		}
-		jcliVotePlansCreated = jcliVotePlansCreated + vpi + 1 // vpi is an index so we need +1
-	}
-
-	certSignersFiles := make([]string, 0) //, 0, len(leaders))
-	for i := range leaders {
-		// we need a secret key
-		if leaders[i].skFile == "" {
-			continue
-		}
-		certSignersFiles = append(certSignersFiles, leaders[i].skFile)
-		break // right now only one key is needed to sign a certificate so bail as soon as we have one
-	}
-
-	if *block0Voteplans && len(certSignersFiles) == 0 {
-		kit.FatalOn(fmt.Errorf("no [%s] available to sign the block0 certificate(s)", "bft leader SK (secret key)"), "block0-voteplan")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s resume [options] <jnode_VIT_xxxxx dir>\n", os.Args[0])
+		fs.PrintDefaults()
 	}
+	_ = fs.Parse(args)
 
-	// Generate voteplan certificates and id
-	for i := range jcliVotePlans {
-
-		jcliVotePlans[i].VoteStart = voteStart
-		jcliVotePlans[i].VoteEnd = voteEnd
-		jcliVotePlans[i].CommitteeEnd = committeeEnd
-
-		// Add committee privacy public keys if VotePlan payload is private
-		switch jcliVotePlans[i].Payload {
-		case "private":
-			jcliVotePlans[i].CommitteeMemberPublicKeys = committeePrivacyPublicKeys
-		case "public":
-			jcliVotePlans[i].CommitteeMemberPublicKeys = []string{}
-		}
-
-		stdinConfig, err := json.MarshalIndent(jcliVotePlans[i], "", " ")
-		kit.FatalOn(err, "json.Marshal VotePlan Config")
-
-		ucert, err := jcli.CertificateNewVotePlan(stdinConfig, "", "")
-		kit.FatalOn(err, "CertificateNewVotePlan", kit.B2S(ucert))
-
-		id, err := jcli.CertificateGetVotePlanID(ucert, "", "")
-		kit.FatalOn(err, "CertificateGetVotePlanID:", kit.B2S(id))
-
-		jcliVotePlans[i].VotePlanID = kit.B2S(id)
-
-		// Assuming that bft leaders will be part of committee signing keys
-		scert := []byte{}
-		if *block0Voteplans {
-			scert, err = jcli.CertificateSign(ucert, certSignersFiles, "", "")
-			kit.FatalOn(err, "CertificateSign:", kit.B2S(scert))
-
-			jcliVotePlans[i].Certificate = kit.B2S(scert)
-		}
-
-		// VotePlan - configuration
-		vpj, err := os.Create(filepath.Join(votePlanDir, jcliVotePlans[i].Payload+"_voteplan_"+kit.B2S(id)+".json"))
-		kit.FatalOn(err, "VotePlan json CREATE", kit.B2S(id))
-		_, err = vpj.Write(stdinConfig)
-		kit.FatalOn(err, "VotePlan json WRITE", kit.B2S(id))
-		err = vpj.Close()
-		kit.FatalOn(err, "VotePlan json CLOSE", kit.B2S(id))
-
-		// VotePlan - unsigned certificate
-		vpuc, err := os.Create(filepath.Join(votePlanDir, jcliVotePlans[i].Payload+"_voteplan_"+kit.B2S(id)+".cert-unsigned"))
-		kit.FatalOn(err, "VotePlan cert-unsigned CREATE", kit.B2S(id))
-		_, err = vpuc.Write(ucert)
-		kit.FatalOn(err, "VotePlan cert-unsigned WRITE", kit.B2S(id))
-		err = vpuc.Close()
-		kit.FatalOn(err, "VotePlan cert-unsigned CLOSE", kit.B2S(id))
-
-		// VotePlan - signed certificate
-		if len(scert) > 0 {
-			vpsc, err := os.Create(filepath.Join(votePlanDir, jcliVotePlans[i].Payload+"_voteplan_"+kit.B2S(id)+".cert-signed"))
-			kit.FatalOn(err, "VotePlan cert-signed CREATE", kit.B2S(id))
-			_, err = vpsc.Write(scert)
-			kit.FatalOn(err, "VotePlan cert-signed WRITE", kit.B2S(id))
-			err = vpsc.Close()
-			kit.FatalOn(err, "VotePlan cert-signed CLOSE", kit.B2S(id))
-		}
-
-		// Update Fund info with VotePlans Data - TODO: when defined update to support multiple funds
-		funds.First().VotePlans[i].VotePlanID = jcliVotePlans[i].VotePlanID
-		funds.First().VotePlans[i].VoteStart = voteStartTime.Format(*dateTimeFormat)
-		funds.First().VotePlans[i].VoteEnd = voteEndTime.Format(*dateTimeFormat)
-		funds.First().VotePlans[i].CommitteeEnd = committeeEndTime.Format(*dateTimeFormat)
-		funds.First().VotePlans[i].Payload = jcliVotePlans[i].Payload
-
-		funds.First().VotePlans[i].FundID = funds.First().FundID
-		funds.First().VotePlans[i].VpInternalID = strconv.Itoa(i + 1)
-
-		// set chain_vote_encryption_key for the api
-		if jcliVotePlans[i].Payload == "private" {
-			funds.First().VotePlans[i].VoteEncryptionKey = kit.B2S(voteEncKey)
-		}
-
-		// Update proposals index and voteplan
-		for pi, prop := range jcliVotePlans[i].Proposals {
-			// TODO: fix this search
-			proposal := datastore.FilterSingle(
-				proposals.All(),
-				func(v *loader.ProposalData) bool {
-					return v.ChainProposal.ExternalID == prop.ExternalID
-				},
-			)
-
-			proposal.ChainProposal.Index = uint8(pi)
-			proposal.ChainVotePlan = &(funds.First().VotePlans[i])
-		}
-
-		if *block0Voteplans {
-			// Vote Plans add certificate to block0
-			err = block0cfg.AddInitialCertificate(jcliVotePlans[i].Certificate)
-			kit.FatalOn(err, "AddInitialCertificate")
-		}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
 	}
 
-	log.Printf("VIT - Voteplan(s) data are dumped at (%s)", votePlanDir)
-	log.Println()
+	env, err := vitenv.Resume(fs.Arg(0))
+	kit.FatalOn(err, "vitenv.Resume")
+	log.Printf("Working Directory: %s", env.WorkingDir)
 
-	//////////////////////////////////////////////
-	/* TODO: TMP - remove once/if properly defined */
-	if funds.First().StartTime == "" {
-		funds.First().StartTime = voteStartTime.Format(*dateTimeFormat)
-	}
-	if funds.First().EndTime == "" {
-		funds.First().EndTime = voteEndTime.Format(*dateTimeFormat)
-	}
-	if funds.First().VotingPowerInfo == "" {
-		funds.First().VotingPowerInfo = funds.First().StartTime
+	env.Config.StartNode = *startNode
+	env.Config.StartVit = *startVit
+	if *proxyNode >= 0 {
+		env.Config.ProxyNode = uint(*proxyNode)
 	}
-	if funds.First().RewardsInfo == "" {
-		funds.First().RewardsInfo = committeeEndTime.Add(7 * epochDur).Format(*dateTimeFormat)
-	}
-	if funds.First().NextStartTime == "" {
-		funds.First().NextStartTime = committeeEndTime.Add(15 * epochDur).Format(*dateTimeFormat)
-	}
-	/* TODO: TMP - remove once/if properly defined */
-	//////////////////////////////////////////////
-
-	// FUNDS - dump
-	fundsFile, err := os.Create(filepath.Join(vitStationDir, "sql_funds.csv"))
-	kit.FatalOn(err, "Funds csv CREATE")
-	f := []*loader.FundData{funds.First()}
-	err = gocsv.MarshalFile(&f, fundsFile) // Use this to save the CSV back to the file
-	kit.FatalOn(err, "Funds csv WRITE")
-	err = fundsFile.Close()
-	kit.FatalOn(err, "Funds csv CLOSE")
-
-	// VOTEPLANS - dump
-	votePlansFile, err := os.Create(filepath.Join(vitStationDir, "sql_voteplans.csv"))
-	kit.FatalOn(err, "Voteplans csv CREATE")
-	vp := funds.First().VotePlans
-	err = gocsv.MarshalFile(&vp, votePlansFile)
-	kit.FatalOn(err, "Voteplans csv WRITE")
-	err = votePlansFile.Close()
-	kit.FatalOn(err, "Voteplans csv CLOSE")
-
-	// PROPOSALS - dump
-	proposalsFile, err := os.Create(filepath.Join(vitStationDir, "sql_proposals.csv"))
-	kit.FatalOn(err, "Proposals csv CREATE")
-	p := proposals.All()
-	err = gocsv.MarshalFile(p, proposalsFile)
-	kit.FatalOn(err, "Proposals csv WRITE")
-	err = proposalsFile.Close()
-	kit.FatalOn(err, "Proposals csv CLOSE")
-
-	block0Yaml, err := block0cfg.ToYaml()
-	kit.FatalOn(err)
 
-	if *genesisExtraDataPath != "" {
-		bulkExtraData, err := ioutil.ReadFile(*genesisExtraDataPath)
-		kit.FatalOn(err)
+	run(env)
+}
 
-		if len(bulkExtraData) > 0 {
-			if len(block0cfg.Initial) == 0 {
-				block0Yaml = append(block0Yaml, "\ninitial:\n"...)
-			}
-			block0Yaml = append(block0Yaml, bulkExtraData...)
-		}
+// onChain submits the voteplans (post genesis) and then the public voteplans tally, if enabled, to the proxy target node.
+func onChain(ctx context.Context, env *vitenv.Environment) {
+	node, err := env.ProxyNode()
+	if err == nil && env.Config.SubmitVotePlans {
+		err = env.SubmitVotePlans(ctx, node)
 	}
-
-	// need this file for starting the node (--genesis-block)
-	block0BinFile := filepath.Join(workingDir, "VIT-block0.bin")
-
-	// keep also the text block0 config
-	block0TxtFile := filepath.Join(workingDir, "VIT-block0.yaml")
-
-	// block0BinFile will be created by jcli
-	block0Bin, err := jcli.GenesisEncode(block0Yaml, "", block0BinFile)
-	kit.FatalOn(err, kit.B2S(block0Bin), kit.B2S(block0Yaml))
-
-	block0Hash, err := jcli.GenesisHash(block0Bin, "")
-	kit.FatalOn(err, kit.B2S(block0Hash))
-
-	// block0TxtFile will be created by jcli
-	block0Txt, err := jcli.GenesisDecode(block0Bin, "", block0TxtFile)
-	kit.FatalOn(err, kit.B2S(block0Txt))
-
-	//////////////////////
-	//  secrets config  //
-	//////////////////////
-
-	for i := range leaders {
-		// we need secret key, but only public ones may have been provided
-		if leaders[i].sk == "" {
-			continue
-		}
-
-		secretCfg := jnode.NewSecretConfig()
-
-		secretCfg.Bft.SigningKey = leaders[i].sk
-
-		secretCfgYaml, err := secretCfg.ToYaml()
-		kit.FatalOn(err)
-
-		// need this file for starting the node (--secret)
-		secretCfgFile := leaders[i].skFile + ".yaml"
-		err = ioutil.WriteFile(secretCfgFile, secretCfgYaml, 0744)
-		kit.FatalOn(err)
-
-		leaders[i].cfgFile = secretCfgFile
+	if err == nil && env.Config.AutoTally {
+		err = env.TallyPublic(ctx, node)
 	}
-
-	///////////////////
-	//  node config  //
-	///////////////////
-
-	nodeCfg := jnode.NewNodeConfig()
-
-	nodeCfg.Storage = filepath.Join(workingDir, "storage")
-
-	nodeCfg.SkipBootstrap = *skipBootstrap
-	nodeCfg.BootstrapFromTrustedPeers = true
-
-	nodeCfg.Rest.Listen = restAddress
-	nodeCfg.Rest.Cors.AllowedOrigins = strings.Split(*restCorsAllowed, ",")
-	nodeCfg.Rest.Cors.MaxAgeSecs = 0
-
-	nodeCfg.P2P.PublicAddress = p2pListenAddress
-	nodeCfg.P2P.ListenAddress = p2pListenAddress
-	nodeCfg.P2P.AllowPrivateAddresses = true
-	nodeCfg.P2P.MaxBootstrapAttempts = 5
-
-	nodeCfg.Log.Level = *nodeLogLevel
-
-	nodeCfg.Explorer.Enabled = *explorerEnabled
-
-	for i := range leaders {
-		// we need secret key to build config file, but only public ones may have been provided
-		if leaders[i].cfgFile == "" {
-			continue
-		}
-		nodeCfg.AddSecretFile(leaders[i].cfgFile)
+	if err != nil && err != context.Canceled {
+		log.Printf("FAILED: %v", err)
 	}
+}
 
-	nodeCfgYaml, err := nodeCfg.ToYaml()
-	kit.FatalOn(err)
-
-	// need this file for starting the node (--config)
-	nodeCfgFile := filepath.Join(workingDir, "node-config.yaml")
-	err = ioutil.WriteFile(nodeCfgFile, nodeCfgYaml, 0644)
-	kit.FatalOn(err)
-
-	//////////////////////
-	// running the node //
-	//////////////////////
-
-	// Check for jörmungandr binary. Local folder first, then PATH
-	jnodeBin, err := kit.FindExecutable("jormungandr", "jor_bins")
-	kit.FatalOn(err, jnodeBin)
-	jnode.BinName(jnodeBin)
-
-	// get jörmungandr version
-	jormungandrVersion, err := jnode.VersionFull()
-	kit.FatalOn(err, kit.B2S(jormungandrVersion))
-
-	node := jnode.NewJnode()
-
-	node.WorkingDir = workingDir
-	node.GenesisBlock = block0BinFile
-	node.ConfigFile = nodeCfgFile
-
-	for i := range leaders {
-		// we need secret key to build config file, but only public ones may have been provided so no leader config possible
-		if leaders[i].cfgFile == "" {
-			continue
-		}
-		node.AddSecretFile(leaders[i].cfgFile)
-	}
-
-	// Run the node (Start + Wait)
-	if *startNode {
-		node.Stdout, err = os.Create(filepath.Join(workingDir, "stdout.log"))
-		kit.FatalOn(err)
-		node.Stderr, err = os.Create(filepath.Join(workingDir, "stderr.log"))
-		kit.FatalOn(err)
-
-		err = os.Setenv("RUST_BACKTRACE", "full")
-		kit.FatalOn(err, "Failed to set env (RUST_BACKTRACE=full)")
-
-		err = node.Run()
-		if err != nil {
-			log.Fatalf("node.Run FAILED: %v", err)
-		}
-	}
-
-	//////////////////////
-	// VIT station data //
-	//////////////////////
-
-	// vit-servicing-station-cli
+// tallyCmd submits the voteplans tally (public or private) of an existing (and running) working directory environment.
+func tallyCmd(name string, args []string, tally func(*vitenv.Environment, context.Context, *vitenv.NetworkNode) error) {
 	var (
-		vcliBin     string
-		vcliVersion []byte
-
-		vitDb      = filepath.Join(vitStationDir, "database.sqlite3")
-		vitCfgFile = filepath.Join(vitStationDir, "vit_cfg.json")
+		fs   = flag.NewFlagSet(name, flag.ExitOnError)
+		node = fs.Int("node", -1, "Index of the node the tally is submitted to. Defaults to the working directory \"proxy-node\"")
 	)
-
-	// Check for vit-servicing-station-cli binary. Local folder first (vit_bins), then PATH
-	vcliBin, err = kit.FindExecutable("vit-servicing-station-cli", "vit_bins")
-	if err != nil {
-		log.Printf("***** %s - DB data related to %s will NOT be generated", err.Error(), "vit-servicing-station")
-		vcliBin = ""
-	} else {
-		vcli.BinName(vcliBin)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s [options] <jnode_VIT_xxxxx dir>\n", os.Args[0], name)
+		fs.PrintDefaults()
 	}
+	_ = fs.Parse(args)
 
-	if vcliBin != "" {
-		// get vit-servicing-station-cli version
-		vcliVersion, err = vcli.Version()
-		kit.FatalOn(err, kit.B2S(vcliVersion))
-
-		// init database
-		out, err := vcli.DbInit(vitDb)
-		kit.FatalOn(err, "vcli.DbInit", kit.B2S(out))
-
-		// populate the database with already dumped data
-		out, err = vcli.CsvDataLoad(vitDb, fundsFile.Name(), proposalsFile.Name(), *challengesPath, votePlansFile.Name())
-		kit.FatalOn(err, "vcli.CsvDataLoad", kit.B2S(out))
-	}
-
-	// vit-servicing-station-server
-	var (
-		vstationBin     string
-		vstationVersion []byte
-	)
-
-	vs := vstation.NewVstation()
-	vs.WorkingDir = vitStationDir
-	vs.Address = *vitAddrPort
-	vs.Block0Path = block0BinFile
-	vs.DbUrl = vitDb
-	vs.Log.LogLevel = *vitLogLevel
-	vs.Log.LogOutputPath = filepath.Join(vitStationDir, "vit_station.log")
-	vs.Cors.AllowedOrigins = strings.Split(*restCorsAllowed, ",")
-
-	vsJson, err := json.MarshalIndent(&vs, "", " ")
-	kit.FatalOn(err, "vstation json.MarshalIndent")
-	err = ioutil.WriteFile(vitCfgFile, vsJson, 0755)
-	kit.FatalOn(err, "vstation ioutil.WriteFile", vitCfgFile)
-
-	// Check for vit-servicing-station-server binary. Local folder first (vit_bins), then PATH
-	vstationBin, err = kit.FindExecutable("vit-servicing-station-server", "vit_bins")
-	if err != nil {
-		log.Printf("***** %s", err.Error())
-		vstationBin = ""
-	} else {
-		vstation.BinName(vstationBin)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
 	}
 
-	if vstationBin != "" {
-		// get vit-servicing-station-server version
-		vstationVersion, err = vstation.Version()
-		kit.FatalOn(err, kit.B2S(vstationVersion))
+	env, err := vitenv.Resume(fs.Arg(0))
+	kit.FatalOn(err, "vitenv.Resume")
 
-		if *startVit {
-			err = vs.Run()
-			if err != nil {
-				log.Fatalf("vs.Run FAILED: %v", err)
-			}
-		}
+	if *node >= 0 {
+		env.Config.ProxyNode = uint(*node)
 	}
+	target, err := env.ProxyNode()
+	kit.FatalOn(err, "node")
 
-	////////////////////
-	// internal proxy //
-	////////////////////
-
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 	go func() {
-		err := webproxy.Run(proposals, funds, &block0Bin, proxyAddress, "http://"+restAddress)
-		if err != nil {
-			kit.FatalOn(err, "Proxy Run")
-		}
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+		<-sigs
+		cancel()
 	}()
 
-	log.Println()
-	log.Printf("OS: %s, ARCH: %s", runtime.GOOS, runtime.GOARCH)
-	log.Println()
-	log.Printf("jcli: %s", jcliBin)
-	log.Printf("ver : %s", jcliVersion)
-	log.Println()
-	log.Printf("node: %s", jnodeBin)
-	log.Printf("ver : %s", jormungandrVersion)
-	log.Println()
-
-	log.Printf("VIT - BFT Genesis Hash: %s\n", kit.B2S(block0Hash))
-	log.Println()
-	log.Printf("VIT - BFT Genesis: %s - %d", "COMMITTEE", len(block0cfg.BlockchainConfiguration.Committees)+len(block0cfg.BlockchainConfiguration.ConsensusLeaderIds))
-	log.Printf("VIT - BFT Genesis: %s - %d", "VOTEPLANS", len(jcliVotePlans))
-	log.Printf("VIT - BFT Genesis: %s - %d", "PROPOSALS", proposals.Total())
-	log.Println()
-
-	log.Printf("JÖRMUNGANDR listening at: %s - %v", p2pListenAddress, *startNode)
-	log.Printf("JÖRMUNGANDR Rest API available at: http://%s/api - %v", restAddress, *startNode)
-	log.Println()
-	log.Printf("VIT-STATION API available at: http://%s/api - %v", *vitAddrPort, *startVit)
-	log.Println()
-	log.Printf("APP - PROXY Rest API available at: http://%s/api", proxyAddress)
-	log.Println()
-	log.Println("VIT - BFT Genesis Node - Running...")
-	log.Println()
+	err = tally(env, ctx, target)
+	kit.FatalOn(err, name)
+}
 
-	if vstationBin != "" {
-		log.Printf("\t%s %s", vstationBin, strings.Join(vs.BuildCmdArg(), " "))
-		log.Println()
+// voteLoadCmd casts the working directory test voters votes, through the proxy, and reports the outcome.
+func voteLoadCmd(args []string) {
+	var (
+		load = vitenv.DefaultVoteLoad()
+		fs   = flag.NewFlagSet("vote-load", flag.ExitOnError)
+		node = fs.Int("node", -1, "Index of the node checked for the vote start and the voters counters. Defaults to the working directory \"proxy-node\"")
+	)
+	fs.StringVar(&load.Proxy, "proxy", "", "Address (IP:PORT) of the PROXY the fragments are submitted to (/api/v0/message). Defaults to the working directory \"proxy\"")
+	fs.IntVar(&load.Votes, "votes", load.Votes, "Number of votes to cast, at most one per voter and proposal")
+	fs.Float64Var(&load.Rate, "rate", load.Rate, "Target rate of submitted fragments per second (0 - 1000000]")
+	fs.IntVar(&load.Workers, "workers", load.Workers, "Number of concurrent jcli fragments builders")
+	fs.Var((*float64sFlag)(&load.Choices), "choices", "Comma separated weight of each option choice (ex: blank,yes,no). The missing ones weigh as the last one")
+	fs.Float64Var(&load.Zipf, "zipf", load.Zipf, "Proposals popularity skew (zipf s > 1). 0 for a uniform distribution")
+	fs.Int64Var(&load.Seed, "seed", load.Seed, "Seed of the votes distribution")
+	fs.DurationVar(&load.Wait, "wait", load.Wait, "Max wait for the accepted fragments to get in a block")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s vote-load [options] <jnode_VIT_xxxxx dir>\n", os.Args[0])
+		fs.PrintDefaults()
 	}
+	_ = fs.Parse(args)
 
-	log.Printf("\t%s %s", jnodeBin, strings.Join(node.BuildCmdArg(), " "))
-	log.Println()
-
-	if *startVit && vstationBin != "" {
-		vs.Wait() // Wait for the vit station to stop.
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
 	}
 
-	if *startNode {
-		node.Wait() // Wait for the node to stop.
-	}
+	env, err := vitenv.Resume(fs.Arg(0))
+	kit.FatalOn(err, "vitenv.Resume")
 
-	if *allowNodeRestart || !*startNode {
-		switch {
-		case !*startNode:
-			log.Println("The node has to be started manually or issue SIGINT/SIGTERM again.")
-		case *allowNodeRestart:
-			log.Println("The node has stopped. Please start the node manually and keep the same running config or issue SIGINT/SIGTERM again.")
-		}
+	if *node >= 0 {
+		env.Config.ProxyNode = uint(*node)
+	}
+	target, err := env.ProxyNode()
+	kit.FatalOn(err, "node")
 
-		// Listen for the service syscalls
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
 		sigs := make(chan os.Signal, 1)
 		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 		<-sigs
+		cancel()
+	}()
 
-		if *shutdownNode {
-			// Attempt node shutdown in case the node was restarted manually again
-			_, _ = jcli.RestShutdown("http://"+restAddress+"/api", "")
-		}
-	}
+	report, err := env.CastVotes(ctx, target, load)
+	kit.FatalOn(err, "vote-load")
 
-	log.Println("...VIT - BFT Genesis Node - Done") // All done. Node has stopped.
+	log.Printf("VOTELOAD - %d votes (%d voters, %d proposals) submitted in %s, %.2f/s (target %v/s)", report.Submitted, report.Voters, report.Proposals, report.Duration, report.Rate, report.TargetRate)
+	log.Printf("VOTELOAD - accepted: %d, in a block: %d, pending: %d", report.Accepted, report.InABlock, report.Pending)
+	for reason, n := range report.Refused {
+		log.Printf("VOTELOAD - refused: %d - %s", n, reason)
+	}
+	for reason, n := range report.Rejected {
+		log.Printf("VOTELOAD - rejected: %d - %s", n, reason)
+	}
+	log.Printf("VOTELOAD - latency ms: p50 %.1f, p90 %.1f, p95 %.1f, p99 %.1f, max %.1f", report.Latency.P50, report.Latency.P90, report.Latency.P95, report.Latency.P99, report.Latency.Max)
+	log.Printf("VOTELOAD - report: %s", filepath.Join(env.WorkingDir, vitenv.VoteLoadDir, vitenv.VoteLoadReportFile))
 }
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testAuditBundle(t *testing.T, dir string) *AuditManifest {
+	t.Helper()
+	raw := map[string][]byte{
+		auditVotePlansFile: []byte(`[]`),
+		auditProposalsFile: []byte(`[]`),
+		auditFundsFile:     []byte(`{}`),
+	}
+	manifest := &AuditManifest{Version: "test"}
+	for _, file := range []string{auditVotePlansFile, auditProposalsFile, auditFundsFile} {
+		manifest.Entries = append(manifest.Entries, NewAuditEntry(file, "file://"+file, time.Now(), raw[file]))
+	}
+	if err := writeAuditBundle(dir, manifest, raw); err != nil {
+		t.Fatalf("writeAuditBundle: %v", err)
+	}
+	return manifest
+}
+
+func TestAuditBundleRoundTrip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "bundle")
+	manifest := testAuditBundle(t, dir)
+
+	got, err := readAuditBundle(dir)
+	if err != nil {
+		t.Fatalf("readAuditBundle: %v", err)
+	}
+	if len(got.Entries) != len(manifest.Entries) {
+		t.Fatalf("entries: got %d, want %d", len(got.Entries), len(manifest.Entries))
+	}
+
+	archive := dir + ".tar.gz"
+	if err = tarAuditBundle(dir, manifest, archive); err != nil {
+		t.Fatalf("tarAuditBundle: %v", err)
+	}
+	untarDir, err := untarAuditBundle(archive)
+	if err != nil {
+		t.Fatalf("untarAuditBundle: %v", err)
+	}
+	defer os.RemoveAll(untarDir)
+	if _, err = readAuditBundle(untarDir); err != nil {
+		t.Fatalf("readAuditBundle untar: %v", err)
+	}
+}
+
+func TestReadAuditBundleErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(t *testing.T, dir string, manifest *AuditManifest)
+		errMsg string
+	}{
+		{
+			name: "hash mismatch",
+			modify: func(t *testing.T, dir string, manifest *AuditManifest) {
+				writeTestFile(t, filepath.Join(dir, auditProposalsFile), []byte(`[{}]`))
+			},
+			errMsg: "blake2b mismatch",
+		},
+		{
+			name: "entry outside of bundle",
+			modify: func(t *testing.T, dir string, manifest *AuditManifest) {
+				manifest.Entries[0].File = "../" + auditVotePlansFile
+				writeTestManifest(t, dir, manifest)
+			},
+			errMsg: "wrong manifest entry",
+		},
+		{
+			name: "entry in sub directory",
+			modify: func(t *testing.T, dir string, manifest *AuditManifest) {
+				manifest.Entries[0].File = "sub/" + auditVotePlansFile
+				writeTestManifest(t, dir, manifest)
+			},
+			errMsg: "wrong manifest entry",
+		},
+		{
+			name: "entry parent directory",
+			modify: func(t *testing.T, dir string, manifest *AuditManifest) {
+				manifest.Entries[0].File = ".."
+				writeTestManifest(t, dir, manifest)
+			},
+			errMsg: "wrong manifest entry",
+		},
+		{
+			name: "entry empty",
+			modify: func(t *testing.T, dir string, manifest *AuditManifest) {
+				manifest.Entries[0].File = ""
+				writeTestManifest(t, dir, manifest)
+			},
+			errMsg: "wrong manifest entry",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			manifest := testAuditBundle(t, dir)
+			tt.modify(t, dir, manifest)
+
+			_, err := readAuditBundle(dir)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Fatalf("readAuditBundle: got %v, want error containing %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestUntarAuditBundleCleanup(t *testing.T) {
+	tmp := t.TempDir()
+	defer os.Setenv("TMPDIR", os.Getenv("TMPDIR"))
+	os.Setenv("TMPDIR", tmp)
+
+	// valid gzip, truncated tar
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	if err := tw.WriteHeader(&tar.Header{Name: auditManifestFile, Mode: 0644, Size: 1024}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+	gw.Close()
+
+	archive := filepath.Join(t.TempDir(), "broken.tar.gz")
+	writeTestFile(t, archive, buf.Bytes())
+
+	if _, err := untarAuditBundle(archive); err == nil {
+		t.Fatal("untarAuditBundle: expected error on truncated archive")
+	}
+	left, err := ioutil.ReadDir(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != 0 {
+		t.Fatalf("untarAuditBundle: temporary dir left behind: %s", left[0].Name())
+	}
+}
+
+func TestTarAuditBundleReproducible(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "bundle")
+	manifest := testAuditBundle(t, dir)
+	manifest.Created = "2021-03-04T05:06:07.891Z"
+
+	var archives [2][]byte
+	for i := range archives {
+		archive := filepath.Join(t.TempDir(), "bundle.tar.gz")
+		if err := tarAuditBundle(dir, manifest, archive); err != nil {
+			t.Fatalf("tarAuditBundle: %v", err)
+		}
+		archives[i] = readTestFile(t, archive)
+		if i == 0 {
+			time.Sleep(1100 * time.Millisecond) // a different wall clock second
+		}
+	}
+	if !bytes.Equal(archives[0], archives[1]) {
+		t.Fatal("tarAuditBundle: archives of the same bundle differ")
+	}
+
+	gr, err := gzip.NewReader(bytes.NewReader(archives[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gr)
+	want := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		if !hdr.ModTime.Equal(want) || hdr.Uid != 0 || hdr.Gid != 0 || hdr.Uname != "" || hdr.Gname != "" {
+			t.Errorf("%s: got mtime %s, owner %d:%d %q:%q", hdr.Name, hdr.ModTime, hdr.Uid, hdr.Gid, hdr.Uname, hdr.Gname)
+		}
+	}
+}
+
+func TestOpenAuditBundleCleanup(t *testing.T) {
+	tmp := t.TempDir()
+	defer os.Setenv("TMPDIR", os.Getenv("TMPDIR"))
+	os.Setenv("TMPDIR", tmp)
+
+	leftBehind := func() int {
+		left, err := ioutil.ReadDir(tmp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(left)
+	}
+
+	dir := filepath.Join(t.TempDir(), "bundle")
+	manifest := testAuditBundle(t, dir)
+	archive := dir + ".tar.gz"
+	if err := tarAuditBundle(dir, manifest, archive); err != nil {
+		t.Fatalf("tarAuditBundle: %v", err)
+	}
+
+	bundleDir, cleanup, err := openAuditBundle(archive)
+	if err != nil {
+		t.Fatalf("openAuditBundle: %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(bundleDir, auditFundsFile)); err != nil {
+		t.Fatalf("openAuditBundle: %v", err)
+	}
+	cleanup()
+	if n := leftBehind(); n != 0 {
+		t.Fatalf("cleanup: %d temporary dirs left behind", n)
+	}
+
+	// the fund input is missing, the extracted dir goes away with the error
+	manifest.Entries = manifest.Entries[:2]
+	writeTestManifest(t, dir, manifest)
+	if err = tarAuditBundle(dir, manifest, archive); err != nil {
+		t.Fatalf("tarAuditBundle: %v", err)
+	}
+	if _, _, err = openAuditBundle(archive); err == nil || !strings.Contains(err.Error(), auditFundsFile) {
+		t.Fatalf("openAuditBundle: got %v, want %s not found", err, auditFundsFile)
+	}
+	if n := leftBehind(); n != 0 {
+		t.Fatalf("openAuditBundle: %d temporary dirs left behind", n)
+	}
+
+	// unsigned bundle
+	pk, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = verifyTarget(pk, archive, ""); err == nil {
+		t.Fatal("verifyTarget: expected error on unsigned bundle")
+	}
+	if n := leftBehind(); n != 0 {
+		t.Fatalf("verifyTarget: %d temporary dirs left behind", n)
+	}
+}
+
+func readTestFile(t *testing.T, file string) []byte {
+	t.Helper()
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func writeTestFile(t *testing.T, file string, data []byte) {
+	t.Helper()
+	if err := ioutil.WriteFile(file, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeTestManifest(t *testing.T, dir string, manifest *AuditManifest) {
+	t.Helper()
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(dir, auditManifestFile), data)
+}
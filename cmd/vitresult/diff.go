@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gocarina/gocsv"
+	"github.com/input-output-hk/jorvit/internal/kit"
+	"github.com/input-output-hk/jorvit/pkg/vresult"
+)
+
+// ResultRecord is the subset of a result needed to compare two of them.
+type ResultRecord struct {
+	InternalID           uint64 `json:"internal_id"       csv:"internal_id"`
+	ProposalID           string `json:"proposal_id"       csv:"proposal_id"`
+	Title                string `json:"proposal_title"    csv:"proposal_title"`
+	ChainProposalID      string `json:"chain_proposal_id" csv:"chain_proposal_id"`
+	VotePlanID           string `json:"chain_voteplan_id" csv:"chain_voteplan_id"`
+	VotesCast            uint   `json:"votes_cast"        csv:"votes_cast"`
+	vresult.TallyOptions `json:"-"`
+	Tally                []uint `json:"tally"             csv:"-"`
+}
+
+// loadResultRecords reads a result csv file or an audit bundle (directory or .tar.gz).
+func loadResultRecords(path string) ([]ResultRecord, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []ResultRecord
+
+	if !info.IsDir() && !isTarGz(path) {
+		rf, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer rf.Close()
+
+		err = gocsv.Unmarshal(rf, &records)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		bundleDir := path
+		if isTarGz(path) {
+			bundleDir, err = untarAuditBundle(path)
+			if err != nil {
+				return nil, err
+			}
+			defer os.RemoveAll(bundleDir)
+		}
+		if _, err = readAuditBundle(bundleDir); err != nil {
+			return nil, err
+		}
+
+		vpFile, err := os.Open(filepath.Join(bundleDir, auditVotePlansFile))
+		if err != nil {
+			return nil, err
+		}
+		defer vpFile.Close()
+		prFile, err := os.Open(filepath.Join(bundleDir, auditProposalsFile))
+		if err != nil {
+			return nil, err
+		}
+		defer prFile.Close()
+
+		results, err := vresult.Results(vpFile, prFile)
+		if err != nil {
+			return nil, err
+		}
+
+		records = make([]ResultRecord, 0, len(results))
+		for _, p := range results {
+			record := ResultRecord{
+				InternalID:      p.InternalID,
+				ProposalID:      p.Proposal.ID,
+				Title:           p.Title,
+				ChainProposalID: p.ChainProposal.ExternalID,
+				VotesCast:       p.VotesCast,
+				TallyOptions:    p.TallyOptions,
+			}
+			if p.ChainVotePlan != nil {
+				record.VotePlanID = p.VotePlanID
+			}
+			records = append(records, record)
+		}
+	}
+
+	for i := range records {
+		records[i].Tally = records[i].TallyOptions.Values()
+	}
+	return records, nil
+}
+
+// ValueChange is the old and new value of a single result field.
+type ValueChange struct {
+	Field string `json:"field"`
+	Old   uint   `json:"old"`
+	New   uint   `json:"new"`
+	Delta int64  `json:"delta"`
+}
+
+// ProposalChange lists the changed fields of a proposal present in both results.
+type ProposalChange struct {
+	InternalID uint64        `json:"internal_id"`
+	ProposalID string        `json:"proposal_id"`
+	Title      string        `json:"proposal_title"`
+	Changes    []ValueChange `json:"changes"`
+}
+
+// ResultDiff is the outcome of comparing two results.
+type ResultDiff struct {
+	Old       string           `json:"old"`
+	New       string           `json:"new"`
+	Added     []ResultRecord   `json:"added"`
+	Removed   []ResultRecord   `json:"removed"`
+	Changed   []ProposalChange `json:"changed"`
+	Unchanged int              `json:"unchanged"`
+}
+
+func valueChange(field string, old uint, new uint) ValueChange {
+	return ValueChange{
+		Field: field,
+		Old:   old,
+		New:   new,
+		Delta: int64(new) - int64(old),
+	}
+}
+
+// tallyOption returns the tally result of option, 0 if not available.
+func tallyOption(tally []uint, option int) uint {
+	if option < len(tally) {
+		return tally[option]
+	}
+	return 0
+}
+
+// diffResults compares the proposals of two results by their internal id.
+func diffResults(oldRecords []ResultRecord, newRecords []ResultRecord) ResultDiff {
+	diff := ResultDiff{
+		Added:   []ResultRecord{},
+		Removed: []ResultRecord{},
+		Changed: []ProposalChange{},
+	}
+
+	newByID := make(map[uint64]ResultRecord, len(newRecords))
+	for _, r := range newRecords {
+		newByID[r.InternalID] = r
+	}
+
+	oldByID := make(map[uint64]bool, len(oldRecords))
+	for _, o := range oldRecords {
+		oldByID[o.InternalID] = true
+
+		n, ok := newByID[o.InternalID]
+		if !ok {
+			diff.Removed = append(diff.Removed, o)
+			continue
+		}
+
+		var changes []ValueChange
+		if o.VotesCast != n.VotesCast {
+			changes = append(changes, valueChange("votes_cast", o.VotesCast, n.VotesCast))
+		}
+		// compare over the union of the options, missing ones count as 0
+		options := len(o.Tally)
+		if len(n.Tally) > options {
+			options = len(n.Tally)
+		}
+		for opt := 0; opt < options; opt++ {
+			oldTally, newTally := tallyOption(o.Tally, opt), tallyOption(n.Tally, opt)
+			if oldTally != newTally {
+				changes = append(changes, valueChange("tally_"+strconv.Itoa(opt), oldTally, newTally))
+			}
+		}
+
+		if len(changes) == 0 {
+			diff.Unchanged++
+			continue
+		}
+		diff.Changed = append(diff.Changed, ProposalChange{
+			InternalID: n.InternalID,
+			ProposalID: n.ProposalID,
+			Title:      n.Title,
+			Changes:    changes,
+		})
+	}
+
+	for _, n := range newRecords {
+		if !oldByID[n.InternalID] {
+			diff.Added = append(diff.Added, n)
+		}
+	}
+
+	return diff
+}
+
+// writeDiffText prints the diff in a human readable format.
+func writeDiffText(w io.Writer, diff ResultDiff) {
+	fmt.Fprintf(w, "--- %s\n", diff.Old)
+	fmt.Fprintf(w, "+++ %s\n", diff.New)
+
+	for _, r := range diff.Removed {
+		fmt.Fprintf(w, "- %d [%s] %s - votes_cast: %d, tally: %v\n", r.InternalID, r.ProposalID, r.Title, r.VotesCast, r.Tally)
+	}
+	for _, r := range diff.Added {
+		fmt.Fprintf(w, "+ %d [%s] %s - votes_cast: %d, tally: %v\n", r.InternalID, r.ProposalID, r.Title, r.VotesCast, r.Tally)
+	}
+	for _, c := range diff.Changed {
+		fmt.Fprintf(w, "~ %d [%s] %s\n", c.InternalID, c.ProposalID, c.Title)
+		for _, vc := range c.Changes {
+			fmt.Fprintf(w, "    %s: %d -> %d (%+d)\n", vc.Field, vc.Old, vc.New, vc.Delta)
+		}
+	}
+
+	fmt.Fprintf(w, "added: %d, removed: %d, changed: %d, unchanged: %d\n", len(diff.Added), len(diff.Removed), len(diff.Changed), diff.Unchanged)
+}
+
+// diffCmd - vitresult diff [-format text|json] [-output file] <old> <new>
+func diffCmd(args []string) {
+	var (
+		fs     = flag.NewFlagSet("diff", flag.ExitOnError)
+		format = fs.String("format", "text", "Output format, [text, json]")
+		output = fs.String("output", "", "File to write the diff to. If not set STDOUT will be used")
+	)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s diff [options] <old result|bundle> <new result|bundle>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *format != "text" && *format != "json" {
+		log.Fatalf("%s - expected to be one of (%s, %s) - but [%s] provided", "format", "text", "json", *format)
+	}
+
+	oldRecords, err := loadResultRecords(fs.Arg(0))
+	kit.FatalOn(err, "loadResultRecords", fs.Arg(0))
+	newRecords, err := loadResultRecords(fs.Arg(1))
+	kit.FatalOn(err, "loadResultRecords", fs.Arg(1))
+
+	diff := diffResults(oldRecords, newRecords)
+	diff.Old, diff.New = fs.Arg(0), fs.Arg(1)
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		df, err := os.Create(*output)
+		kit.FatalOn(err, "diff CREATE", *output)
+		defer df.Close()
+		w = df
+	}
+
+	switch *format {
+	case "json":
+		diffJson, err := json.MarshalIndent(diff, "", "  ")
+		kit.FatalOn(err, "diff json.MarshalIndent")
+		_, err = w.Write(append(diffJson, '\n'))
+		kit.FatalOn(err, "diff WRITE")
+	default:
+		writeDiffText(w, diff)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffResults(t *testing.T) {
+	record := func(id uint64, votesCast uint, tally ...uint) ResultRecord {
+		return ResultRecord{InternalID: id, ProposalID: "p", Title: "title", VotesCast: votesCast, Tally: tally}
+	}
+
+	tests := []struct {
+		name      string
+		old       []ResultRecord
+		new       []ResultRecord
+		added     []uint64
+		removed   []uint64
+		changed   map[uint64][]ValueChange
+		unchanged int
+	}{
+		{
+			name:      "unchanged",
+			old:       []ResultRecord{record(1, 2, 0, 1, 1)},
+			new:       []ResultRecord{record(1, 2, 0, 1, 1)},
+			unchanged: 1,
+		},
+		{
+			name:      "added",
+			old:       []ResultRecord{record(1, 0)},
+			new:       []ResultRecord{record(1, 0), record(2, 1, 0, 1, 0)},
+			added:     []uint64{2},
+			unchanged: 1,
+		},
+		{
+			name:      "removed",
+			old:       []ResultRecord{record(1, 0), record(2, 1, 0, 1, 0)},
+			new:       []ResultRecord{record(2, 1, 0, 1, 0)},
+			removed:   []uint64{1},
+			unchanged: 1,
+		},
+		{
+			name: "changed votes cast and tally",
+			old:  []ResultRecord{record(1, 1, 0, 1, 0)},
+			new:  []ResultRecord{record(1, 3, 0, 2, 1)},
+			changed: map[uint64][]ValueChange{
+				1: {
+					{Field: "votes_cast", Old: 1, New: 3, Delta: 2},
+					{Field: "tally_1", Old: 1, New: 2, Delta: 1},
+					{Field: "tally_2", Old: 0, New: 1, Delta: 1},
+				},
+			},
+		},
+		{
+			name: "new tally with more options",
+			old:  []ResultRecord{record(1, 2)},
+			new:  []ResultRecord{record(1, 2, 0, 2)},
+			changed: map[uint64][]ValueChange{
+				1: {{Field: "tally_1", Old: 0, New: 2, Delta: 2}},
+			},
+		},
+		{
+			name: "new tally with less options",
+			old:  []ResultRecord{record(1, 2, 0, 1, 1)},
+			new:  []ResultRecord{record(1, 2, 0, 1)},
+			changed: map[uint64][]ValueChange{
+				1: {{Field: "tally_2", Old: 1, New: 0, Delta: -1}},
+			},
+		},
+	}
+
+	ids := func(records []ResultRecord) []uint64 {
+		var res []uint64
+		for _, r := range records {
+			res = append(res, r.InternalID)
+		}
+		return res
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := diffResults(tt.old, tt.new)
+
+			if got := ids(diff.Added); !reflect.DeepEqual(got, tt.added) {
+				t.Errorf("added: got %v, want %v", got, tt.added)
+			}
+			if got := ids(diff.Removed); !reflect.DeepEqual(got, tt.removed) {
+				t.Errorf("removed: got %v, want %v", got, tt.removed)
+			}
+			if len(diff.Changed) != len(tt.changed) {
+				t.Fatalf("changed: got %d, want %d", len(diff.Changed), len(tt.changed))
+			}
+			for _, c := range diff.Changed {
+				if !reflect.DeepEqual(c.Changes, tt.changed[c.InternalID]) {
+					t.Errorf("changed %d: got %+v, want %+v", c.InternalID, c.Changes, tt.changed[c.InternalID])
+				}
+			}
+			if diff.Unchanged != tt.unchanged {
+				t.Errorf("unchanged: got %d, want %d", diff.Unchanged, tt.unchanged)
+			}
+		})
+	}
+}
+
+func TestWriteDiffText(t *testing.T) {
+	diff := diffResults(
+		[]ResultRecord{{InternalID: 1, VotesCast: 1, Tally: []uint{0, 1}}, {InternalID: 2}},
+		[]ResultRecord{{InternalID: 1, VotesCast: 2, Tally: []uint{0, 2}}, {InternalID: 3}},
+	)
+	diff.Old, diff.New = "old.csv", "new.csv"
+
+	var buf bytes.Buffer
+	writeDiffText(&buf, diff)
+
+	for _, line := range []string{
+		"--- old.csv",
+		"+++ new.csv",
+		"- 2 ",
+		"+ 3 ",
+		"~ 1 ",
+		"    votes_cast: 1 -> 2 (+1)",
+		"    tally_1: 1 -> 2 (+1)",
+		"added: 1, removed: 1, changed: 1, unchanged: 0",
+	} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("writeDiffText: missing %q in:\n%s", line, buf.String())
+		}
+	}
+}
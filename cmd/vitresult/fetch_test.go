@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"0", 0},
+		{"5", 5 * time.Second},
+		{" 120 ", 2 * time.Minute},
+		{"-1", 0},
+		{"soon", 0},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q): got %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	f := &Fetcher{RetryWaitMax: 10 * time.Second}
+
+	tests := []struct {
+		name    string
+		backoff time.Duration
+		err     error
+		want    time.Duration
+		wantErr bool
+	}{
+		{"backoff", 2 * time.Second, http.ErrHandlerTimeout, 2 * time.Second, false},
+		{"backoff capped", time.Minute, http.ErrHandlerTimeout, 10 * time.Second, false},
+		{"no retry after", 2 * time.Second, &HttpStatusError{StatusCode: http.StatusBadGateway}, 2 * time.Second, false},
+		{"retry after", 2 * time.Second, &HttpStatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: 7 * time.Second}, 7 * time.Second, false},
+		{"retry after too long", 2 * time.Second, &HttpStatusError{StatusCode: http.StatusServiceUnavailable, RetryAfter: time.Hour}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := f.retryDelay(tt.backoff, tt.err)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("retryDelay: got error %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("retryDelay: got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHttpGetRetry(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			_, _ = w.Write([]byte(`[]`))
+		}
+	}))
+	defer srv.Close()
+
+	f := &Fetcher{Client: srv.Client(), Retries: 3, RetryWait: time.Millisecond, RetryWaitMax: 5 * time.Millisecond}
+	data, err := f.httpGetRetry(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("httpGetRetry: %v", err)
+	}
+	if string(data) != `[]` || atomic.LoadInt32(&calls) != 3 {
+		t.Errorf("httpGetRetry: got %q after %d calls", data, calls)
+	}
+}
+
+func TestHttpGetRetryErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		calls      int32
+		errMsg     string
+	}{
+		{"not temporary", http.StatusNotFound, "", 1, "404"},
+		{"retries exhausted", http.StatusBadGateway, "", 3, "3 attempts failed"},
+		{"retry after too long", http.StatusTooManyRequests, "3600", 1, "Retry-After"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			f := &Fetcher{Client: srv.Client(), Retries: 2, RetryWait: time.Millisecond, RetryWaitMax: time.Second}
+			_, err := f.httpGetRetry(context.Background(), srv.URL)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Fatalf("httpGetRetry: got %v, want error containing %q", err, tt.errMsg)
+			}
+			if got := atomic.LoadInt32(&calls); got != tt.calls {
+				t.Errorf("httpGetRetry: got %d calls, want %d", got, tt.calls)
+			}
+		})
+	}
+}
+
+func TestHttpGetRetryCancel(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	// cancelled during the backoff wait
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	f := &Fetcher{Client: srv.Client(), Retries: 3, RetryWait: time.Minute, RetryWaitMax: time.Minute}
+	start := time.Now()
+	_, err := f.httpGetRetry(ctx, srv.URL)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("httpGetRetry: got %v, want %v", err, context.Canceled)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second || atomic.LoadInt32(&calls) != 1 {
+		t.Errorf("httpGetRetry: returned after %s and %d calls", elapsed, calls)
+	}
+
+	// already cancelled, no request
+	if _, err = f.httpGetRetry(ctx, srv.URL); !errors.Is(err, context.Canceled) || atomic.LoadInt32(&calls) != 1 {
+		t.Errorf("httpGetRetry: got %v after %d calls, want %v", err, calls, context.Canceled)
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	urlErr := func(err error) error { return &url.Error{Op: "Get", URL: "https://vit.example", Err: err} }
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"503", &HttpStatusError{StatusCode: http.StatusServiceUnavailable}, true},
+		{"429", &HttpStatusError{StatusCode: http.StatusTooManyRequests}, true},
+		{"404", &HttpStatusError{StatusCode: http.StatusNotFound}, false},
+		{"dns not found", urlErr(&net.DNSError{Err: "no such host", Name: "vit.example", IsNotFound: true}), false},
+		{"dns timeout", urlErr(&net.DNSError{Err: "i/o timeout", Name: "vit.example", IsTimeout: true}), true},
+		{"connection refused", urlErr(&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}), false},
+		{"too many open files", urlErr(&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("socket", syscall.EMFILE)}), true},
+		{"unknown authority", urlErr(x509.UnknownAuthorityError{}), false},
+		{"client timeout", urlErr(context.DeadlineExceeded), true},
+		{"other", errors.New("unexpected"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryable(tt.err); got != tt.want {
+				t.Errorf("retryable(%v): got %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHttpGetRetryPermanent(t *testing.T) {
+	var calls int32
+	tlsSrv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+	}))
+	defer tlsSrv.Close()
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"unknown certificate authority", tlsSrv.URL},
+		{"connection refused", closed.URL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the default client doesn't trust the test server certificate
+			f := &Fetcher{Client: &http.Client{Timeout: 5 * time.Second}, Retries: 3, RetryWait: time.Minute, RetryWaitMax: time.Minute}
+			_, err := f.httpGetRetry(context.Background(), tt.url)
+			if err == nil || strings.Contains(err.Error(), "attempts failed") {
+				t.Fatalf("httpGetRetry: got %v, want a single attempt error", err)
+			}
+		})
+	}
+	if got := atomic.LoadInt32(&calls); got != 0 {
+		t.Errorf("httpGetRetry: got %d calls, want 0", got)
+	}
+}
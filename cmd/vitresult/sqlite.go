@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/input-output-hk/jorvit/internal/loader"
+	_ "modernc.org/sqlite" // database/sql "sqlite" driver, pure go
+)
+
+// vit-servicing-station database data kinds, selected with "sqlite://path?data=<kind>"
+const (
+	sqliteProposals = "proposals"
+	sqliteFund      = "fund"
+)
+
+// vit-servicing-station schema queries, times are stored as unix time.
+const (
+	sqliteVotePlansQuery = `SELECT id, chain_voteplan_id, chain_vote_start_time, chain_vote_end_time, chain_committee_end_time,
+	chain_voteplan_payload, chain_vote_encryption_key, fund_id
+FROM voteplans ORDER BY id`
+
+	sqliteProposalsQuery = `SELECT id, proposal_category, proposal_id, proposal_title, proposal_summary, proposal_problem, proposal_solution,
+	proposal_url, proposal_files_url, proposal_public_key, proposal_funds, proposal_impact_score,
+	proposer_name, proposer_contact, proposer_url, proposer_relevant_experience,
+	chain_proposal_id, chain_proposal_index, chain_vote_options, chain_voteplan_id, challenge_id
+FROM proposals ORDER BY id`
+
+	sqliteFundQuery = `SELECT id, fund_name, fund_goal, voting_power_info, voting_power_threshold, rewards_info,
+	fund_start_time, fund_end_time, next_fund_start_time
+FROM funds ORDER BY id LIMIT 1`
+)
+
+// sqliteDB reads the vit-servicing-station database, opened read only.
+// The voteplans don't change during a fund, so they are loaded only once.
+type sqliteDB struct {
+	file      string
+	db        *sql.DB
+	votePlans []loader.ChainVotePlan
+}
+
+// openSqliteDB opens the existing database file read only.
+func openSqliteDB(file string) (*sqliteDB, error) {
+	if _, err := os.Stat(file); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", (&url.URL{Scheme: "file", Path: file, RawQuery: "mode=ro"}).String())
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteDB{file: file, db: db}, nil
+}
+
+// sqliteTime formats the unix time stored in the database the same way the service api does.
+func sqliteTime(unix int64) string {
+	return time.Unix(unix, 0).UTC().Format("2006-01-02T15:04:05-07:00")
+}
+
+// VotePlans loads the voteplans of the vit-servicing-station database, cached after the first load.
+func (db *sqliteDB) VotePlans(ctx context.Context) ([]loader.ChainVotePlan, error) {
+	if db.votePlans != nil {
+		return db.votePlans, nil
+	}
+
+	rows, err := db.db.QueryContext(ctx, sqliteVotePlansQuery)
+	if err != nil {
+		return nil, fmt.Errorf("%s voteplans: %w", db.file, err)
+	}
+	defer rows.Close()
+
+	votePlans := make([]loader.ChainVotePlan, 0)
+	for rows.Next() {
+		var (
+			vp                               loader.ChainVotePlan
+			voteStart, voteEnd, committeeEnd int64
+		)
+		err = rows.Scan(&vp.VpInternalID, &vp.VotePlanID, &voteStart, &voteEnd, &committeeEnd,
+			&vp.Payload, &vp.VoteEncryptionKey, &vp.FundID)
+		if err != nil {
+			return nil, fmt.Errorf("%s voteplans: %w", db.file, err)
+		}
+		vp.VoteStart, vp.VoteEnd, vp.CommitteeEnd = sqliteTime(voteStart), sqliteTime(voteEnd), sqliteTime(committeeEnd)
+		votePlans = append(votePlans, vp)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s voteplans: %w", db.file, err)
+	}
+	db.votePlans = votePlans
+	return db.votePlans, nil
+}
+
+// Proposals loads the proposals of the vit-servicing-station database, each linked to its voteplan.
+func (db *sqliteDB) Proposals(ctx context.Context, votePlans []loader.ChainVotePlan) ([]loader.ProposalData, error) {
+	rows, err := db.db.QueryContext(ctx, sqliteProposalsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("%s proposals: %w", db.file, err)
+	}
+	defer rows.Close()
+
+	proposals := make([]loader.ProposalData, 0)
+	for rows.Next() {
+		var (
+			p                 loader.ProposalData
+			funds             uint64
+			impactScore       int64
+			voteOptions, vpID string
+		)
+		err = rows.Scan(&p.InternalID, &p.CategoryName, &p.Proposal.ID, &p.Title, &p.Summary, &p.Problem, &p.Solution,
+			&p.ProposalURL, &p.DataURL, &p.PublicKey, &funds, &impactScore,
+			&p.ProposerName, &p.ProposerEmail, &p.ProposerURL, &p.ProposerExperience,
+			&p.ExternalID, &p.Index, &voteOptions, &vpID, &p.ChallengeID)
+		if err != nil {
+			return nil, fmt.Errorf("%s proposals: %w", db.file, err)
+		}
+		p.Funds = loader.Lovelace(funds)
+		p.ImpactScore = loader.Score(impactScore)
+		if err = p.VoteOptions.UnmarshalCSV(voteOptions); err != nil {
+			return nil, err
+		}
+
+		for i := range votePlans {
+			if votePlans[i].VotePlanID == vpID {
+				p.ChainVotePlan = &votePlans[i]
+				break
+			}
+		}
+		if p.ChainVotePlan == nil {
+			return nil, fmt.Errorf("proposal %d - voteplan [%s] not found", p.InternalID, vpID)
+		}
+
+		proposals = append(proposals, p)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s proposals: %w", db.file, err)
+	}
+	return proposals, nil
+}
+
+// Fund loads the (first) fund of the vit-servicing-station database, with its voteplans.
+func (db *sqliteDB) Fund(ctx context.Context, votePlans []loader.ChainVotePlan) (*loader.FundData, error) {
+	var (
+		fund                              loader.FundData
+		threshold                         uint64
+		startTime, endTime, nextStartTime int64
+	)
+	err := db.db.QueryRowContext(ctx, sqliteFundQuery).Scan(&fund.FundID, &fund.Name, &fund.Goal, &fund.VotingPowerInfo,
+		&threshold, &fund.RewardsInfo, &startTime, &endTime, &nextStartTime)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%s - no funds found", db.file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s fund: %w", db.file, err)
+	}
+	fund.VotingPowerThreshold = loader.Lovelace(threshold)
+	fund.StartTime, fund.EndTime, fund.NextStartTime = sqliteTime(startTime), sqliteTime(endTime), sqliteTime(nextStartTime)
+
+	fund.VotePlans = []loader.ChainVotePlan{}
+	for _, vp := range votePlans {
+		if vp.FundID == fund.FundID {
+			fund.VotePlans = append(fund.VotePlans, vp)
+		}
+	}
+	return &fund, nil
+}
+
+// sqliteData builds from the vit-servicing-station database the same json the service api provides.
+//
+// Tally results live only on the node (its storage is not read), so voteplans results can't be read from there:
+// producing results against a stopped environment is out of scope, the node (or a file:// dump of its voteplans) is still required.
+func (f *Fetcher) sqliteData(ctx context.Context, u *url.URL) ([]byte, error) {
+	file := u.Host + u.Path
+
+	db, ok := f.sqlite[file]
+	if !ok {
+		var err error
+		db, err = openSqliteDB(file)
+		if err != nil {
+			return nil, err
+		}
+		if f.sqlite == nil {
+			f.sqlite = make(map[string]*sqliteDB)
+		}
+		f.sqlite[file] = db
+	}
+
+	votePlans, err := db.VotePlans(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Query().Get("data") {
+	case sqliteProposals:
+		proposals, err := db.Proposals(ctx, votePlans)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(proposals)
+
+	case sqliteFund:
+		fund, err := db.Fund(ctx, votePlans)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(fund)
+
+	default:
+		return nil, fmt.Errorf("%s - expected to be one of (%s, %s) - but [%s] provided, tally results are available only from the node", "sqlite data", sqliteProposals, sqliteFund, u.Query().Get("data"))
+	}
+}
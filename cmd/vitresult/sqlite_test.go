@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/input-output-hk/jorvit/internal/loader"
+)
+
+// testSqliteSchema is the vit-servicing-station database schema.
+const testSqliteSchema = `
+CREATE TABLE funds (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	fund_name VARCHAR NOT NULL,
+	fund_goal VARCHAR NOT NULL,
+	voting_power_info VARCHAR NOT NULL,
+	voting_power_threshold BIGINT NOT NULL,
+	rewards_info VARCHAR NOT NULL,
+	fund_start_time BIGINT NOT NULL,
+	fund_end_time BIGINT NOT NULL,
+	next_fund_start_time BIGINT NOT NULL
+);
+CREATE TABLE voteplans (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	chain_voteplan_id VARCHAR NOT NULL UNIQUE,
+	chain_vote_start_time BIGINT NOT NULL,
+	chain_vote_end_time BIGINT NOT NULL,
+	chain_committee_end_time BIGINT NOT NULL,
+	chain_voteplan_payload VARCHAR NOT NULL,
+	chain_vote_encryption_key VARCHAR NOT NULL,
+	fund_id INTEGER NOT NULL
+);
+CREATE TABLE proposals (
+	id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	proposal_id VARCHAR NOT NULL,
+	proposal_category VARCHAR NOT NULL,
+	proposal_title VARCHAR NOT NULL,
+	proposal_summary VARCHAR NOT NULL,
+	proposal_problem VARCHAR NOT NULL,
+	proposal_solution VARCHAR NOT NULL,
+	proposal_public_key VARCHAR NOT NULL,
+	proposal_funds BIGINT NOT NULL,
+	proposal_url VARCHAR NOT NULL,
+	proposal_files_url VARCHAR NOT NULL,
+	proposal_impact_score BIGINT NOT NULL,
+	proposer_name VARCHAR NOT NULL,
+	proposer_contact VARCHAR NOT NULL,
+	proposer_url VARCHAR NOT NULL,
+	proposer_relevant_experience VARCHAR NOT NULL,
+	chain_proposal_id BLOB NOT NULL,
+	chain_proposal_index BIGINT NOT NULL,
+	chain_vote_options VARCHAR NOT NULL,
+	chain_voteplan_id VARCHAR NOT NULL,
+	challenge_id INTEGER NOT NULL
+);
+INSERT INTO funds VALUES (1, 'Fund3', 'goal', 'info', 500000000, 'rewards', 1614592800, 1615802400, 1617012000);
+INSERT INTO voteplans VALUES (1, 'vp1', 1614592800, 1615802400, 1616407200, 'public', '', 1);
+INSERT INTO proposals VALUES (1, '100', 'Dapps', 'Proposal 100', 'summary', 'problem', 'solution', 'pk', 10000000000,
+	'https://proposal', 'https://files', 350, 'IOHK', 'iohk@iohk.io', 'https://iohk.io', 'experience',
+	CAST('cp1' AS BLOB), 0, 'blank,yes,no', 'vp1', 7);
+`
+
+// testSqliteDB creates a vit-servicing-station database, modified by the extra statements.
+func testSqliteDB(t *testing.T, statements ...string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "database.sqlite3")
+	testSqliteExec(t, file, append([]string{testSqliteSchema}, statements...)...)
+	return file
+}
+
+func testSqliteExec(t *testing.T, file string, statements ...string) {
+	t.Helper()
+	db, err := sql.Open("sqlite", file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, stmt := range statements {
+		if _, err = db.Exec(stmt); err != nil {
+			t.Fatalf("sqlite [%s]: %v", stmt, err)
+		}
+	}
+}
+
+func TestSqliteData(t *testing.T) {
+	db := testSqliteDB(t)
+	f := &Fetcher{}
+
+	data, err := f.fetchData(context.Background(), &url.URL{Scheme: "sqlite", Path: db, RawQuery: "data=" + sqliteProposals})
+	if err != nil {
+		t.Fatalf("sqlite proposals: %v", err)
+	}
+	var proposals []loader.ProposalData
+	if err = json.Unmarshal(data, &proposals); err != nil {
+		t.Fatal(err)
+	}
+	if len(proposals) != 1 {
+		t.Fatalf("proposals: got %d, want %d", len(proposals), 1)
+	}
+	p := proposals[0]
+	if p.InternalID != 1 || p.Proposal.ID != "100" || p.ExternalID != "cp1" || p.Funds != 10000000000 || p.ImpactScore != 350 || p.ChallengeID != 7 {
+		t.Errorf("proposal: got %+v", p)
+	}
+	if p.CategoryName != "Dapps" || p.ProposerEmail != "iohk@iohk.io" || p.ProposerExperience != "experience" {
+		t.Errorf("proposal category and proposer: got %+v %+v", p.ProposalCategory, p.Proposer)
+	}
+	if p.ChainVotePlan == nil || p.VotePlanID != "vp1" || p.VoteStart != "2021-03-01T10:00:00+00:00" || p.CommitteeEnd != "2021-03-22T10:00:00+00:00" {
+		t.Errorf("proposal voteplan: got %+v", p.ChainVotePlan)
+	}
+	if p.VoteOptions["no"] != 2 {
+		t.Errorf("proposal vote options: got %v", p.VoteOptions)
+	}
+
+	// voteplans are cached, a voteplan added later is not picked up
+	testSqliteExec(t, db, "INSERT INTO voteplans VALUES (2, 'vp2', 1614592800, 1615802400, 1616407200, 'private', 'key', 1)")
+
+	data, err = f.fetchData(context.Background(), &url.URL{Scheme: "sqlite", Path: db, RawQuery: "data=" + sqliteFund})
+	if err != nil {
+		t.Fatalf("sqlite fund: %v", err)
+	}
+	var fund loader.FundData
+	if err = json.Unmarshal(data, &fund); err != nil {
+		t.Fatal(err)
+	}
+	if fund.FundID != 1 || fund.Name != "Fund3" || fund.VotingPowerThreshold != 500000000 || fund.NextStartTime != "2021-03-29T10:00:00+00:00" {
+		t.Errorf("fund: got %+v", fund)
+	}
+	if len(fund.VotePlans) != 1 {
+		t.Errorf("fund voteplans: got %d, want %d (cached)", len(fund.VotePlans), 1)
+	}
+
+	_, err = f.fetchData(context.Background(), &url.URL{Scheme: "sqlite", Path: db, RawQuery: "data=plans"})
+	if err == nil || !strings.Contains(err.Error(), "only from the node") {
+		t.Errorf("sqlite voteplans: got %v, want node required error", err)
+	}
+}
+
+func TestSqliteDataErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		statement string
+		data      string
+		errMsg    string
+	}{
+		{"bad funds", "UPDATE proposals SET proposal_funds = 'ten'", sqliteProposals, "proposals"},
+		{"negative threshold", "UPDATE funds SET voting_power_threshold = -1", sqliteFund, "fund"},
+		{"index overflow", "UPDATE proposals SET chain_proposal_index = 256", sqliteProposals, "proposals"},
+		{"unknown voteplan", "UPDATE proposals SET chain_voteplan_id = 'vp9'", sqliteProposals, "voteplan [vp9] not found"},
+		{"no funds", "DELETE FROM funds", sqliteFund, "no funds found"},
+		{"missing column", "ALTER TABLE voteplans DROP COLUMN chain_vote_encryption_key", sqliteFund, "chain_vote_encryption_key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testSqliteDB(t, tt.statement)
+			_, err := (&Fetcher{}).fetchData(context.Background(), &url.URL{Scheme: "sqlite", Path: db, RawQuery: "data=" + tt.data})
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Fatalf("sqlite %s: got %v, want error containing %q", tt.data, err, tt.errMsg)
+			}
+		})
+	}
+
+	// not created when missing
+	missing := filepath.Join(t.TempDir(), "missing.sqlite3")
+	if _, err := (&Fetcher{}).fetchData(context.Background(), &url.URL{Scheme: "sqlite", Path: missing, RawQuery: "data=" + sqliteFund}); err == nil {
+		t.Fatal("sqlite: expected error on missing database")
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/input-output-hk/jorvit/internal/bech32"
+)
+
+// "jcli key sign" output (jorcli testdata)
+const (
+	jcliPublicKey = "ed25519_pk10p43s2c5g3hhdklz9k6awwy5nvv7cnkwv6szgaxvac4ju0jm2a0qyf6j8v"
+	jcliSignature = "ed25519_sig1spmuf9mahqvgkv3a9prf0phrf3zk666pcngk6q0ywu9xfar7sunr6qmladu8rntmdguraz3pnfj0knr7c5k9jjul4zt893ll8qsexrq96nffp"
+	jcliSignedHex = "4ac396524d554e47414e4452"
+)
+
+// testSigningKey writes a jcli format ed25519 secret key, returning its file and public key.
+func testSigningKey(t *testing.T, dir string) (string, ed25519.PublicKey) {
+	t.Helper()
+	seed := make([]byte, ed25519.SeedSize)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	sk, err := bech32.Encode(hrpSecretKey, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skFile := filepath.Join(dir, "leader.sk")
+	writeTestFile(t, skFile, []byte(sk+"\n"))
+	return skFile, ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey)
+}
+
+func TestVerifyFileJcli(t *testing.T) {
+	dir := t.TempDir()
+	data, err := hex.DecodeString(jcliSignedHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "data")
+	writeTestFile(t, file, data)
+	writeTestFile(t, file+signatureExt, []byte(jcliSignature+"\n"))
+
+	pk, err := parsePublicKey(jcliPublicKey)
+	if err != nil {
+		t.Fatalf("parsePublicKey: %v", err)
+	}
+	if err = verifyFile(pk, file, file+signatureExt); err != nil {
+		t.Errorf("verifyFile: %v", err)
+	}
+
+	// public key given as file
+	pkFile := filepath.Join(dir, "leader.pk")
+	writeTestFile(t, pkFile, []byte(jcliPublicKey+"\n"))
+	pk2, err := parsePublicKey(pkFile)
+	if err != nil {
+		t.Fatalf("parsePublicKey file: %v", err)
+	}
+	if !pk.Equal(pk2) {
+		t.Errorf("parsePublicKey file: got %x, want %x", pk2, pk)
+	}
+
+	writeTestFile(t, file, append(data, '\n'))
+	if err = verifyFile(pk, file, file+signatureExt); err == nil {
+		t.Error("verifyFile: expected error on modified data")
+	}
+}
+
+func TestVerifyTarget(t *testing.T) {
+	dir := t.TempDir()
+	skFile, pk := testSigningKey(t, dir)
+	sk, err := readSigningKey(skFile)
+	if err != nil {
+		t.Fatalf("readSigningKey: %v", err)
+	}
+	if pkStr, _ := publicKeyString(sk); !strings.HasPrefix(pkStr, hrpPublicKey+"1") {
+		t.Errorf("publicKeyString: got %s", pkStr)
+	}
+	_, otherPk := testSigningKey(t, t.TempDir())
+	otherPk[0] ^= 0xff
+
+	// signed result file
+	result := filepath.Join(dir, "TallyResult.csv")
+	writeTestFile(t, result, []byte("internal_id,votes_cast\n1,2\n"))
+	if _, err = signFile(sk, result); err != nil {
+		t.Fatalf("signFile: %v", err)
+	}
+
+	// signed audit bundle, as directory and as archive
+	bundle := filepath.Join(dir, "bundle")
+	manifest := testAuditBundle(t, bundle)
+	if _, err = signFile(sk, filepath.Join(bundle, auditManifestFile)); err != nil {
+		t.Fatalf("signFile: %v", err)
+	}
+	if err = tarAuditBundle(bundle, manifest, bundle+".tar.gz"); err != nil {
+		t.Fatalf("tarAuditBundle: %v", err)
+	}
+
+	// tampered audit bundle, the manifest signature is fine but not the hashes
+	tampered := filepath.Join(dir, "tampered")
+	testAuditBundle(t, tampered)
+	if _, err = signFile(sk, filepath.Join(tampered, auditManifestFile)); err != nil {
+		t.Fatalf("signFile: %v", err)
+	}
+	writeTestFile(t, filepath.Join(tampered, auditFundsFile), []byte(`{"id":2}`))
+
+	// signature with the wrong bech32 prefix
+	wrongHrp := filepath.Join(dir, "wrong_hrp.sig")
+	pkStr, _ := bech32.Encode(hrpPublicKey, pk)
+	writeTestFile(t, wrongHrp, []byte(pkStr))
+
+	tests := []struct {
+		name    string
+		pk      ed25519.PublicKey
+		target  string
+		sigFile string
+		errMsg  string
+	}{
+		{"result", pk, result, "", ""},
+		{"bundle dir", pk, bundle, "", ""},
+		{"bundle tar.gz", pk, bundle + ".tar.gz", "", ""},
+		{"result wrong public key", otherPk, result, "", "signature verification failed"},
+		{"bundle wrong public key", otherPk, bundle + ".tar.gz", "", "signature verification failed"},
+		{"result signature of other file", pk, result, filepath.Join(bundle, auditManifestFile+signatureExt), "signature verification failed"},
+		{"result signature wrong prefix", pk, result, wrongHrp, "bech32 prefix"},
+		{"result signature missing", pk, filepath.Join(bundle, auditFundsFile), "", "no such file"},
+		{"bundle tampered", pk, tampered, "", "blake2b mismatch"},
+		{"target missing", pk, filepath.Join(dir, "missing.csv"), "", "no such file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyTarget(tt.pk, tt.target, tt.sigFile)
+			switch {
+			case tt.errMsg == "" && err != nil:
+				t.Errorf("verifyTarget: %v", err)
+			case tt.errMsg != "" && (err == nil || !strings.Contains(err.Error(), tt.errMsg)):
+				t.Errorf("verifyTarget: got %v, want error containing %q", err, tt.errMsg)
+			}
+		})
+	}
+}
@@ -3,19 +3,37 @@
 package main
 
 import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"log"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/gocarina/gocsv"
+	"github.com/input-output-hk/jorvit/internal/bech32"
 	"github.com/input-output-hk/jorvit/internal/kit"
 	"github.com/input-output-hk/jorvit/internal/loader"
+	"github.com/input-output-hk/jorvit/pkg/vresult"
+	"golang.org/x/crypto/blake2b"
 )
 
 var (
@@ -25,138 +43,862 @@ var (
 	BuildDate  = "unknown"
 )
 
-type ChainTime struct {
-	Epoch  int64 `json:"epoch"`
-	SlotID int64 `json:"slot_id"`
-}
-
-type VoteOption struct {
-	Start uint8 `json:"start"`
-	End   uint8 `json:"end"`
-}
-
-type TallyResult map[string]struct {
-	Result struct {
-		Options VoteOption `json:"options"`
-		Results []uint     `json:"results"`
-	} `json:"result"`
-}
-
-type VoteProposal struct {
-	Index      uint8       `json:"index"`
-	ProposalID string      `json:"proposal_id"`
-	Options    VoteOption  `json:"options"`
-	Tally      TallyResult `json:"tally"`
-	VotesCast  uint        `json:"votes_cast"`
-}
-
-type VotePlans struct {
-	ID                  string         `json:"id"`
-	Payload             string         `json:"payload"`
-	VoteStart           ChainTime      `json:"vote_start"`
-	VoteEnd             ChainTime      `json:"vote_end"`
-	CommitteeEnd        ChainTime      `json:"committee_end"`
-	CommitteeMemberKeys []string       `json:"committee_member_keys"`
-	Proposals           []VoteProposal `json:"proposals"`
-}
-
-// TallyOptions total 16 choices available (0-15)
-type TallyOptions struct {
-	// TODO: this is ...
-	Tally00 uint `json:"tally_0"  csv:"tally_0_BLANK"`
-	Tally01 uint `json:"tally_1"  csv:"tally_1_YES"`
-	Tally02 uint `json:"tally_2"  csv:"tally_2_NO"`
-	Tally03 uint `json:"-"        csv:"-"`
-	Tally04 uint `json:"-"        csv:"-"`
-	Tally05 uint `json:"-"        csv:"-"`
-	Tally06 uint `json:"-"        csv:"-"`
-	Tally07 uint `json:"-"        csv:"-"`
-	Tally08 uint `json:"-"        csv:"-"`
-	Tally09 uint `json:"-"        csv:"-"`
-	Tally10 uint `json:"-"        csv:"-"`
-	Tally11 uint `json:"-"        csv:"-"`
-	Tally12 uint `json:"-"        csv:"-"`
-	Tally13 uint `json:"-"        csv:"-"`
-	Tally14 uint `json:"-"        csv:"-"`
-	Tally15 uint `json:"-"        csv:"-"`
-}
-
-type ProposalsResult struct {
-	loader.ProposalData
-	VotesCast uint `json:"votes_cast" csv:"votes_cast"`
-	TallyOptions
-}
-
-func getData(client *http.Client, u *url.URL, dst interface{}) error {
-	var (
-		data []byte
-		err  error
-	)
+// Audit bundle file names
+const (
+	auditManifestFile  = "manifest.json"
+	auditVotePlansFile = "vote_plans.json"
+	auditProposalsFile = "proposals.json"
+	auditFundsFile     = "fund.json"
+)
+
+// AuditEntry describes one raw input saved within an audit bundle.
+type AuditEntry struct {
+	File      string `json:"file"`
+	Source    string `json:"source"`
+	Timestamp string `json:"timestamp"`
+	Blake2b   string `json:"blake2b_256"`
+	Size      int    `json:"size"`
+}
+
+// AuditManifest lists all the raw inputs used to build a result.
+type AuditManifest struct {
+	Version string       `json:"version"`
+	Created string       `json:"created"`
+	Entries []AuditEntry `json:"entries"`
+}
+
+// Entry returns the manifest entry for the given bundle file name.
+func (am *AuditManifest) Entry(file string) (*AuditEntry, error) {
+	for i := range am.Entries {
+		if am.Entries[i].File == file {
+			return &am.Entries[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%s - not found in audit manifest", file)
+}
+
+// NewAuditEntry hashes the raw data fetched from source.
+func NewAuditEntry(file string, source string, fetched time.Time, data []byte) AuditEntry {
+	hash := blake2b.Sum256(data)
+	return AuditEntry{
+		File:      file,
+		Source:    source,
+		Timestamp: fetched.UTC().Format(time.RFC3339Nano),
+		Blake2b:   hex.EncodeToString(hash[:]),
+		Size:      len(data),
+	}
+}
+
+// writeAuditBundle dumps the raw data and the manifest into dir.
+func writeAuditBundle(dir string, manifest *AuditManifest, raw map[string][]byte) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range manifest.Entries {
+		err = ioutil.WriteFile(filepath.Join(dir, entry.File), raw[entry.File], 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	manifestJson, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, auditManifestFile), manifestJson, 0644)
+}
+
+// readAuditBundle loads the manifest from dir and checks the hash of every entry.
+func readAuditBundle(dir string) (*AuditManifest, error) {
+	manifestJson, err := ioutil.ReadFile(filepath.Join(dir, auditManifestFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest AuditManifest
+	err = json.Unmarshal(manifestJson, &manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range manifest.Entries {
+		// bundles are flat, entries can't point outside of dir
+		if filepath.Base(entry.File) != entry.File || entry.File == "." || entry.File == ".." {
+			return nil, fmt.Errorf("[%s] - wrong manifest entry, expected a bundle file name", entry.File)
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, entry.File))
+		if err != nil {
+			return nil, err
+		}
+		hash := blake2b.Sum256(data)
+		if hex.EncodeToString(hash[:]) != entry.Blake2b {
+			return nil, fmt.Errorf("%s - blake2b mismatch, expected [%s] got [%s]", entry.File, entry.Blake2b, hex.EncodeToString(hash[:]))
+		}
+	}
+
+	return &manifest, nil
+}
+
+// tarAuditBundle packs the bundle files of dir into a tar.gz archive.
+// The archive is reproducible: same bundle, same bytes. Entries get the manifest creation time
+// (or the unix epoch if missing) and no owner.
+func tarAuditBundle(dir string, manifest *AuditManifest, archive string) error {
+	modTime, err := time.Parse(time.RFC3339Nano, manifest.Created)
+	if err != nil {
+		modTime = time.Unix(0, 0)
+	}
+	modTime = modTime.UTC().Truncate(time.Second)
+
+	af, err := os.Create(archive)
+	if err != nil {
+		return err
+	}
+	defer af.Close()
+
+	gw := gzip.NewWriter(af)
+	gw.ModTime = modTime
+	tw := tar.NewWriter(gw)
+
+	files := []string{auditManifestFile}
+	if _, err := os.Stat(filepath.Join(dir, auditManifestFile+signatureExt)); err == nil {
+		files = append(files, auditManifestFile+signatureExt)
+	}
+	for _, entry := range manifest.Entries {
+		files = append(files, entry.File)
+	}
+
+	for _, file := range files {
+		data, err := ioutil.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			return err
+		}
+		err = tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     file,
+			Mode:     0644,
+			Size:     int64(len(data)),
+			ModTime:  modTime,
+			Uid:      0,
+			Gid:      0,
+			Format:   tar.FormatUSTAR,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err = tw.Write(data); err != nil {
+			return err
+		}
+	}
+
+	if err = tw.Close(); err != nil {
+		return err
+	}
+	if err = gw.Close(); err != nil {
+		return err
+	}
+	return af.Close()
+}
+
+// untarAuditBundle extracts a tar.gz audit bundle into a new temporary directory.
+// On failure the temporary directory is removed.
+func untarAuditBundle(archive string) (string, error) {
+	af, err := os.Open(archive)
+	if err != nil {
+		return "", err
+	}
+	defer af.Close()
+
+	gr, err := gzip.NewReader(af)
+	if err != nil {
+		return "", err
+	}
+	defer gr.Close()
+
+	dir, err := ioutil.TempDir("", "vitresult_bundle_")
+	if err != nil {
+		return "", err
+	}
+
+	err = untarFlat(tar.NewReader(gr), dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+// untarFlat writes the regular files of the archive into dir,
+// bundles are flat so the paths stored within the archive are never followed.
+func untarFlat(tr *tar.Reader, dir string) error {
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(filepath.Join(dir, filepath.Base(hdr.Name)), data, 0644)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// openAuditBundle checks the audit bundle (directory or .tar.gz) to re-run from, returning its absolute dir.
+// Archives are extracted into a temporary directory, removed by cleanup.
+func openAuditBundle(bundle string) (dir string, cleanup func(), err error) {
+	dir, cleanup = bundle, func() {}
+	if isTarGz(bundle) {
+		tmpDir, err := untarAuditBundle(bundle)
+		if err != nil {
+			return "", nil, fmt.Errorf("untarAuditBundle: %w", err)
+		}
+		dir, cleanup = tmpDir, func() { os.RemoveAll(tmpDir) }
+	}
 
+	dir, err = auditBundleInputs(dir)
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return dir, cleanup, nil
+}
+
+// auditBundleInputs checks that the bundle holds all the result inputs, returning its absolute dir.
+func auditBundleInputs(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	manifest, err := readAuditBundle(dir)
+	if err != nil {
+		return "", fmt.Errorf("readAuditBundle: %w", err)
+	}
+	for _, file := range []string{auditVotePlansFile, auditProposalsFile, auditFundsFile} {
+		if _, err = manifest.Entry(file); err != nil {
+			return "", err
+		}
+	}
+	return dir, nil
+}
+
+func isTarGz(file string) bool {
+	return strings.HasSuffix(file, ".tar.gz") || strings.HasSuffix(file, ".tgz")
+}
+
+// Signatures use the jcli bech32 formats, so they can be checked also with "jcli key verify"
+const (
+	hrpSecretKey = "ed25519_sk"
+	hrpPublicKey = "ed25519_pk"
+	hrpSignature = "ed25519_sig"
+	signatureExt = ".sig"
+)
+
+// decodeBech32 decodes a bech32 string checking its prefix and data size.
+func decodeBech32(str string, hrp string, size int) ([]byte, error) {
+	prefix, data, err := bech32.Decode(strings.TrimSpace(str))
+	if err != nil {
+		return nil, err
+	}
+	if prefix != hrp {
+		return nil, fmt.Errorf("%s - expected to be one of (%s) - but [%s] provided", "bech32 prefix", hrp, prefix)
+	}
+	if len(data) != size {
+		return nil, fmt.Errorf("%s - wrong size, expected [%d] got [%d]", hrp, size, len(data))
+	}
+	return data, nil
+}
+
+// readSigningKey loads an ed25519 secret key file in jcli format (ex: a vitconfig bft leader secret key).
+func readSigningKey(file string) (ed25519.PrivateKey, error) {
+	sk, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := decodeBech32(string(sk), hrpSecretKey, ed25519.SeedSize)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// parsePublicKey decodes an ed25519 public key in jcli format, given directly or as a file containing it.
+func parsePublicKey(key string) (ed25519.PublicKey, error) {
+	if !strings.HasPrefix(key, hrpPublicKey+"1") {
+		pk, err := ioutil.ReadFile(key)
+		if err != nil {
+			return nil, err
+		}
+		key = string(pk)
+	}
+	pk, err := decodeBech32(key, hrpPublicKey, ed25519.PublicKeySize)
+	if err != nil {
+		return nil, err
+	}
+	return ed25519.PublicKey(pk), nil
+}
+
+// publicKeyString returns the public key of sk in jcli format.
+func publicKeyString(sk ed25519.PrivateKey) (string, error) {
+	return bech32.Encode(hrpPublicKey, sk.Public().(ed25519.PublicKey))
+}
+
+// signFile signs the content of file, the signature is written next to it with the .sig extension.
+func signFile(sk ed25519.PrivateKey, file string) (string, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	sig, err := bech32.Encode(hrpSignature, ed25519.Sign(sk, data))
+	if err != nil {
+		return "", err
+	}
+	sigFile := file + signatureExt
+	return sigFile, ioutil.WriteFile(sigFile, []byte(sig), 0644)
+}
+
+// verifyFile checks the signature of file against the public key.
+func verifyFile(pk ed25519.PublicKey, file string, sigFile string) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	sigStr, err := ioutil.ReadFile(sigFile)
+	if err != nil {
+		return err
+	}
+	sig, err := decodeBech32(string(sigStr), hrpSignature, ed25519.SignatureSize)
+	if err != nil {
+		return fmt.Errorf("%s: %w", sigFile, err)
+	}
+	if !ed25519.Verify(pk, data, sig) {
+		return fmt.Errorf("%s - signature verification failed with [%s]", file, sigFile)
+	}
+	return nil
+}
+
+// Fetcher retrieves the inputs, http(s) requests are retried with exponential backoff up to RetryWaitMax.
+type Fetcher struct {
+	Client       *http.Client
+	Headers      http.Header
+	Retries      uint
+	RetryWait    time.Duration
+	RetryWaitMax time.Duration
+
+	sqlite map[string]*sqliteDB // vit-servicing-station databases, by file
+}
+
+// HttpStatusError is returned when the http response status is not 2xx.
+type HttpStatusError struct {
+	Url        string
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // from the Retry-After header of 429/503 responses, 0 if not provided
+}
+
+func (e *HttpStatusError) Error() string {
+	return fmt.Sprintf("GET [%s] - %d %s - %s", e.Url, e.StatusCode, http.StatusText(e.StatusCode), e.Body)
+}
+
+// Temporary reports whether the request is worth to be retried.
+func (e *HttpStatusError) Temporary() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests
+}
+
+func (f *Fetcher) fetchData(ctx context.Context, u *url.URL) ([]byte, error) {
 	switch u.Scheme {
 	case "http", "https":
-		data, err = httpGet(client, u.String())
+		return f.httpGetRetry(ctx, u.String())
 	case "file":
-		data, err = ioutil.ReadFile(u.Host + u.Path)
+		return ioutil.ReadFile(u.Host + u.Path)
+	case "sqlite":
+		return f.sqliteData(ctx, u)
 	default:
-		err = fmt.Errorf("unknown schema: [%s] from [%s]", u.Scheme, u.String())
+		return nil, fmt.Errorf("unknown schema: [%s] from [%s]", u.Scheme, u.String())
 	}
+}
+
+// getData fetches and decodes the data into dst, returning also the raw bytes.
+func (f *Fetcher) getData(ctx context.Context, u *url.URL, dst interface{}) ([]byte, error) {
+	data, err := f.fetchData(ctx, u)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	return data, json.Unmarshal(data, &dst)
+}
+
+// retryDelay returns the wait before the next retry, the backoff wait capped to RetryWaitMax,
+// or the server requested Retry-After. A Retry-After longer than RetryWaitMax is not retried.
+func (f *Fetcher) retryDelay(wait time.Duration, err error) (time.Duration, error) {
+	if f.RetryWaitMax > 0 && wait > f.RetryWaitMax {
+		wait = f.RetryWaitMax
+	}
+
+	var statusErr *HttpStatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+		if f.RetryWaitMax > 0 && statusErr.RetryAfter > f.RetryWaitMax {
+			return 0, fmt.Errorf("Retry-After %s exceeds %s: %w", statusErr.RetryAfter, f.RetryWaitMax, err)
+		}
+		wait = statusErr.RetryAfter
 	}
+	return wait, nil
+}
 
-	return json.Unmarshal(data, &dst)
+// retryable reports whether a failed request is worth to be retried: 5xx/429 responses,
+// timeouts and temporary network errors. Permanent ones (DNS not found, TLS certificate,
+// connection refused, ...) fail right away.
+func retryable(err error) bool {
+	var statusErr *HttpStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Temporary()
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && (netErr.Timeout() || netErr.Temporary())
 }
 
-func httpGet(client *http.Client, u string) ([]byte, error) {
-	req, err := http.NewRequest("GET", u, nil)
+// httpGetRetry retries httpGet on timeouts, temporary network errors and 5xx/429 responses, until ctx is done.
+func (f *Fetcher) httpGetRetry(ctx context.Context, u string) ([]byte, error) {
+	backoff := f.RetryWait
+	for attempt := uint(0); ; attempt++ {
+		data, err := f.httpGet(ctx, u)
+		if err == nil {
+			return data, nil
+		}
+
+		if ctx.Err() != nil || !retryable(err) {
+			return nil, err
+		}
+		if attempt >= f.Retries {
+			return nil, fmt.Errorf("%d attempts failed: %w", attempt+1, err)
+		}
+
+		wait, delayErr := f.retryDelay(backoff, err)
+		if delayErr != nil {
+			return nil, fmt.Errorf("%d attempts failed: %w", attempt+1, delayErr)
+		}
+
+		log.Printf("GET [%s] - attempt %d failed, retry in %s: %v", u, attempt+1, wait, err)
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("%d attempts failed: %v: %w", attempt+1, err, ctx.Err())
+		}
+		if f.RetryWaitMax <= 0 || backoff < f.RetryWaitMax {
+			backoff *= 2
+		}
+	}
+}
+
+// parseRetryAfter reads the Retry-After header, given as seconds or as http date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.ParseUint(value, 10, 32); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+func (f *Fetcher) httpGet(ctx context.Context, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
 	if err != nil {
 		return nil, err
 	}
 	req.Close = true
+	for key, values := range f.Headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
 
-	res, err := client.Do(req)
+	res, err := f.Client.Do(req)
 	if err != nil {
 		return nil, err
 	}
+	defer res.Body.Close()
 
 	data, err := ioutil.ReadAll(res.Body)
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = io.Copy(ioutil.Discard, res.Body) // WE READ THE BODY
-	if err != nil {
-		return nil, err
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		body := kit.B2S(data)
+		if len(body) > 256 {
+			body = body[:256] + "..."
+		}
+		statusErr := &HttpStatusError{Url: u, StatusCode: res.StatusCode, Body: body}
+		if res.StatusCode == http.StatusTooManyRequests || res.StatusCode == http.StatusServiceUnavailable {
+			statusErr.RetryAfter = parseRetryAfter(res.Header.Get("Retry-After"), time.Now())
+		}
+		return nil, statusErr
 	}
-	res.Body.Close()
 
 	return data, nil
 }
 
+// newHttpClient with custom CA bundle, or skipping the server certificate verification.
+func newHttpClient(timeout time.Duration, caFile string, insecure bool) (*http.Client, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: insecure,
+	}
+
+	if caFile != "" {
+		caPEM, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil || rootCAs == nil {
+			rootCAs = x509.NewCertPool()
+		}
+		if !rootCAs.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("%s - no valid PEM certificates found", caFile)
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}, nil
+}
+
+// parseHeaders converts "Key: Value" entries to http.Header.
+func parseHeaders(entries []string) (http.Header, error) {
+	headers := make(http.Header, len(entries))
+	for _, entry := range entries {
+		kv := strings.SplitN(entry, ":", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("%s - expected in \"Key: Value\" format - but [%s] provided", "http-header", entry)
+		}
+		headers.Add(strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1]))
+	}
+	return headers, nil
+}
+
+type sliceFlag []string
+
+func (sf *sliceFlag) String() string {
+	return strings.Join(*sf, ",")
+}
+
+func (sf *sliceFlag) Set(val string) error {
+	*sf = append(*sf, val)
+	return nil
+}
+
+// inputUrls parses the voteplans (from the node), proposals and fund (from the service) addresses.
+func inputUrls(nodeUrl, serviceUrl, votePlans, proposals, funds string) (vpUrl, prUrl, fuUrl *url.URL, err error) {
+	if vpUrl, err = url.ParseRequestURI(nodeUrl + votePlans); err != nil {
+		return nil, nil, nil, fmt.Errorf("%s: %w", votePlans, err)
+	}
+	if prUrl, err = url.ParseRequestURI(serviceUrl + proposals); err != nil {
+		return nil, nil, nil, fmt.Errorf("%s: %w", proposals, err)
+	}
+	if fuUrl, err = url.ParseRequestURI(serviceUrl + funds); err != nil {
+		return nil, nil, nil, fmt.Errorf("%s: %w", funds, err)
+	}
+	return vpUrl, prUrl, fuUrl, nil
+}
+
+// Snapshot holds the inputs fetched at one point in time, decoded and raw.
+type Snapshot struct {
+	VotePlans []vresult.VotePlans
+	Proposals []vresult.ProposalsResult
+	Funds     loader.FundData
+	Manifest  AuditManifest
+	Raw       map[string][]byte
+}
+
+// fetchSnapshot retrieves voteplans, proposals and fund info.
+func fetchSnapshot(ctx context.Context, fetcher *Fetcher, vpUrl, prUrl, fuUrl *url.URL) (*Snapshot, error) {
+	var (
+		err  error
+		snap = Snapshot{
+			Manifest: AuditManifest{Version: Version},
+			Raw:      make(map[string][]byte, 3),
+		}
+	)
+
+	snap.Raw[auditVotePlansFile], err = fetcher.getData(ctx, vpUrl, &snap.VotePlans)
+	if err != nil {
+		return nil, fmt.Errorf("getData VotePlans: %w", err)
+	}
+	snap.Manifest.Entries = append(snap.Manifest.Entries, NewAuditEntry(auditVotePlansFile, vpUrl.String(), time.Now(), snap.Raw[auditVotePlansFile]))
+
+	snap.Raw[auditProposalsFile], err = fetcher.getData(ctx, prUrl, &snap.Proposals)
+	if err != nil {
+		return nil, fmt.Errorf("getData Proposals: %w", err)
+	}
+	snap.Manifest.Entries = append(snap.Manifest.Entries, NewAuditEntry(auditProposalsFile, prUrl.String(), time.Now(), snap.Raw[auditProposalsFile]))
+
+	snap.Raw[auditFundsFile], err = fetcher.getData(ctx, fuUrl, &snap.Funds)
+	if err != nil {
+		return nil, fmt.Errorf("getData Funds: %w", err)
+	}
+	snap.Manifest.Entries = append(snap.Manifest.Entries, NewAuditEntry(auditFundsFile, fuUrl.String(), time.Now(), snap.Raw[auditFundsFile]))
+
+	snap.Manifest.Created = time.Now().UTC().Format(time.RFC3339Nano)
+
+	return &snap, nil
+}
+
+// writeResult dumps the proposals results into a csv file.
+func writeResult(file string, proposals []vresult.ProposalsResult) error {
+	tallyFile, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	err = gocsv.MarshalFile(&proposals, tallyFile)
+	if err != nil {
+		tallyFile.Close()
+		return err
+	}
+	return tallyFile.Close()
+}
+
+// writeAggregates dumps the aggregates into a json file.
+func writeAggregates(file string, aggregates vresult.Aggregates) error {
+	aggregatesJson, err := json.MarshalIndent(aggregates, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, aggregatesJson, 0644)
+}
+
+// printAggregates prints a short summary line per challenge and category.
+func printAggregates(w io.Writer, aggregates vresult.Aggregates) {
+	for _, section := range []struct {
+		name   string
+		groups []vresult.GroupStats
+	}{
+		{"challenge", aggregates.Challenges},
+		{"category", aggregates.Categories},
+	} {
+		fmt.Fprintf(w, "Aggregates by %s:\n", section.name)
+		for _, g := range section.groups {
+			fmt.Fprintf(w, "  [%s] proposals: %d, requested funds: %d, votes cast: %d (min: %d, median: %.1f, max: %d, no votes: %d)\n",
+				g.Group, g.Proposals, g.RequestedFunds, g.VotesCast,
+				g.Participation.Min, g.Participation.Median, g.Participation.Max, g.Participation.NoVotes,
+			)
+		}
+	}
+}
+
+// VotesCastSample is one time series point of the votes cast on a proposal.
+type VotesCastSample struct {
+	Timestamp         string `csv:"timestamp"`
+	VotePlanID        string `csv:"chain_voteplan_id"`
+	VotePlanPayload   string `csv:"chain_voteplan_payload"`
+	VotePlanVotesCast uint   `csv:"voteplan_votes_cast"`
+	ProposalIndex     uint8  `csv:"chain_proposal_index"`
+	ProposalID        string `csv:"chain_proposal_id"`
+	InternalID        uint64 `csv:"internal_id"`
+	VotesCast         uint   `csv:"votes_cast"`
+}
+
+// votesCastSamples builds the time series points for every voteplan proposal.
+func votesCastSamples(at time.Time, votePlans []vresult.VotePlans, proposals []vresult.ProposalsResult) []VotesCastSample {
+	internalIDs := make(map[string]uint64, len(proposals))
+	for i := range proposals {
+		internalIDs[proposals[i].VotePlanID+proposals[i].ChainProposal.ExternalID] = proposals[i].InternalID
+	}
+
+	samples := make([]VotesCastSample, 0, len(proposals))
+	for x := range votePlans {
+		var votePlanVotesCast uint
+		for y := range votePlans[x].Proposals {
+			votePlanVotesCast += votePlans[x].Proposals[y].VotesCast
+		}
+
+		for y := range votePlans[x].Proposals {
+			samples = append(samples, VotesCastSample{
+				Timestamp:         at.UTC().Format(time.RFC3339),
+				VotePlanID:        votePlans[x].ID,
+				VotePlanPayload:   votePlans[x].Payload,
+				VotePlanVotesCast: votePlanVotesCast,
+				ProposalIndex:     votePlans[x].Proposals[y].Index,
+				ProposalID:        votePlans[x].Proposals[y].ProposalID,
+				InternalID:        internalIDs[votePlans[x].ID+votePlans[x].Proposals[y].ProposalID],
+				VotesCast:         votePlans[x].Proposals[y].VotesCast,
+			})
+		}
+	}
+	return samples
+}
+
+// appendVotesCast appends the samples to the csv file, header included only once.
+func appendVotesCast(file string, samples []VotesCastSample) error {
+	sf, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := sf.Stat()
+	if err != nil {
+		sf.Close()
+		return err
+	}
+
+	if info.Size() == 0 {
+		err = gocsv.Marshal(&samples, sf)
+	} else {
+		err = gocsv.MarshalWithoutHeaders(&samples, sf)
+	}
+	if err != nil {
+		sf.Close()
+		return err
+	}
+	return sf.Close()
+}
+
+// verifyTarget checks the signature of a result file, or of an audit bundle (directory or .tar.gz).
+// Bundles are verified by their manifest signature, then by the manifest hashes.
+// If sigFile is empty the file (or the bundle manifest) with ".sig" extension is used.
+func verifyTarget(pk ed25519.PublicKey, target string, sigFile string) error {
+	file := target
+
+	isBundle := isTarGz(target)
+	if !isBundle {
+		fi, err := os.Stat(target)
+		if err != nil {
+			return err
+		}
+		isBundle = fi.IsDir()
+	}
+	if isBundle {
+		bundleDir := target
+		if isTarGz(target) {
+			var err error
+			bundleDir, err = untarAuditBundle(target)
+			if err != nil {
+				return fmt.Errorf("untarAuditBundle: %w", err)
+			}
+			defer os.RemoveAll(bundleDir)
+		}
+		file = filepath.Join(bundleDir, auditManifestFile)
+	}
+
+	if sigFile == "" {
+		sigFile = file + signatureExt
+	}
+	if err := verifyFile(pk, file, sigFile); err != nil {
+		return err
+	}
+
+	if isBundle {
+		if _, err := readAuditBundle(filepath.Dir(file)); err != nil {
+			return fmt.Errorf("readAuditBundle: %w", err)
+		}
+	}
+	return nil
+}
+
+// verifyCmd - vitresult verify -public-key <pk|file> [-signature file] <result|bundle>
+func verifyCmd(args []string) {
+	var (
+		fs        = flag.NewFlagSet("verify", flag.ExitOnError)
+		publicKey = fs.String("public-key", "", "Published PK (public key) of the signer, or file containing it. ex: ed25519_pk1...")
+		signature = fs.String("signature", "", "Signature file. Defaults to the file (or the bundle manifest) with \".sig\" extension")
+	)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s verify [options] <result file|bundle>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *publicKey == "" {
+		log.Fatalf("[%s] - parameter missing", "public-key")
+	}
+
+	pk, err := parsePublicKey(*publicKey)
+	kit.FatalOn(err, "public-key", *publicKey)
+
+	target := fs.Arg(0)
+	err = verifyTarget(pk, target, *signature)
+	kit.FatalOn(err, "verify", target)
+
+	fmt.Printf("Signature OK: %s\n", target)
+}
+
 func main() {
+	// subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "diff":
+			diffCmd(os.Args[2:])
+			return
+		case "verify":
+			verifyCmd(os.Args[2:])
+			return
+		}
+	}
+
 	var (
 		// Http
-		client = http.Client{
-			Timeout: time.Second * 10,
-		}
-		// Data
-		votePlans []VotePlans
-		proposals []ProposalsResult
-		funds     loader.FundData
+		httpHeaders sliceFlag
 		// Flags
-		serviceUrl   = flag.String("service-addr", "https://servicing-station.vit.iohk.io", "Address of remote service, or file://")
+		serviceUrl   = flag.String("service-addr", "https://servicing-station.vit.iohk.io", "Address of remote service, or file://, or sqlite://<vit-station database file> (\"proposals\" and \"funds\" are ignored, \"node-addr\" still required for the tally results)")
 		nodeUrl      = flag.String("node-addr", "https://servicing-station.vit.iohk.io", "Address of remote service, or file://")
 		votePlansUrl = flag.String("vote-plans", "/api/v0/vote/active/plans", "Endpoint (or file path) containing  tally results from the chain, added to \"node-addr\"")
 		proposalsUrl = flag.String("proposals", "/api/v0/proposals", "Endpoint (or file path) containing proposals, added to \"service-addr\"")
 		fundsUrl     = flag.String("funds", "/api/v0/fund", "Endpoint (or file path) containing fund info, added to \"service-addr\"")
 		timeout      = flag.String("http-timeout", "10s", "Http request timeout")
+		retries      = flag.Uint("http-retries", 3, "Http request retries on timeouts, temporary network errors and 5xx/429 responses")
+		retryWait    = flag.String("http-retry-wait", "1s", "Http wait before the first retry, doubled on each next one")
+		retryWaitMax = flag.String("http-retry-wait-max", "30s", "Http max wait between retries, also for the \"Retry-After\" of 429/503 responses")
+		caFile       = flag.String("http-ca-file", "", "PEM file with extra CA certificates to trust (ex: self signed staging endpoints)")
+		insecure     = flag.Bool("http-insecure", false, "Skip the server TLS certificate verification")
 		// Flags - TallyResult file
 		tallyResultFile = flag.String("result-file", "TallyResult.csv", "File name of the output result")
+		// Flags - outcome rules
+		quorumVotes     = flag.Uint("quorum-votes", 0, "Minimum votes cast for a proposal to be ranked. 0 disabled")
+		quorumStakePct  = flag.Float64("quorum-stake-pct", 0, "Minimum tallied stake, as percentage of \"registered-stake\", for a proposal to be ranked, \"pending\" until tallied. 0 disabled")
+		registeredStake = flag.Uint64("registered-stake", 0, "Total registered stake (lovelace) used by \"quorum-stake-pct\"")
+		// Flags - Aggregates file
+		aggregateFile = flag.String("aggregate-file", "", "File name of the per challenge and per category aggregates (ex: TallyAggregate.json). Disabled if empty")
+		aggregateTop  = flag.Int("aggregate-top", 5, "Number of top proposals listed for each challenge and category. -1 for all")
+		// Flags - audit
+		auditDir    = flag.String("audit-dir", "", "Directory where the raw inputs and their manifest are archived for audit")
+		auditTar    = flag.Bool("audit-tar", false, "Pack also \"audit-dir\" into a \"audit-dir\".tar.gz archive")
+		auditBundle = flag.String("audit-bundle", "", "Audit bundle (directory or .tar.gz) to re-run from. Overrides the inputs addresses")
+		// Flags - signature
+		signKey = flag.String("sign-key", "", "File containing the SK (secret key) used to sign the result file and the audit manifest (ex: a BFT leader ed25519_sk)")
+		// Flags - watch
+		watch     = flag.String("watch", "", "Keep polling the inputs at the given interval (ex: 5m), regenerating the result each cycle")
+		watchFile = flag.String("watch-file", "VotesCast.csv", "CSV file where the votes cast time series is appended on each \"watch\" cycle")
 		// Flags - version info
 		version = flag.Bool("version", false, "Print current app version and build info")
 	)
 
+	flag.Var(&httpHeaders, "http-header", "Extra http request header in \"Key: Value\" format, can be repeated. ex: \"API-Token: xxxxx\"")
+
 	flag.Parse()
 
 	// version info
@@ -170,96 +912,194 @@ func main() {
 	// Http timeout
 	timeoutDur, err := time.ParseDuration(*timeout)
 	kit.FatalOn(err, "http-timeout:", *timeout)
-	client.Timeout = timeoutDur
+	retryWaitDur, err := time.ParseDuration(*retryWait)
+	kit.FatalOn(err, "http-retry-wait:", *retryWait)
+	retryWaitMaxDur, err := time.ParseDuration(*retryWaitMax)
+	kit.FatalOn(err, "http-retry-wait-max:", *retryWaitMax)
+	headers, err := parseHeaders(httpHeaders)
+	kit.FatalOn(err, "http-header")
+	client, err := newHttpClient(timeoutDur, *caFile, *insecure)
+	kit.FatalOn(err, "http-ca-file:", *caFile)
+
+	fetcher := &Fetcher{
+		Client:       client,
+		Headers:      headers,
+		Retries:      *retries,
+		RetryWait:    retryWaitDur,
+		RetryWaitMax: retryWaitMaxDur,
+	}
+
+	// Outcome rules
+	if *quorumStakePct < 0 || *quorumStakePct > 100 {
+		log.Fatalf("[%s: %v] - wrong value, expected [0-100]", "quorum-stake-pct", *quorumStakePct)
+	}
+	if *quorumStakePct > 0 && *registeredStake == 0 {
+		log.Fatalf("[%s] - needed when [%s] is set", "registered-stake", "quorum-stake-pct")
+	}
+	rules := vresult.Rules{
+		QuorumVotes:     *quorumVotes,
+		QuorumStakePct:  *quorumStakePct,
+		RegisteredStake: *registeredStake,
+	}
+
+	// Signing key
+	var sk ed25519.PrivateKey
+	if *signKey != "" {
+		sk, err = readSigningKey(*signKey)
+		kit.FatalOn(err, "sign-key", *signKey)
+		pk, err := publicKeyString(sk)
+		kit.FatalOn(err, "sign-key", *signKey)
+		fmt.Printf("Signing with public key: %s\n", pk)
+	}
+
+	// Watch interval
+	var watchDur time.Duration
+	if *watch != "" {
+		watchDur, err = time.ParseDuration(*watch)
+		kit.FatalOn(err, "watch:", *watch)
+		if watchDur <= 0 {
+			log.Fatalf("[%s: %s] - wrong value, expected > 0", "watch", *watch)
+		}
+		if *auditBundle != "" {
+			log.Fatalf("[%s] - can't be used together with [%s]", "watch", "audit-bundle")
+		}
+	}
+
+	// Re-run from an audit bundle, the inputs are read from the bundle files.
+	// An extracted archive is removed by cleanup, that has to run before any exit from here on.
+	cleanup := func() {}
+	if *auditBundle != "" {
+		var bundleDir string
+		bundleDir, cleanup, err = openAuditBundle(*auditBundle)
+		kit.FatalOn(err, "audit-bundle", *auditBundle)
+
+		*nodeUrl, *serviceUrl = "file://", "file://"
+		*votePlansUrl = filepath.ToSlash(filepath.Join(bundleDir, auditVotePlansFile))
+		*proposalsUrl = filepath.ToSlash(filepath.Join(bundleDir, auditProposalsFile))
+		*fundsUrl = filepath.ToSlash(filepath.Join(bundleDir, auditFundsFile))
+	}
+
+	// Read proposals and fund straight from the vit-station database,
+	// voteplans and tally results live only on the node so a running node (or its file:// dump) is still required.
+	if strings.HasPrefix(*nodeUrl, "sqlite://") {
+		log.Fatalf("[%s: %s] - voteplans and tally results are not in the vit-station database, a running node (or file://) is still required", "node-addr", *nodeUrl)
+	}
+	if strings.HasPrefix(*serviceUrl, "sqlite://") {
+		*proposalsUrl = "?data=" + sqliteProposals
+		*fundsUrl = "?data=" + sqliteFund
+		log.Printf("proposals and fund from [%s], voteplans and tally results still from the node [%s]", *serviceUrl, *nodeUrl+*votePlansUrl)
+	}
 
 	// Parse URI
-	vpUrl, err := url.ParseRequestURI(*nodeUrl + *votePlansUrl)
-	kit.FatalOn(err, "url.ParseRequestURI:", *votePlansUrl)
-	prUrl, err := url.ParseRequestURI(*serviceUrl + *proposalsUrl)
-	kit.FatalOn(err, "url.ParseRequestURI:", *proposalsUrl)
-	fuUrl, err := url.ParseRequestURI(*serviceUrl + *fundsUrl)
-	kit.FatalOn(err, "url.ParseRequestURI:", *fundsUrl)
-
-	// Fetch Data
-	kit.FatalOn(getData(&client, vpUrl, &votePlans), "getData VotePlans")
-	kit.FatalOn(getData(&client, prUrl, &proposals), "getData Proposals")
-	kit.FatalOn(getData(&client, fuUrl, &funds), "getData Funds")
+	vpUrl, prUrl, fuUrl, err := inputUrls(*nodeUrl, *serviceUrl, *votePlansUrl, *proposalsUrl, *fundsUrl)
+	if err != nil {
+		cleanup()
+	}
+	kit.FatalOn(err, "url.ParseRequestURI")
 
-	for i := range proposals {
-		for x := range votePlans {
-			// skip other voteplans id
-			if proposals[i].VotePlanID != votePlans[x].ID {
-				continue
+	// report fetches, merges and dumps the results (and the audit bundle if requested)
+	report := func(ctx context.Context) (*Snapshot, error) {
+		snap, err := fetchSnapshot(ctx, fetcher, vpUrl, prUrl, fuUrl)
+		if err != nil {
+			return nil, err
+		}
+
+		// Audit - dump
+		if *auditDir != "" {
+			err = writeAuditBundle(*auditDir, &snap.Manifest, snap.Raw)
+			if err != nil {
+				return nil, fmt.Errorf("writeAuditBundle %s: %w", *auditDir, err)
 			}
+			fmt.Printf("Audit bundle ready at: %s\n", *auditDir)
 
-			for y := range votePlans[x].Proposals {
-				// skip other proposals index
-				if proposals[i].ChainProposal.Index != votePlans[x].Proposals[y].Index {
-					continue
+			if sk != nil {
+				sigFile, err := signFile(sk, filepath.Join(*auditDir, auditManifestFile))
+				if err != nil {
+					return nil, fmt.Errorf("signFile %s: %w", auditManifestFile, err)
 				}
-				// skip other proposals id - in theory this should not never be the case since we matched index
-				if proposals[i].ChainProposal.ExternalID != votePlans[x].Proposals[y].ProposalID {
-					continue
+				fmt.Printf("Audit manifest signature ready at: %s\n", sigFile)
+			}
+
+			if *auditTar {
+				auditArchive := filepath.Clean(*auditDir) + ".tar.gz"
+				err = tarAuditBundle(*auditDir, &snap.Manifest, auditArchive)
+				if err != nil {
+					return nil, fmt.Errorf("tarAuditBundle %s: %w", auditArchive, err)
 				}
+				fmt.Printf("Audit bundle archive ready at: %s\n", auditArchive)
+			}
+		}
 
-				// set the number of votes casted, so it is available even when no tally yet
-				proposals[i].VotesCast = votePlans[x].Proposals[y].VotesCast
+		snap.Proposals = vresult.ApplyRules(vresult.Merge(snap.VotePlans, snap.Proposals), rules)
 
-				// we should have the tally done and only one payload
-				if len(votePlans[x].Proposals[y].Tally) != 1 {
-					continue
-				}
+		// TallyResult - dump
+		err = writeResult(*tallyResultFile, snap.Proposals)
+		if err != nil {
+			return nil, fmt.Errorf("tallyFile csv %s: %w", *tallyResultFile, err)
+		}
+		fmt.Printf("Result ready at: %s\n", *tallyResultFile)
 
-				for _, res := range votePlans[x].Proposals[y].Tally {
-					for r, tr := range res.Result.Results {
-						switch r {
-						// TODO: this is ...
-						case 0:
-							proposals[i].TallyOptions.Tally00 = tr
-						case 1:
-							proposals[i].TallyOptions.Tally01 = tr
-						case 2:
-							proposals[i].TallyOptions.Tally02 = tr
-						case 3:
-							proposals[i].TallyOptions.Tally03 = tr
-						case 4:
-							proposals[i].TallyOptions.Tally04 = tr
-						case 5:
-							proposals[i].TallyOptions.Tally05 = tr
-						case 6:
-							proposals[i].TallyOptions.Tally06 = tr
-						case 7:
-							proposals[i].TallyOptions.Tally07 = tr
-						case 8:
-							proposals[i].TallyOptions.Tally08 = tr
-						case 9:
-							proposals[i].TallyOptions.Tally09 = tr
-						case 10:
-							proposals[i].TallyOptions.Tally10 = tr
-						case 11:
-							proposals[i].TallyOptions.Tally11 = tr
-						case 12:
-							proposals[i].TallyOptions.Tally12 = tr
-						case 13:
-							proposals[i].TallyOptions.Tally13 = tr
-						case 14:
-							proposals[i].TallyOptions.Tally14 = tr
-						case 15:
-							proposals[i].TallyOptions.Tally15 = tr
-						}
-					}
-				}
+		if sk != nil {
+			sigFile, err := signFile(sk, *tallyResultFile)
+			if err != nil {
+				return nil, fmt.Errorf("signFile %s: %w", *tallyResultFile, err)
+			}
+			fmt.Printf("Result signature ready at: %s\n", sigFile)
+		}
+
+		// Aggregates - dump
+		if *aggregateFile != "" {
+			aggregates := vresult.Aggregate(snap.Proposals, *aggregateTop)
+			printAggregates(os.Stdout, aggregates)
+
+			err = writeAggregates(*aggregateFile, aggregates)
+			if err != nil {
+				return nil, fmt.Errorf("aggregateFile json %s: %w", *aggregateFile, err)
 			}
+			fmt.Printf("Aggregates ready at: %s\n", *aggregateFile)
 		}
+
+		return snap, nil
 	}
 
-	// TallyResult - dump
-	tallyFile, err := os.Create(*tallyResultFile)
-	kit.FatalOn(err, "tallyFile csv CREATE", *tallyResultFile)
-	err = gocsv.MarshalFile(&proposals, tallyFile)
-	kit.FatalOn(err, "tallyFile csv WRITE", *tallyResultFile)
-	err = tallyFile.Close()
-	kit.FatalOn(err, "tallyFile csv CLOSE", *tallyResultFile)
+	if watchDur == 0 {
+		_, err = report(context.Background())
+		cleanup()
+		kit.FatalOn(err, "report")
+		return
+	}
+
+	// Watch mode, keep reporting and tracking the votes cast until SIGINT/SIGTERM,
+	// that also interrupts the running fetches and their retries.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	ticker := time.NewTicker(watchDur)
+	defer ticker.Stop()
 
-	fmt.Printf("Result ready at: %s\n", *tallyResultFile)
+	for {
+		now := time.Now()
+		snap, err := report(ctx)
+		switch {
+		case ctx.Err() != nil:
+			log.Println("watch - done")
+			return
+		case err != nil:
+			log.Printf("watch - %v", err)
+		default:
+			err = appendVotesCast(*watchFile, votesCastSamples(now, snap.VotePlans, snap.Proposals))
+			if err != nil {
+				log.Printf("watch - votes cast csv %s: %v", *watchFile, err)
+			} else {
+				fmt.Printf("Votes cast series updated at: %s\n", *watchFile)
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			log.Println("watch - done")
+			return
+		}
+	}
 }
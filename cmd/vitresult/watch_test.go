@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gocarina/gocsv"
+	"github.com/input-output-hk/jorvit/internal/loader"
+	"github.com/input-output-hk/jorvit/pkg/vresult"
+)
+
+func testWatchInputs() ([]vresult.VotePlans, []vresult.ProposalsResult) {
+	votePlans := []vresult.VotePlans{
+		{
+			ID:      "vp1",
+			Payload: "public",
+			Proposals: []vresult.VoteProposal{
+				{Index: 0, ProposalID: "p0", VotesCast: 3},
+				{Index: 1, ProposalID: "p1", VotesCast: 4},
+			},
+		},
+		{
+			ID:      "vp2",
+			Payload: "private",
+			Proposals: []vresult.VoteProposal{
+				{Index: 0, ProposalID: "p2", VotesCast: 0},
+			},
+		},
+	}
+
+	proposal := func(internalID uint64, votePlanID string, externalID string) vresult.ProposalsResult {
+		var p vresult.ProposalsResult
+		p.InternalID = internalID
+		p.ChainVotePlan = &loader.ChainVotePlan{VotePlanID: votePlanID}
+		p.ExternalID = externalID
+		return p
+	}
+	proposals := []vresult.ProposalsResult{
+		proposal(10, "vp1", "p0"),
+		proposal(11, "vp1", "p1"),
+		proposal(12, "vp2", "p2"),
+	}
+	return votePlans, proposals
+}
+
+func TestVotesCastSamples(t *testing.T) {
+	votePlans, proposals := testWatchInputs()
+	at := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	samples := votesCastSamples(at, votePlans, proposals)
+
+	want := []VotesCastSample{
+		{"2021-03-01T10:00:00Z", "vp1", "public", 7, 0, "p0", 10, 3},
+		{"2021-03-01T10:00:00Z", "vp1", "public", 7, 1, "p1", 11, 4},
+		{"2021-03-01T10:00:00Z", "vp2", "private", 0, 0, "p2", 12, 0},
+	}
+	if len(samples) != len(want) {
+		t.Fatalf("samples: got %d, want %d", len(samples), len(want))
+	}
+	for i := range want {
+		if samples[i] != want[i] {
+			t.Errorf("sample [%d]: got %+v, want %+v", i, samples[i], want[i])
+		}
+	}
+}
+
+func TestAppendVotesCast(t *testing.T) {
+	votePlans, proposals := testWatchInputs()
+	file := filepath.Join(t.TempDir(), "VotesCast.csv")
+
+	first := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	for _, at := range []time.Time{first, first.Add(5 * time.Minute)} {
+		if err := appendVotesCast(file, votesCastSamples(at, votePlans, proposals)); err != nil {
+			t.Fatalf("appendVotesCast: %v", err)
+		}
+	}
+
+	sf, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sf.Close()
+
+	// a second header would fail to decode as a sample row
+	var samples []VotesCastSample
+	if err = gocsv.Unmarshal(sf, &samples); err != nil {
+		t.Fatalf("gocsv.Unmarshal: %v", err)
+	}
+	if len(samples) != 6 {
+		t.Fatalf("samples: got %d, want %d", len(samples), 6)
+	}
+	if samples[0].Timestamp == samples[3].Timestamp {
+		t.Errorf("samples: expected two cycles, got the same timestamp [%s]", samples[0].Timestamp)
+	}
+}
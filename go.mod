@@ -1,9 +1,11 @@
 module github.com/input-output-hk/jorvit
 
-go 1.15
+go 1.16
 
 require (
 	github.com/gocarina/gocsv v0.0.0-20201103164230-b291445e0dd2
 	github.com/rinor/jorcli v0.0.0-20201117192102-2a69360d3a83
 	golang.org/x/crypto v0.0.0-20201117144127-c1f2f97bffc9
+	gopkg.in/yaml.v2 v2.4.0
+	modernc.org/sqlite v1.11.2
 )
@@ -0,0 +1,149 @@
+// Package bech32 provides the bech32 encoding used by jcli for keys and signatures.
+//
+// Decode doesn't enforce the BIP-0173 MaxLength, since jcli keys, signatures and
+// addresses exceed it, DecodeLimit can be used when the limit applies.
+package bech32
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaxLength of a bech32 string as defined by BIP-0173.
+const MaxLength = 90
+
+const charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+var generator = [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+func polymod(values []byte) uint32 {
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+func hrpExpand(hrp string) []byte {
+	ret := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		ret = append(ret, hrp[i]>>5)
+	}
+	ret = append(ret, 0)
+	for i := 0; i < len(hrp); i++ {
+		ret = append(ret, hrp[i]&31)
+	}
+	return ret
+}
+
+// convertBits regroups the data from fromBits to toBits groups.
+func convertBits(data []byte, fromBits uint, toBits uint, pad bool) ([]byte, error) {
+	var (
+		acc    uint32
+		bits   uint
+		ret    []byte
+		maxv   = uint32(1)<<toBits - 1
+		maxAcc = uint32(1)<<(fromBits+toBits-1) - 1
+	)
+	for _, v := range data {
+		if uint32(v)>>fromBits != 0 {
+			return nil, fmt.Errorf("invalid data value: %d", v)
+		}
+		acc = (acc<<fromBits | uint32(v)) & maxAcc
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			ret = append(ret, byte(acc>>bits&maxv))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			ret = append(ret, byte(acc<<(toBits-bits)&maxv))
+		}
+	} else if bits >= fromBits || acc<<(toBits-bits)&maxv != 0 {
+		return nil, fmt.Errorf("invalid padding")
+	}
+	return ret, nil
+}
+
+// Encode data bytes with the given human readable part.
+func Encode(hrp string, data []byte) (string, error) {
+	values, err := convertBits(data, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+
+	hrp = strings.ToLower(hrp)
+	chk := polymod(append(append(hrpExpand(hrp), values...), 0, 0, 0, 0, 0, 0)) ^ 1
+
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, v := range values {
+		sb.WriteByte(charset[v])
+	}
+	for i := 0; i < 6; i++ {
+		sb.WriteByte(charset[(chk>>uint(5*(5-i)))&31])
+	}
+	return sb.String(), nil
+}
+
+// Decode a bech32 string of any length returning the human readable part and the data bytes.
+func Decode(str string) (string, []byte, error) {
+	return DecodeLimit(str, 0)
+}
+
+// DecodeLimit decodes a bech32 string not longer than limit chars (ex: MaxLength), 0 for no limit.
+func DecodeLimit(str string, limit int) (string, []byte, error) {
+	hrp, values, err := decode(str, limit)
+	if err != nil {
+		return "", nil, err
+	}
+	data, err := convertBits(values, 5, 8, false)
+	if err != nil {
+		return "", nil, err
+	}
+	return hrp, data, nil
+}
+
+// decode verifies the bech32 string returning the human readable part and the 5 bits data values.
+func decode(str string, limit int) (string, []byte, error) {
+	if limit > 0 && len(str) > limit {
+		return "", nil, fmt.Errorf("bech32 string too long: %d chars, limit %d", len(str), limit)
+	}
+	for i := 0; i < len(str); i++ {
+		if str[i] < 33 || str[i] > 126 {
+			return "", nil, fmt.Errorf("invalid bech32 character: %q", str[i])
+		}
+	}
+	if strings.ToLower(str) != str && strings.ToUpper(str) != str {
+		return "", nil, fmt.Errorf("mixed case bech32 string")
+	}
+	str = strings.ToLower(str)
+
+	pos := strings.LastIndexByte(str, '1')
+	if pos < 1 || pos+7 > len(str) {
+		return "", nil, fmt.Errorf("invalid bech32 separator position")
+	}
+
+	hrp := str[:pos]
+	values := make([]byte, 0, len(str)-pos-1)
+	for i := pos + 1; i < len(str); i++ {
+		v := strings.IndexByte(charset, str[i])
+		if v < 0 {
+			return "", nil, fmt.Errorf("invalid bech32 character: %q", str[i])
+		}
+		values = append(values, byte(v))
+	}
+
+	if polymod(append(hrpExpand(hrp), values...)) != 1 {
+		return "", nil, fmt.Errorf("invalid bech32 checksum")
+	}
+	return hrp, values[:len(values)-6], nil
+}
@@ -0,0 +1,141 @@
+package bech32
+
+import (
+	"strings"
+	"testing"
+)
+
+// BIP-0173 test vectors
+func TestDecodeBIP173(t *testing.T) {
+	valid := []string{
+		"A12UEL5L",
+		"a12uel5l",
+		"an83characterlonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1tt5tgs",
+		"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw",
+		"11qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqc8247j",
+		"split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w",
+		"?1ezyfcl",
+	}
+	for _, str := range valid {
+		hrp, _, err := decode(str, MaxLength)
+		if err != nil {
+			t.Errorf("decode(%q): %v", str, err)
+			continue
+		}
+		if want := strings.ToLower(str[:strings.LastIndexByte(str, '1')]); hrp != want {
+			t.Errorf("decode(%q): got hrp %q, want %q", str, hrp, want)
+		}
+	}
+
+	invalid := []struct {
+		str    string
+		reason string
+	}{
+		{"\x201nwldj5", "hrp character out of range"},
+		{"\x7f1axkwrx", "hrp character out of range"},
+		{"\x801eym55h", "hrp character out of range"},
+		{"an84characterslonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1569pvx", "overall max length exceeded"},
+		{"pzry9x0s0muk", "no separator character"},
+		{"1pzry9x0s0muk", "empty hrp"},
+		{"x1b4n0q5v", "invalid data character"},
+		{"li1dgmt3", "too short checksum"},
+		{"de1lg7wt\xff", "invalid character in checksum"},
+		{"A1G7SGD8", "checksum calculated with uppercase form of hrp"},
+		{"10a06t8", "empty hrp"},
+		{"1qzzfhee", "empty hrp"},
+		{"A12UeL5L", "mixed case"},
+	}
+	for _, tt := range invalid {
+		if _, _, err := decode(tt.str, MaxLength); err == nil {
+			t.Errorf("decode(%q): expected error, %s", tt.str, tt.reason)
+		}
+	}
+}
+
+func TestDecodeLimit(t *testing.T) {
+	// valid checksum, 91 chars
+	long := "an84characterslonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1569pvx"
+	if _, _, err := decode(long, 0); err != nil {
+		t.Fatalf("decode no limit: %v", err)
+	}
+	_, _, err := decode(long, MaxLength)
+	if err == nil || !strings.Contains(err.Error(), "too long") {
+		t.Errorf("decode limit: got %v, want too long error", err)
+	}
+}
+
+// Keys and signature from jcli (jorcli testdata), they exceed the BIP-0173 length limit.
+const (
+	jcliSecretKey  = "ed25519e_sk1wzuwptdq7y7eqszadtj48p4a9z7ayxdc5zx76x4gxmhuezmhp4ra5s2e03g4wjydwujwq0acmp9rw6jrhr6p2x9prnpc0dnfkthxtps9029w4"
+	jcliPublicKey  = "ed25519_pk10p43s2c5g3hhdklz9k6awwy5nvv7cnkwv6szgaxvac4ju0jm2a0qyf6j8v"
+	jcliSignature  = "ed25519_sig1spmuf9mahqvgkv3a9prf0phrf3zk666pcngk6q0ywu9xfar7sunr6qmladu8rntmdguraz3pnfj0knr7c5k9jjul4zt893ll8qsexrq96nffp"
+	jcliAddressAcc = "ta1s4uxkxptz3zx7akmugkmt4ecjjd3nmzween2qfr5enhzkt37tdt4ulu8sap"
+)
+
+func TestJcliRoundTrip(t *testing.T) {
+	tests := []struct {
+		str  string
+		hrp  string
+		size int
+	}{
+		{jcliSecretKey, "ed25519e_sk", 64},
+		{jcliPublicKey, "ed25519_pk", 32},
+		{jcliSignature, "ed25519_sig", 64},
+		{jcliAddressAcc, "ta", 33},
+	}
+	for _, tt := range tests {
+		hrp, data, err := Decode(tt.str)
+		if err != nil {
+			t.Errorf("Decode(%q): %v", tt.str, err)
+			continue
+		}
+		if hrp != tt.hrp || len(data) != tt.size {
+			t.Errorf("Decode(%q): got %s with %d bytes, want %s with %d bytes", tt.str, hrp, len(data), tt.hrp, tt.size)
+		}
+
+		str, err := Encode(hrp, data)
+		if err != nil {
+			t.Errorf("Encode(%q): %v", tt.hrp, err)
+			continue
+		}
+		if str != tt.str {
+			t.Errorf("Encode(%q): got %q, want %q", tt.hrp, str, tt.str)
+		}
+
+		if len(tt.str) > MaxLength {
+			if _, _, err = DecodeLimit(tt.str, MaxLength); err == nil {
+				t.Errorf("DecodeLimit(%q): expected too long error", tt.str)
+			}
+		}
+	}
+
+	// the account address data is the kind (account, testing) followed by the public key
+	_, pk, _ := Decode(jcliPublicKey)
+	_, acc, _ := Decode(jcliAddressAcc)
+	if acc[0] != 0x85 || string(acc[1:]) != string(pk) {
+		t.Errorf("account address: got kind %#x and key %x, want %#x and %x", acc[0], acc[1:], 0x85, pk)
+	}
+}
+
+func TestConvertBits(t *testing.T) {
+	data := []byte{0x00, 0xff, 0x10, 0x7f, 0x80}
+	values, err := convertBits(data, 8, 5, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	back, err := convertBits(values, 5, 8, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(back) != string(data) {
+		t.Errorf("convertBits: got %x, want %x", back, data)
+	}
+
+	if _, err = convertBits([]byte{32}, 5, 8, false); err == nil {
+		t.Error("convertBits: expected invalid data value error")
+	}
+	// 2 values, 10 bits, non zero padding
+	if _, err = convertBits([]byte{0, 1}, 5, 8, false); err == nil {
+		t.Error("convertBits: expected invalid padding error")
+	}
+}
@@ -108,3 +108,27 @@ func (b *Funds) First() *loader.FundData {
 func (b *Funds) Total() int {
 	return len(*b.List)
 }
+
+// InitializeDump loads the proposals from the vit station csv dump (sql_proposals.csv).
+func (b *Proposals) InitializeDump(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	b.List, err = loader.LoadDumpData(file)
+	return err
+}
+
+// InitializeDump loads the funds from the vit station csv dump (sql_funds.csv).
+func (b *Funds) InitializeDump(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	b.List, err = loader.LoadDumpFundData(file)
+	return err
+}
@@ -23,6 +23,15 @@ func FatalOn(err error, str ...string) {
 	}
 }
 
+// ErrorOn wraps err with the str context, using the same format of FatalOn.
+// Returns nil if err is nil.
+func ErrorOn(err error, str ...string) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%s -> %w", str, err)
+}
+
 // B2S converts []byte to string with all leading
 // and trailing white space removed, as defined by Unicode.
 func B2S(b []byte) string {
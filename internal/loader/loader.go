@@ -127,3 +127,59 @@ func LoadFundData(r io.Reader) (*[]*FundData, error) {
 	err := gocsv.Unmarshal(r, &funds)
 	return &funds, err
 }
+
+// dumpProposal is a proposal dumped for the vit station (sql_proposals.csv),
+// where funds are already in lovelace and the impact score already scaled.
+// The dump columns are declared before ProposalData since gocsv matches the first field with the column name.
+type dumpProposal struct {
+	Funds       uint64 `csv:"proposal_funds"`
+	ImpactScore int    `csv:"proposal_impact_score"`
+	ProposalData
+}
+
+// LoadDumpData loads the proposals dumped for the vit station (sql_proposals.csv).
+func LoadDumpData(r io.Reader) (*[]*ProposalData, error) {
+	dump := make([]*dumpProposal, 0)
+	err := gocsv.Unmarshal(r, &dump)
+	if err != nil {
+		return nil, err
+	}
+
+	proposals := make([]*ProposalData, 0, len(dump))
+	for _, v := range dump {
+		v.ProposalData.Funds = Lovelace(v.Funds)
+		v.ProposalData.ImpactScore = Score(v.ImpactScore)
+		proposals = append(proposals, &v.ProposalData)
+	}
+	return &proposals, nil
+}
+
+// dumpFund is a fund dumped for the vit station (sql_funds.csv),
+// where the voting power threshold is already in lovelace.
+type dumpFund struct {
+	VotingPowerThreshold uint64 `csv:"voting_power_threshold"`
+	FundData
+}
+
+// LoadDumpFundData loads the funds dumped for the vit station (sql_funds.csv).
+func LoadDumpFundData(r io.Reader) (*[]*FundData, error) {
+	dump := make([]*dumpFund, 0)
+	err := gocsv.Unmarshal(r, &dump)
+	if err != nil {
+		return nil, err
+	}
+
+	funds := make([]*FundData, 0, len(dump))
+	for _, v := range dump {
+		v.FundData.VotingPowerThreshold = Lovelace(v.VotingPowerThreshold)
+		funds = append(funds, &v.FundData)
+	}
+	return &funds, nil
+}
+
+// LoadVotePlanData loads the voteplans dumped for the vit station (sql_voteplans.csv).
+func LoadVotePlanData(r io.Reader) ([]ChainVotePlan, error) {
+	votePlans := make([]ChainVotePlan, 0)
+	err := gocsv.Unmarshal(r, &votePlans)
+	return votePlans, err
+}
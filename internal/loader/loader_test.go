@@ -0,0 +1,124 @@
+package loader
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gocarina/gocsv"
+)
+
+const testProposalsCSV = `internal_id,category_name,proposal_id,proposal_title,proposal_funds,proposal_impact_score,chain_vote_options,chain_vote_type,challenge_id
+1,Fund3 challenge,1001,proposal 1,10000,4.25,"blank,yes,no",public,2
+2,Fund3 challenge,1002,proposal 2,25000,1,"blank,yes,no",private,3
+`
+
+const testFundCSV = `id,fund_name,fund_goal,voting_power_threshold
+3,Fund3,test goal,450
+`
+
+// testDumpProposalsCSV is a sql_proposals.csv sample, funds in lovelace and score already scaled.
+const testDumpProposalsCSV = `internal_id,category_name,proposal_id,proposal_title,proposal_funds,proposal_impact_score,chain_proposal_id,chain_proposal_index,chain_vote_options,chain_vote_type,chain_vote_action,challenge_id,id,chain_voteplan_id,chain_vote_start_time,chain_vote_end_time,chain_committee_end_time,chain_voteplan_payload,chain_vote_encryption_key,fund_id
+1,Fund3 challenge,1001,proposal 1,10000000000,425,aa01,0,"blank,yes,no",public,off_chain,2,1,vp01,1609459200,1609632000,1609718400,public,,3
+2,Fund3 challenge,1002,proposal 2,25000000000,100,bb02,0,"blank,yes,no",private,off_chain,3,2,vp02,1609459200,1609632000,1609718400,private,key,3
+`
+
+// testDumpFundCSV is a sql_funds.csv sample, threshold in lovelace.
+const testDumpFundCSV = `id,fund_name,voting_power_threshold,fund_goal,voting_power_info,rewards_info,fund_start_time,fund_end_time,next_fund_start_time
+3,Fund3,450000000,test goal,2021-01-01T00:00:00Z,2021-02-01T00:00:00Z,2021-01-01T00:00:00Z,2021-01-03T00:00:00Z,2021-03-01T00:00:00Z
+`
+
+func TestLoadDumpData(t *testing.T) {
+	proposals, err := LoadDumpData(strings.NewReader(testDumpProposalsCSV))
+	if err != nil {
+		t.Fatalf("LoadDumpData: %v", err)
+	}
+	if len(*proposals) != 2 {
+		t.Fatalf("LoadDumpData: got %d proposals, want 2", len(*proposals))
+	}
+
+	want := []struct {
+		internalID  uint64
+		funds       Lovelace
+		score       Score
+		externalID  string
+		challengeID uint32
+		votePlanID  string
+	}{
+		{1, 10_000_000_000, 425, "aa01", 2, "vp01"},
+		{2, 25_000_000_000, 100, "bb02", 3, "vp02"},
+	}
+	for i, w := range want {
+		p := (*proposals)[i]
+		if p.InternalID != w.internalID || p.Funds != w.funds || p.ImpactScore != w.score || p.ExternalID != w.externalID || p.ChallengeID != w.challengeID {
+			t.Errorf("proposal %d: got (%d, %d, %d, %s, %d)", i, p.InternalID, p.Funds, p.ImpactScore, p.ExternalID, p.ChallengeID)
+		}
+		if p.ChainVotePlan == nil || p.VotePlanID != w.votePlanID || p.FundID != 3 {
+			t.Errorf("proposal %d: got voteplan %+v", i, p.ChainVotePlan)
+		}
+		if !reflect.DeepEqual(p.VoteOptions, ChainVoteOptions{"blank": 0, "yes": 1, "no": 2}) {
+			t.Errorf("proposal %d: got options %v", i, p.VoteOptions)
+		}
+	}
+
+	if _, err = LoadDumpData(strings.NewReader("internal_id,proposal_funds\n1,ten\n")); err == nil {
+		t.Error("LoadDumpData: expected error on wrong funds")
+	}
+}
+
+func TestLoadDumpFundData(t *testing.T) {
+	funds, err := LoadDumpFundData(strings.NewReader(testDumpFundCSV))
+	if err != nil {
+		t.Fatalf("LoadDumpFundData: %v", err)
+	}
+	if len(*funds) != 1 {
+		t.Fatalf("LoadDumpFundData: got %d funds, want 1", len(*funds))
+	}
+	f := (*funds)[0]
+	if f.FundID != 3 || f.Name != "Fund3" || f.VotingPowerThreshold != 450_000_000 || f.NextStartTime != "2021-03-01T00:00:00Z" {
+		t.Errorf("fund: got %+v", f)
+	}
+}
+
+// the dump of the loaded data loads back the same
+func TestLoadDumpRoundTrip(t *testing.T) {
+	proposals, err := LoadData(strings.NewReader(testProposalsCSV))
+	if err != nil {
+		t.Fatalf("LoadData: %v", err)
+	}
+	for _, p := range *proposals {
+		p.ChainVotePlan = &ChainVotePlan{VotePlanID: "vp", FundID: 3}
+	}
+	funds, err := LoadFundData(strings.NewReader(testFundCSV))
+	if err != nil {
+		t.Fatalf("LoadFundData: %v", err)
+	}
+	if (*proposals)[0].Funds != 10_000_000_000 || (*proposals)[0].ImpactScore != 425 || (*funds)[0].VotingPowerThreshold != 450_000_000 {
+		t.Fatalf("loaded: got (%d, %d, %d)", (*proposals)[0].Funds, (*proposals)[0].ImpactScore, (*funds)[0].VotingPowerThreshold)
+	}
+
+	var buf bytes.Buffer
+	if err = gocsv.Marshal(proposals, &buf); err != nil {
+		t.Fatal(err)
+	}
+	dumped, err := LoadDumpData(&buf)
+	if err != nil {
+		t.Fatalf("LoadDumpData: %v", err)
+	}
+	if !reflect.DeepEqual(dumped, proposals) {
+		t.Errorf("proposals: got %+v, want %+v", *dumped, *proposals)
+	}
+
+	buf.Reset()
+	if err = gocsv.Marshal(funds, &buf); err != nil {
+		t.Fatal(err)
+	}
+	dumpedFunds, err := LoadDumpFundData(&buf)
+	if err != nil {
+		t.Fatalf("LoadDumpFundData: %v", err)
+	}
+	if !reflect.DeepEqual(dumpedFunds, funds) {
+		t.Errorf("funds: got %+v, want %+v", *dumpedFunds, *funds)
+	}
+}
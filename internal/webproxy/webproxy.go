@@ -12,13 +12,6 @@ import (
 	"github.com/input-output-hk/jorvit/internal/datastore"
 )
 
-var (
-	reverseProxyAddress = "http://127.0.0.1:8001"
-	proposals           datastore.ProposalsStore
-	funds               datastore.FundsStore
-	block0Bin           *[]byte
-)
-
 // ShiftPath splits off the first component of p, which will be cleaned of
 // relative components before processing. head will never contain a slash and
 // tail will always be a rooted path without trailing slash.
@@ -34,6 +27,8 @@ func ShiftPath(p string) (head, tail string) {
 type App struct {
 	// Not using http.Handler for decoupling
 	ApiHandler *ApiHandler
+	// Node rest address (ex: "http://127.0.0.1:8001") the explorer requests are proxied to
+	ReverseProxyAddress string
 }
 
 func (h *App) ServeHTTP(res http.ResponseWriter, req *http.Request) {
@@ -51,7 +46,7 @@ func (h *App) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 		h.ApiHandler.ServeHTTP(res, req)
 		return
 	case "explorer":
-		serveReverseProxy("/explorer", res, req)
+		serveReverseProxy(h.ReverseProxyAddress, "/explorer", res, req)
 	default:
 		http.Error(res, "Not Found", http.StatusNotFound)
 		return
@@ -80,6 +75,8 @@ type V0Handler struct {
 	ProposalHandler *ProposalHandler
 	Block0Handler   *Block0Handler
 	FundInfoHandler *FundInfoHandler
+	// Node rest address (ex: "http://127.0.0.1:8001") the node api requests are proxied to
+	ReverseProxyAddress string
 }
 
 func (h *V0Handler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
@@ -96,24 +93,24 @@ func (h *V0Handler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 	case "fund":
 		h.FundInfoHandler.ServeHTTP(res, req)
 	case "account":
-		serveReverseProxy("/api/v0/account", res, req)
+		serveReverseProxy(h.ReverseProxyAddress, "/api/v0/account", res, req)
 		return
 	case "block":
-		serveReverseProxy("/api/v0/block", res, req)
+		serveReverseProxy(h.ReverseProxyAddress, "/api/v0/block", res, req)
 		return
 	case "fragment":
-		serveReverseProxy("/api/v0/fragment", res, req)
+		serveReverseProxy(h.ReverseProxyAddress, "/api/v0/fragment", res, req)
 		return
 	case "message":
-		serveReverseProxy("/api/v0/message", res, req)
+		serveReverseProxy(h.ReverseProxyAddress, "/api/v0/message", res, req)
 		return
 	case "settings":
-		serveReverseProxy("/api/v0/settings", res, req)
+		serveReverseProxy(h.ReverseProxyAddress, "/api/v0/settings", res, req)
 	case "vote":
-		serveReverseProxy("/api/v0/vote", res, req)
+		serveReverseProxy(h.ReverseProxyAddress, "/api/v0/vote", res, req)
 		return
 	case "fragments":
-		serveReverseProxy("/api/v1/fragments", res, req)
+		serveReverseProxy(h.ReverseProxyAddress, "/api/v1/fragments", res, req)
 		return
 	default:
 		http.Error(res, "Not Found", http.StatusNotFound)
@@ -151,7 +148,9 @@ func (h *ProposalHandler) ServeHTTP(res http.ResponseWriter, req *http.Request)
 	}
 }
 
-type ProposalListAll struct{}
+type ProposalListAll struct {
+	Proposals datastore.ProposalsStore
+}
 
 func (h *ProposalListAll) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 	res.Header().Set("Content-Type", "application/json")
@@ -159,12 +158,12 @@ func (h *ProposalListAll) ServeHTTP(res http.ResponseWriter, req *http.Request)
 
 	switch req.Method {
 	case "GET":
-		if proposals.Total() == 0 {
+		if h.Proposals.Total() == 0 {
 			res.WriteHeader(http.StatusNotFound)
 			res.Write([]byte(`{"error": "empty data"}`))
 			return
 		}
-		resData, err := json.MarshalIndent(proposals.All(), "", "  ")
+		resData, err := json.MarshalIndent(h.Proposals.All(), "", "  ")
 		if err != nil {
 			res.WriteHeader(http.StatusInternalServerError)
 			res.Write([]byte(`{"error": "error marshalling data"}`))
@@ -179,7 +178,9 @@ func (h *ProposalListAll) ServeHTTP(res http.ResponseWriter, req *http.Request)
 	}
 }
 
-type ProposalListSingle struct{}
+type ProposalListSingle struct {
+	Proposals datastore.ProposalsStore
+}
 
 func (h *ProposalListSingle) Handler(internalID string, res http.ResponseWriter, req *http.Request) http.Handler {
 	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
@@ -188,7 +189,7 @@ func (h *ProposalListSingle) Handler(internalID string, res http.ResponseWriter,
 
 		switch req.Method {
 		case "GET":
-			proposal := proposals.SearchID(internalID)
+			proposal := h.Proposals.SearchID(internalID)
 			if proposal == nil {
 				res.WriteHeader(http.StatusNotFound)
 				res.Write([]byte(`{"error": not found"}`))
@@ -210,34 +211,38 @@ func (h *ProposalListSingle) Handler(internalID string, res http.ResponseWriter,
 	})
 }
 
-type Block0Handler struct{}
+type Block0Handler struct {
+	Block0 *[]byte
+}
 
 func (h *Block0Handler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 	res.Header().Set("Content-Type", "application/octet-stream")
-	res.Header().Set("Content-Length", strconv.Itoa(len(*block0Bin)))
+	res.Header().Set("Content-Length", strconv.Itoa(len(*h.Block0)))
 	switch req.Method {
 	case "GET":
 		corsHeaders(res, req)
 		res.WriteHeader(http.StatusOK)
-		res.Write(*block0Bin)
+		res.Write(*h.Block0)
 		return
 	default:
 		http.Error(res, "Only GET is allowed", http.StatusMethodNotAllowed)
 	}
 }
 
-type FundInfoHandler struct{}
+type FundInfoHandler struct {
+	Funds datastore.FundsStore
+}
 
 func (h *FundInfoHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 	res.Header().Set("Content-Type", "application/json")
 	switch req.Method {
 	case "GET":
-		if funds.Total() == 0 {
+		if h.Funds.Total() == 0 {
 			res.WriteHeader(http.StatusNotFound)
 			res.Write([]byte(`{"error": "empty data"}`))
 			return
 		}
-		resData, err := json.MarshalIndent(funds.First(), "", "  ")
+		resData, err := json.MarshalIndent(h.Funds.First(), "", "  ")
 		if err != nil {
 			res.WriteHeader(http.StatusInternalServerError)
 			res.Write([]byte(`{"error": "error marshalling data"}`))
@@ -253,34 +258,35 @@ func (h *FundInfoHandler) ServeHTTP(res http.ResponseWriter, req *http.Request)
 }
 
 func Run(p datastore.ProposalsStore, f datastore.FundsStore, block0 *[]byte, address string, revProxyAddr string) error {
-	proposals = p
-	funds = f
-	reverseProxyAddress = revProxyAddr
-	block0Bin = block0
+	return NewServer(p, f, block0, address, revProxyAddr).ListenAndServe()
+}
 
+// NewServer returns the proxy http server, not yet listening.
+// All the state is kept by the server handlers, so more servers can run in the same process.
+func NewServer(p datastore.ProposalsStore, f datastore.FundsStore, block0 *[]byte, address string, revProxyAddr string) *http.Server {
 	app := &App{
 		ApiHandler: &ApiHandler{
 			V0Handler: &V0Handler{
 				ProposalHandler: &ProposalHandler{
-					ProposalListAll:    new(ProposalListAll),
-					ProposalListSingle: new(ProposalListSingle),
+					ProposalListAll:    &ProposalListAll{Proposals: p},
+					ProposalListSingle: &ProposalListSingle{Proposals: p},
 				},
-				Block0Handler:   new(Block0Handler),
-				FundInfoHandler: new(FundInfoHandler),
+				Block0Handler:       &Block0Handler{Block0: block0},
+				FundInfoHandler:     &FundInfoHandler{Funds: f},
+				ReverseProxyAddress: revProxyAddr,
 			},
 		},
+		ReverseProxyAddress: revProxyAddr,
 	}
 
-	srv := &http.Server{
+	return &http.Server{
 		Addr:    address,
 		Handler: app,
 	}
-
-	return srv.ListenAndServe()
 }
 
 // serveReverseProxy - Serve a reverse proxy for a given url
-func serveReverseProxy(target string, res http.ResponseWriter, req *http.Request) {
+func serveReverseProxy(reverseProxyAddress string, target string, res http.ResponseWriter, req *http.Request) {
 	url, _ := url.Parse(reverseProxyAddress + target)
 
 	proxy := httputil.NewSingleHostReverseProxy(url)
@@ -0,0 +1,147 @@
+package webproxy
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/input-output-hk/jorvit/internal/datastore"
+	"github.com/input-output-hk/jorvit/internal/loader"
+)
+
+// testEnv is a proxy with its own data and node rest backend.
+type testEnv struct {
+	proxy *httptest.Server
+	node  *httptest.Server
+}
+
+func newTestEnv(t *testing.T, name string, fundID uint64, internalID uint64, block0 []byte) *testEnv {
+	t.Helper()
+
+	proposal := &loader.ProposalData{InternalID: internalID}
+	proposal.Title = name
+	proposals := &datastore.Proposals{List: &[]*loader.ProposalData{proposal}}
+	funds := &datastore.Funds{List: &[]*loader.FundData{{FundID: fundID, Name: name}}}
+
+	node := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		_, _ = res.Write([]byte(name + " " + req.URL.Path))
+	}))
+	t.Cleanup(node.Close)
+
+	srv := NewServer(proposals, funds, &block0, "", node.URL)
+	proxy := httptest.NewServer(srv.Handler)
+	t.Cleanup(proxy.Close)
+
+	return &testEnv{proxy: proxy, node: node}
+}
+
+func testGet(t *testing.T, url string) (int, []byte) {
+	t.Helper()
+	res, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res.StatusCode, body
+}
+
+func TestServersState(t *testing.T) {
+	envs := []struct {
+		env        *testEnv
+		name       string
+		fundID     uint64
+		internalID string
+		block0     string
+	}{
+		{name: "env1", fundID: 1, internalID: "10", block0: "block0-1"},
+		{name: "env2", fundID: 2, internalID: "20", block0: "block0-2"},
+	}
+	for i := range envs {
+		id := uint64(10 * (i + 1))
+		envs[i].env = newTestEnv(t, envs[i].name, envs[i].fundID, id, []byte(envs[i].block0))
+	}
+
+	// each server keeps serving its own data
+	for _, e := range envs {
+		status, body := testGet(t, e.env.proxy.URL+"/api/v0/fund")
+		var fund loader.FundData
+		if status != http.StatusOK || json.Unmarshal(body, &fund) != nil || fund.FundID != e.fundID {
+			t.Errorf("%s fund: got %d %s", e.name, status, body)
+		}
+
+		status, body = testGet(t, e.env.proxy.URL+"/api/v0/proposals")
+		var proposals []loader.ProposalData
+		if status != http.StatusOK || json.Unmarshal(body, &proposals) != nil || len(proposals) != 1 || proposals[0].Title != e.name {
+			t.Errorf("%s proposals: got %d %s", e.name, status, body)
+		}
+
+		status, _ = testGet(t, e.env.proxy.URL+"/api/v0/proposals/"+e.internalID)
+		if status != http.StatusOK {
+			t.Errorf("%s proposal %s: got %d", e.name, e.internalID, status)
+		}
+		for _, other := range envs {
+			if other.internalID == e.internalID {
+				continue
+			}
+			if status, _ = testGet(t, e.env.proxy.URL+"/api/v0/proposals/"+other.internalID); status != http.StatusNotFound {
+				t.Errorf("%s proposal %s: got %d, want %d", e.name, other.internalID, status, http.StatusNotFound)
+			}
+		}
+
+		status, body = testGet(t, e.env.proxy.URL+"/api/v0/block0")
+		if status != http.StatusOK || string(body) != e.block0 {
+			t.Errorf("%s block0: got %d %s", e.name, status, body)
+		}
+
+		// node api and explorer go to the server own node
+		status, body = testGet(t, e.env.proxy.URL+"/api/v0/settings")
+		if want := e.name + " /api/v0/settings"; status != http.StatusOK || !strings.HasPrefix(string(body), want) {
+			t.Errorf("%s settings: got %d %q, want %q", e.name, status, body, want)
+		}
+		status, body = testGet(t, e.env.proxy.URL+"/explorer/graphql")
+		if want := e.name + " /explorer/graphql"; status != http.StatusOK || !strings.HasPrefix(string(body), want) {
+			t.Errorf("%s explorer: got %d %q, want %q", e.name, status, body, want)
+		}
+	}
+}
+
+func TestEmptyData(t *testing.T) {
+	block0 := []byte{}
+	srv := NewServer(
+		&datastore.Proposals{List: &[]*loader.ProposalData{}},
+		&datastore.Funds{List: &[]*loader.FundData{}},
+		&block0, "", "http://127.0.0.1:1",
+	)
+	proxy := httptest.NewServer(srv.Handler)
+	defer proxy.Close()
+
+	for _, path := range []string{"/api/v0/fund", "/api/v0/proposals", "/api/v0/proposals/1", "/api/v1/other", "/other"} {
+		if status, _ := testGet(t, proxy.URL+path); status != http.StatusNotFound {
+			t.Errorf("%s: got %d, want %d", path, status, http.StatusNotFound)
+		}
+	}
+}
+
+func TestShiftPath(t *testing.T) {
+	tests := []struct {
+		path, head, tail string
+	}{
+		{"/", "", "/"},
+		{"/api", "api", "/"},
+		{"/api/v0/fund/", "api", "/v0/fund"},
+		{"api/../v0//fund", "v0", "/fund"},
+	}
+	for _, tt := range tests {
+		head, tail := ShiftPath(tt.path)
+		if head != tt.head || tail != tt.tail {
+			t.Errorf("ShiftPath(%q): got (%q, %q), want (%q, %q)", tt.path, head, tail, tt.head, tt.tail)
+		}
+	}
+}
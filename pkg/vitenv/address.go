@@ -0,0 +1,177 @@
+package vitenv
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"hash/crc32"
+	"math/big"
+	"strings"
+
+	"github.com/input-output-hk/jorvit/internal/bech32"
+)
+
+// Account address kind, as defined by jörmungandr (the high bit is the test discrimination)
+const (
+	addressKindAccount = 0x05
+	addressTesting     = 0x80
+)
+
+// Addresses discrimination and the bech32 prefix used for each one
+const (
+	DiscriminationProduction = "production"
+	DiscriminationTesting    = "testing"
+
+	prefixProduction = "ca"
+	prefixTesting    = "ta"
+)
+
+// addressPrefix of the discrimination.
+func addressPrefix(discrimination string) string {
+	if discrimination == DiscriminationTesting {
+		return prefixTesting
+	}
+	return prefixProduction
+}
+
+// jcliDiscrimination is the jcli address discrimination flag ("" defaults to production).
+func jcliDiscrimination(discrimination string) string {
+	if discrimination == DiscriminationTesting {
+		return DiscriminationTesting
+	}
+	return ""
+}
+
+// block0Discrimination is the block0 config discrimination value.
+func block0Discrimination(discrimination string) string {
+	if discrimination == DiscriminationTesting {
+		return "test"
+	}
+	return "production"
+}
+
+// accountAddress builds the account address of a bech32 ed25519 public key, same as "jcli address account".
+func accountAddress(publicKey string, prefix string, discrimination string) (string, error) {
+	hrp, pk, err := bech32.Decode(publicKey)
+	if err != nil {
+		return "", err
+	}
+	if hrp != "ed25519_pk" || len(pk) != ed25519.PublicKeySize {
+		return "", fmt.Errorf("%s - not an ed25519 public key", publicKey)
+	}
+
+	kind := byte(addressKindAccount)
+	if discrimination == DiscriminationTesting {
+		kind |= addressTesting
+	}
+	if prefix == "" {
+		prefix = prefixProduction
+	}
+	return bech32.Encode(prefix, append([]byte{kind}, pk...))
+}
+
+// checkAddress verifies that the bech32 address prefix and discrimination match the expected discrimination.
+func checkAddress(address string, discrimination string) error {
+	hrp, data, err := bech32.Decode(address)
+	if err != nil {
+		return fmt.Errorf("[%s] - %w", address, err)
+	}
+	if len(data) == 0 {
+		return fmt.Errorf("[%s] - empty address", address)
+	}
+
+	testing := data[0]&addressTesting != 0
+	if hrp != addressPrefix(discrimination) || testing != (discrimination == DiscriminationTesting) {
+		return fmt.Errorf("[%s] - expected %s discrimination address (%s1...)", address, discrimination, addressPrefix(discrimination))
+	}
+	return nil
+}
+
+// base58Alphabet used by the legacy (byron) addresses.
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// base58Decode decodes a base58 string, each leading '1' is a zero byte.
+func base58Decode(str string) ([]byte, error) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for i := 0; i < len(str); i++ {
+		v := strings.IndexByte(base58Alphabet, str[i])
+		if v < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q at %d", str[i], i)
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(v)))
+	}
+	zeros := len(str) - len(strings.TrimLeft(str, "1"))
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
+
+// cborHead decodes the cbor head of major type at the start of data, returning its value and size.
+func cborHead(data []byte, major byte) (uint64, int, error) {
+	if len(data) == 0 || data[0]>>5 != major {
+		return 0, 0, fmt.Errorf("expected cbor major type %d", major)
+	}
+	info := data[0] & 0x1f
+	size := 1
+	switch info {
+	case 24:
+		size = 2
+	case 25:
+		size = 3
+	case 26:
+		size = 5
+	case 27:
+		size = 9
+	default:
+		if info > 23 {
+			return 0, 0, fmt.Errorf("unexpected cbor additional info %d", info)
+		}
+		return uint64(info), 1, nil
+	}
+	if len(data) < size {
+		return 0, 0, fmt.Errorf("truncated cbor head")
+	}
+	var v uint64
+	for _, b := range data[1:size] {
+		v = v<<8 | uint64(b)
+	}
+	return v, size, nil
+}
+
+// checkLegacyAddress verifies that address is a legacy (byron) base58 address:
+// a cbor array of the tagged (24) address bytes and their crc32.
+func checkLegacyAddress(address string) error {
+	wrong := func(reason string) error {
+		return fmt.Errorf("[%s] - expected a legacy (base58) address, %s", address, reason)
+	}
+
+	data, err := base58Decode(address)
+	if err != nil {
+		return wrong(err.Error())
+	}
+	if len(data) < 3 || data[0] != 0x82 || data[1] != 0xd8 || data[2] != 24 {
+		return wrong("not a tagged cbor address")
+	}
+	data = data[3:]
+
+	length, n, err := cborHead(data, 2)
+	if err != nil {
+		return wrong(err.Error())
+	}
+	if length == 0 || uint64(len(data)-n) < length {
+		return wrong("wrong address length")
+	}
+	payload := data[n : n+int(length)]
+	data = data[n+int(length):]
+
+	crc, n, err := cborHead(data, 0)
+	if err != nil {
+		return wrong(err.Error())
+	}
+	if n != len(data) {
+		return wrong("unexpected trailing data")
+	}
+	if uint32(crc) != crc32.ChecksumIEEE(payload) || crc > 0xffffffff {
+		return wrong("checksum mismatch")
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package vitenv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/input-output-hk/jorvit/internal/bech32"
+)
+
+// jcli vectors (jorcli address tests):
+//
+//	jcli address account --prefix ta --testing ed25519_pk10p43s2c5g3hhdklz9k6awwy5nvv7cnkwv6szgaxvac4ju0jm2a0qyf6j8v
+//	jcli address single --prefix ta --testing ed25519_pk10p43s2c5g3hhdklz9k6awwy5nvv7cnkwv6szgaxvac4ju0jm2a0qyf6j8v ed25519_pk10p43s2c5g3hhdklz9k6awwy5nvv7cnkwv6szgaxvac4ju0jm2a0qyf6j8v
+const (
+	testPublicKey      = "ed25519_pk10p43s2c5g3hhdklz9k6awwy5nvv7cnkwv6szgaxvac4ju0jm2a0qyf6j8v"
+	testAccountTesting = "ta1s4uxkxptz3zx7akmugkmt4ecjjd3nmzween2qfr5enhzkt37tdt4ulu8sap"
+	testSingleTesting  = "ta1s3uxkxptz3zx7akmugkmt4ecjjd3nmzween2qfr5enhzkt37tdt4u7rtrq43g3r0wmd7ytd46uuffxcea38vue4qy36vem3t9cl9k467x80kcm"
+)
+
+// testAccountProduction is the testAccountTesting account with the production prefix and discrimination.
+func testAccountProduction(t *testing.T) string {
+	t.Helper()
+	_, data, err := bech32.Decode(testAccountTesting)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = append([]byte{data[0] &^ addressTesting}, data[1:]...)
+	address, err := bech32.Encode(prefixProduction, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return address
+}
+
+func TestAccountAddress(t *testing.T) {
+	got, err := accountAddress(testPublicKey, addressPrefix(DiscriminationTesting), DiscriminationTesting)
+	if err != nil || got != testAccountTesting {
+		t.Errorf("accountAddress testing: got %s (%v), want %s", got, err, testAccountTesting)
+	}
+
+	production := testAccountProduction(t)
+	got, err = accountAddress(testPublicKey, addressPrefix(DiscriminationProduction), DiscriminationProduction)
+	if err != nil || got != production {
+		t.Errorf("accountAddress production: got %s (%v), want %s", got, err, production)
+	}
+	_, pk, _ := bech32.Decode(testPublicKey)
+	if _, data, _ := bech32.Decode(got); data[0] != addressKindAccount || !bytes.Equal(data[1:], pk) {
+		t.Errorf("accountAddress production: got data %x", data)
+	}
+
+	// empty prefix defaults to production
+	if got, err = accountAddress(testPublicKey, "", DiscriminationProduction); err != nil || got != production {
+		t.Errorf("accountAddress no prefix: got %s (%v), want %s", got, err, production)
+	}
+
+	for _, pk := range []string{
+		"ed25519_sk1wzuwptdq7y7eqszadtj48p4a9z7ayxdc5zx76x4gxmhuezmhp4ra5s2e03g4wjydwujwq0acmp9rw6jrhr6p2x9prnpc0dnfkthxtps9029w4",
+		testAccountTesting,
+		"ed25519_pk1wrong",
+	} {
+		if _, err = accountAddress(pk, prefixTesting, DiscriminationTesting); err == nil {
+			t.Errorf("accountAddress(%s): expected error", pk)
+		}
+	}
+}
+
+func TestCheckAddress(t *testing.T) {
+	production := testAccountProduction(t)
+	wrongPrefix, err := bech32.Encode(prefixProduction, []byte{addressKindAccount | addressTesting, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	empty, err := bech32.Encode(prefixTesting, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		address        string
+		discrimination string
+		errMsg         string
+	}{
+		{"account testing", testAccountTesting, DiscriminationTesting, ""},
+		{"single testing", testSingleTesting, DiscriminationTesting, ""},
+		{"account production", production, DiscriminationProduction, ""},
+		{"testing as production", testAccountTesting, DiscriminationProduction, "expected production discrimination address (ca1...)"},
+		{"production as testing", production, DiscriminationTesting, "expected testing discrimination address (ta1...)"},
+		{"testing bit with production prefix", wrongPrefix, DiscriminationProduction, "expected production discrimination address"},
+		{"checksum", testAccountTesting[:len(testAccountTesting)-1] + "q", DiscriminationTesting, "[" + testAccountTesting[:len(testAccountTesting)-1] + "q] - "},
+		{"not bech32", "Ae2tdPwUPEYwrazXRJVK4NgHSZCjP9kLSMrx2awgYiBH61zT8kz6u33Sije", DiscriminationProduction, "Ae2td"},
+		{"empty data", empty, DiscriminationTesting, "empty address"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkAddress(tt.address, tt.discrimination)
+			switch {
+			case tt.errMsg == "" && err != nil:
+				t.Fatalf("checkAddress: %v", err)
+			case tt.errMsg != "" && (err == nil || !strings.Contains(err.Error(), tt.errMsg)):
+				t.Fatalf("checkAddress: got %v, want error containing %q", err, tt.errMsg)
+			}
+		})
+	}
+}
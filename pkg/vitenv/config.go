@@ -0,0 +1,394 @@
+package vitenv
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/input-output-hk/jorvit/pkg/vresult"
+)
+
+// Config contains all the settings of a VIT environment.
+// The yaml/json keys are the same as the vitconfig flags names.
+type Config struct {
+	// node settings
+	Proxy         string `yaml:"proxy"          json:"proxy"`
+	Rest          string `yaml:"rest"           json:"rest"`
+	Node          string `yaml:"node"           json:"node"`
+	Explorer      bool   `yaml:"explorer"       json:"explorer"`
+	Cors          string `yaml:"cors"           json:"cors"`
+	SkipBootstrap bool   `yaml:"skip-bootstrap" json:"skip-bootstrap"`
+	NodeLogLevel  string `yaml:"node-log-level" json:"node-log-level"`
+	// extra node
+	AllowNodeRestart bool `yaml:"allow-node-restart" json:"allow-node-restart"`
+	ShutdownNode     bool `yaml:"shutdown-node"      json:"shutdown-node"`
+	StartNode        bool `yaml:"start-node"         json:"start-node"`
+	// multi node network
+	MultiNode bool `yaml:"multi-node" json:"multi-node"`
+	ProxyNode uint `yaml:"proxy-node" json:"proxy-node"`
+
+	// vit service station settings
+	VitStation  string `yaml:"vit-station"   json:"vit-station"`
+	VitLogLevel string `yaml:"vit-log-level" json:"vit-log-level"`
+	StartVit    bool   `yaml:"start-vit"     json:"start-vit"`
+
+	// external proposal data
+	Proposals           string `yaml:"proposals"             json:"proposals"`
+	Fund                string `yaml:"fund"                  json:"fund"`
+	Challenges          string `yaml:"challenges"            json:"challenges"`
+	GenesisExtraData    string `yaml:"genesis-extra-data"    json:"genesis-extra-data"`
+	VotingPowerSnapshot string `yaml:"voting-power-snapshot" json:"voting-power-snapshot"`
+
+	// vote and committee related timing
+	VoteStart         string `yaml:"vote-start"         json:"vote-start"`
+	VoteEnd           string `yaml:"vote-end"           json:"vote-end"`
+	CommitteeEnd      string `yaml:"committee-end"      json:"committee-end"`
+	VoteDuration      string `yaml:"vote-duration"      json:"vote-duration"`
+	CommitteeDuration string `yaml:"committee-duration" json:"committee-duration"`
+
+	VotePlanProposalsMax uint `yaml:"voteplan-proposals-max" json:"voteplan-proposals-max"`
+	Block0VotePlan       bool `yaml:"block0-voteplan"        json:"block0-voteplan"`
+	SubmitVotePlans      bool `yaml:"submit-voteplans"       json:"submit-voteplans"`
+	AutoTally            bool `yaml:"auto-tally"             json:"auto-tally"`
+
+	// genesis (block0) settings
+	GenesisTime         string `yaml:"genesis-time"          json:"genesis-time"`
+	SlotDuration        string `yaml:"slot-duration"         json:"slot-duration"`
+	EpochDuration       string `yaml:"epoch-duration"        json:"epoch-duration"`
+	EpochStabilityDepth uint32 `yaml:"epoch-stability-depth" json:"epoch-stability-depth"`
+	Discrimination      string `yaml:"discrimination"        json:"discrimination"`
+
+	// consensus, bft or genesis_praos (with generated stake pools)
+	Consensus            string  `yaml:"consensus"               json:"consensus"`
+	StakePools           uint    `yaml:"stake-pools"             json:"stake-pools"`
+	StakePoolFund        uint64  `yaml:"stake-pool-fund"         json:"stake-pool-fund"`
+	PraosActiveSlotCoeff float64 `yaml:"praos-active-slot-coeff" json:"praos-active-slot-coeff"`
+	KesUpdateSpeed       uint32  `yaml:"kes-update-speed"        json:"kes-update-speed"`
+
+	// generated test voters wallets, funded in block0
+	Voters       uint   `yaml:"voters"         json:"voters"`
+	VoterFund    uint64 `yaml:"voter-fund"     json:"voter-fund"`
+	VoterFundMax uint64 `yaml:"voter-fund-max" json:"voter-fund-max"`
+
+	// BFT Leaders - also promoted to Global Committee members
+	BftLeaderMin        uint     `yaml:"bft-leader-min"        json:"bft-leader-min"`
+	BftLeaderSecretKeys []string `yaml:"bft-leader-secret-key" json:"bft-leader-secret-key"`
+	BftLeaderPublicKeys []string `yaml:"bft-leader-public-key" json:"bft-leader-public-key"`
+
+	// Global Committee auth members and Voteplan Committee privacy members public keys
+	CommitteeAuthPublicKeys    []string `yaml:"committee-auth-public-key"    json:"committee-auth-public-key"`
+	CommitteePrivacyPublicKeys []string `yaml:"committee-privacy-public-key" json:"committee-privacy-public-key"`
+
+	// Voteplan Committee privacy members key ceremony, used when no privacy public keys are provided
+	CommitteePrivacyMembers   uint `yaml:"committee-privacy-members"   json:"committee-privacy-members"`
+	CommitteePrivacyThreshold uint `yaml:"committee-privacy-threshold" json:"committee-privacy-threshold"`
+
+	// fees
+	FeesCertificate                 uint64 `yaml:"fees-certificate"                   json:"fees-certificate"`
+	FeesCoefficient                 uint64 `yaml:"fees-coefficient"                   json:"fees-coefficient"`
+	FeesConstant                    uint64 `yaml:"fees-constant"                      json:"fees-constant"`
+	FeesCertificatePoolRegistration uint64 `yaml:"fees-certificate-pool-registration" json:"fees-certificate-pool-registration"`
+	FeesCertificateStakeDelegation  uint64 `yaml:"fees-certificate-stake-delegation"  json:"fees-certificate-stake-delegation"`
+	FeesCertificateVotePlan         uint64 `yaml:"fees-certificate-vote-plan"         json:"fees-certificate-vote-plan"`
+	FeesCertificateVoteCast         uint64 `yaml:"fees-certificate-vote-cast"         json:"fees-certificate-vote-cast"`
+	FeesGoTo                        string `yaml:"fees-go-to"                         json:"fees-go-to"`
+
+	// in memory service only
+	TimeFormat string `yaml:"time-format" json:"time-format"`
+
+	// Lovelace amount for Bft Leader and Committee Auth members
+	BftLeaderFund     uint64 `yaml:"bft-leader-fund"     json:"bft-leader-fund"`
+	CommitteeAuthFund uint64 `yaml:"committee-auth-fund" json:"committee-auth-fund"`
+
+	// reproducible environment, same inputs produce the same block0 hash, voteplans ids and proposals ExternalID
+	Reproducible bool   `yaml:"reproducible" json:"reproducible"`
+	OutputDir    string `yaml:"output-dir"   json:"output-dir"`
+	KeySeed      string `yaml:"key-seed"     json:"key-seed"`
+	KeyDir       string `yaml:"key-dir"      json:"key-dir"`
+
+	// BaseDir where the "jnode_VIT_xxxxx" working directory is created. If empty the system temp dir is used
+	BaseDir string `yaml:"-" json:"-"`
+}
+
+// DefaultConfig returns a Config with the vitconfig defaults.
+func DefaultConfig() Config {
+	assets := "." + string(os.PathSeparator) + "assets" + string(os.PathSeparator)
+	return Config{
+		Proxy:         "0.0.0.0:8000",
+		Rest:          "0.0.0.0:8001",
+		Node:          "127.0.0.1:9001",
+		Cors:          "http://127.0.0.1,http://localhost",
+		SkipBootstrap: true,
+		NodeLogLevel:  "warn",
+
+		AllowNodeRestart: true,
+		ShutdownNode:     true,
+
+		VitStation:  "0.0.0.0:3030",
+		VitLogLevel: "warn",
+
+		Proposals:        assets + "proposals.csv",
+		Fund:             assets + "fund.csv",
+		Challenges:       assets + "challenges.csv",
+		GenesisExtraData: assets + "extra_genesis_data.yaml",
+
+		VoteDuration:      "144h",
+		CommitteeDuration: "24h",
+
+		VotePlanProposalsMax: 255,
+
+		SlotDuration:        "20s",
+		EpochDuration:       "24h",
+		EpochStabilityDepth: 102_400,
+		Discrimination:      DiscriminationProduction,
+
+		Consensus:            "bft",
+		StakePools:           1,
+		StakePoolFund:        1_000_000_000_000,
+		PraosActiveSlotCoeff: 0.1,
+		KesUpdateSpeed:       43_200,
+
+		VoterFund: 10_000_000_000,
+
+		BftLeaderMin: 1,
+
+		CommitteePrivacyMembers:   1,
+		CommitteePrivacyThreshold: 1,
+
+		FeesGoTo: "rewards",
+
+		TimeFormat: time.RFC3339,
+	}
+}
+
+// Schedule is the resolved genesis and vote timing.
+type Schedule struct {
+	GenesisTime      time.Time     `json:"genesis_time"`
+	SlotDuration     time.Duration `json:"slot_duration"`
+	EpochDuration    time.Duration `json:"epoch_duration"`
+	VoteStartTime    time.Time     `json:"vote_start_time"`
+	VoteEndTime      time.Time     `json:"vote_end_time"`
+	CommitteeEndTime time.Time     `json:"committee_end_time"`
+
+	VoteStart    vresult.ChainTime `json:"vote_start"`
+	VoteEnd      vresult.ChainTime `json:"vote_end"`
+	CommitteeEnd vresult.ChainTime `json:"committee_end"`
+}
+
+// SlotsPerEpoch of the schedule.
+func (s *Schedule) SlotsPerEpoch() uint32 {
+	return uint32(s.EpochDuration / s.SlotDuration)
+}
+
+// ToChainTime converts t to the schedule chain time.
+func (s *Schedule) ToChainTime(t time.Time) vresult.ChainTime {
+	return vresult.ToChainTime(s.GenesisTime.Unix(), uint8(s.SlotDuration.Seconds()), s.SlotsPerEpoch(), t.Unix())
+}
+
+// parseDuration of a period that needs to be a multiple of step (if > 0).
+func parseDuration(name string, value string, step time.Duration) (time.Duration, error) {
+	dur, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	switch {
+	case dur == 0:
+		return 0, fmt.Errorf("[%s] - cannot be 0", name)
+	case dur%time.Second > 0:
+		return 0, fmt.Errorf("[%s] - smallest unit is [1s]", name)
+	case step > 0 && dur%step > 0:
+		return 0, fmt.Errorf("[%s: %s] - should be multiple of [%s: %s].", name, dur.String(), "SlotDuration", step.String())
+	}
+	return dur, nil
+}
+
+// Resolve validates the config and fills the settings left empty with their computed values
+// (ex: genesis-time is set to Now(), vote-end to vote-start + vote-duration), returning the resulting schedule.
+func (cfg *Config) Resolve() (*Schedule, error) {
+	var (
+		err error
+		s   Schedule
+	)
+
+	if cfg.NodeLogLevel == "" {
+		cfg.NodeLogLevel = "warn"
+	}
+	if cfg.VitLogLevel == "" {
+		cfg.VitLogLevel = "warn"
+	}
+
+	// check if file exist - duplicate data check is performed later on
+	for i := range cfg.BftLeaderSecretKeys {
+		_, err = os.Stat(cfg.BftLeaderSecretKeys[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// set new value for BftLeaderMin if provided inputs are more
+	if inputLeaders := uint(len(cfg.BftLeaderSecretKeys) + len(cfg.BftLeaderPublicKeys)); inputLeaders > cfg.BftLeaderMin {
+		cfg.BftLeaderMin = inputLeaders
+	}
+
+	if cfg.TimeFormat == "" {
+		cfg.TimeFormat = time.RFC3339
+	}
+
+	// nothing can be left to Now() or random generation
+	if cfg.Reproducible {
+		switch {
+		case cfg.GenesisTime == "":
+			return nil, fmt.Errorf("[%s] - needs to be pinned in reproducible mode", "genesis-time")
+		case cfg.OutputDir == "":
+			return nil, fmt.Errorf("[%s] - needs to be set in reproducible mode", "output-dir")
+		case cfg.KeySeed == "" && cfg.KeyDir == "":
+			return nil, fmt.Errorf("[%s or %s] - needs to be set in reproducible mode", "key-seed", "key-dir")
+		}
+	}
+
+	if cfg.GenesisTime == "" {
+		cfg.GenesisTime = time.Now().UTC().Format(time.RFC3339)
+	}
+	s.GenesisTime, err = time.Parse(time.RFC3339, cfg.GenesisTime)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", "genesisTime", err)
+	}
+
+	s.SlotDuration, err = parseDuration("slotDuration", cfg.SlotDuration, 0)
+	if err != nil {
+		return nil, err
+	}
+	if s.SlotDuration > 255*time.Second {
+		return nil, fmt.Errorf("[%s] - max allowed value is [255s]", "slotDuration")
+	}
+
+	s.EpochDuration, err = parseDuration("epochDuration", cfg.EpochDuration, s.SlotDuration)
+	if err != nil {
+		return nil, err
+	}
+	voteDur, err := parseDuration("voteDuration", cfg.VoteDuration, s.SlotDuration)
+	if err != nil {
+		return nil, err
+	}
+	committeeDur, err := parseDuration("committeeDuration", cfg.CommitteeDuration, s.SlotDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.VoteStart == "" {
+		cfg.VoteStart = cfg.GenesisTime
+	}
+	s.VoteStartTime, err = time.Parse(time.RFC3339, cfg.VoteStart)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", "voteStartTime", err)
+	}
+	switch {
+	case s.VoteStartTime.Sub(s.GenesisTime) < 0:
+		return nil, fmt.Errorf("%s: [%s] can't be smaller than %s: [%s]", "voteStart", cfg.VoteStart, "genesisTime", cfg.GenesisTime)
+	case s.VoteStartTime.Sub(s.GenesisTime)%s.SlotDuration != 0:
+		return nil, fmt.Errorf("%s: [%s] needs to have %s: [%s] steps from %s: [%s]", "voteStart", cfg.VoteStart, "SlotDuration", s.SlotDuration.String(), "genesisTime", cfg.GenesisTime)
+	}
+
+	if cfg.VoteEnd == "" {
+		cfg.VoteEnd = s.VoteStartTime.Add(voteDur).Format(time.RFC3339)
+	}
+	s.VoteEndTime, err = time.Parse(time.RFC3339, cfg.VoteEnd)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", "voteEndTime", err)
+	}
+	switch {
+	case s.VoteEndTime.Sub(s.VoteStartTime) < 0:
+		return nil, fmt.Errorf("%s: [%s] can't be smaller than %s: [%s]", "voteEnd", cfg.VoteEnd, "voteStart", cfg.VoteStart)
+	case s.VoteEndTime.Sub(s.GenesisTime)%s.SlotDuration != 0:
+		return nil, fmt.Errorf("%s: [%s] needs to have %s: [%s] steps from %s: [%s]", "voteEnd", cfg.VoteEnd, "SlotDuration", s.SlotDuration.String(), "genesisTime", cfg.GenesisTime)
+	}
+
+	if cfg.CommitteeEnd == "" {
+		cfg.CommitteeEnd = s.VoteEndTime.Add(committeeDur).Format(time.RFC3339)
+	}
+	s.CommitteeEndTime, err = time.Parse(time.RFC3339, cfg.CommitteeEnd)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", "committeeEndTime", err)
+	}
+	switch {
+	case s.CommitteeEndTime.Sub(s.VoteEndTime) < 0:
+		return nil, fmt.Errorf("%s: [%s] can't be smaller than %s: [%s]", "committeeEnd", cfg.CommitteeEnd, "voteEnd", cfg.VoteEnd)
+	case s.CommitteeEndTime.Sub(s.GenesisTime)%s.SlotDuration != 0:
+		return nil, fmt.Errorf("%s: [%s] needs to have %s: [%s] steps from %s: [%s]", "committeeEnd", cfg.CommitteeEnd, "SlotDuration", s.SlotDuration.String(), "genesisTime", cfg.GenesisTime)
+	}
+
+	s.VoteStart = s.ToChainTime(s.VoteStartTime)
+	s.VoteEnd = s.ToChainTime(s.VoteEndTime)
+	s.CommitteeEnd = s.ToChainTime(s.CommitteeEndTime)
+
+	switch {
+	case cfg.Proposals == "":
+		return nil, fmt.Errorf("[%s] - not provided", "proposals file")
+	case cfg.Fund == "":
+		return nil, fmt.Errorf("[%s] - not provided", "fund file")
+	case cfg.Challenges == "":
+		return nil, fmt.Errorf("[%s] - not provided", "challenges file")
+	case cfg.BftLeaderMin == 0:
+		return nil, fmt.Errorf("[%s: %d] - wrong value", "bftLeaderTot", cfg.BftLeaderMin)
+
+	case cfg.Proxy == "":
+		return nil, fmt.Errorf("[%s] - not set", "proxy")
+	case cfg.Rest == "":
+		return nil, fmt.Errorf("[%s] - not set", "rest")
+	case cfg.Node == "":
+		return nil, fmt.Errorf("[%s] - not set", "node")
+
+	case cfg.VitStation == "":
+		return nil, fmt.Errorf("[%s] - not set", "vit-station")
+
+	case cfg.ProxyNode > 0 && !cfg.MultiNode:
+		return nil, fmt.Errorf("[%s: %d] - wrong value, expected 0 when %s is not enabled", "proxy-node", cfg.ProxyNode, "multi-node")
+
+	case cfg.Consensus != "bft" && cfg.Consensus != "genesis_praos":
+		return nil, fmt.Errorf("%s - expected to be one of (%s, %s) - but [%s] provided", "consensus", "bft", "genesis_praos", cfg.Consensus)
+	case cfg.Consensus == "genesis_praos" && cfg.StakePools == 0:
+		return nil, fmt.Errorf("[%s: %d] - wrong value, expected > 0 with %s", "stake-pools", cfg.StakePools, "genesis_praos")
+	case cfg.Consensus == "genesis_praos" && cfg.StakePoolFund == 0:
+		return nil, fmt.Errorf("[%s] - cannot be 0 with %s, the pools need stake", "stake-pool-fund", "genesis_praos")
+	case cfg.Voters > 0 && cfg.VoterFund == 0:
+		return nil, fmt.Errorf("[%s] - cannot be 0 with %s", "voter-fund", "voters")
+	case cfg.VoterFundMax > 0 && cfg.VoterFundMax < cfg.VoterFund:
+		return nil, fmt.Errorf("[%s: %d] - wrong value, expected >= %s (%d)", "voter-fund-max", cfg.VoterFundMax, "voter-fund", cfg.VoterFund)
+	case cfg.PraosActiveSlotCoeff < 0.001 || cfg.PraosActiveSlotCoeff > 1:
+		return nil, fmt.Errorf("[%s: %v] - wrong value, expected [0.001 - 1.000]", "praos-active-slot-coeff", cfg.PraosActiveSlotCoeff)
+	case cfg.KesUpdateSpeed < 60 || cfg.KesUpdateSpeed > 365*24*3600:
+		return nil, fmt.Errorf("[%s: %d] - wrong value, expected [60 - %d] seconds", "kes-update-speed", cfg.KesUpdateSpeed, 365*24*3600)
+	case cfg.EpochStabilityDepth == 0:
+		return nil, fmt.Errorf("[%s] - cannot be 0", "epoch-stability-depth")
+	case cfg.Discrimination != DiscriminationProduction && cfg.Discrimination != DiscriminationTesting:
+		return nil, fmt.Errorf("%s - expected to be one of (%s, %s) - but [%s] provided", "discrimination", DiscriminationProduction, DiscriminationTesting, cfg.Discrimination)
+
+	case cfg.CommitteePrivacyMembers < 1 || cfg.CommitteePrivacyMembers > 255:
+		return nil, fmt.Errorf("[%s: %d] - wrong value, expected [1 - 255]", "committee-privacy-members", cfg.CommitteePrivacyMembers)
+	case cfg.CommitteePrivacyThreshold < 1 || cfg.CommitteePrivacyThreshold > cfg.CommitteePrivacyMembers:
+		return nil, fmt.Errorf("[%s: %d] - wrong value, expected [1 - %d] (%s)", "committee-privacy-threshold", cfg.CommitteePrivacyThreshold, cfg.CommitteePrivacyMembers, "committee-privacy-members")
+
+	case cfg.SubmitVotePlans && cfg.Block0VotePlan:
+		return nil, fmt.Errorf("[%s] - needs [%s] disabled", "submit-voteplans", "block0-voteplan")
+
+	case cfg.VotePlanProposalsMax < 1:
+		return nil, fmt.Errorf("[%s: %d] - wrong value, expected > 0", "votePlanProposalsMax", cfg.VotePlanProposalsMax)
+	}
+
+	return &s, nil
+}
+
+// splitAddrPort splits an IP:PORT address.
+func splitAddrPort(name string, addrPort string) (string, int, error) {
+	listen := strings.Split(addrPort, ":")
+	if len(listen) != 2 {
+		return "", 0, fmt.Errorf("[%s: %s] - wrong value, expected IP:PORT format", name, addrPort)
+	}
+	port, err := strconv.Atoi(listen[1])
+	if err != nil {
+		return "", 0, fmt.Errorf("%s: %w", name, err)
+	}
+	return listen[0], port, nil
+}
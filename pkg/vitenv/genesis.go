@@ -0,0 +1,199 @@
+package vitenv
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"strings"
+
+	"github.com/input-output-hk/jorvit/internal/bech32"
+	"github.com/rinor/jorcli/jnode"
+	"gopkg.in/yaml.v2"
+)
+
+// initialFundsMax outputs of a block0 initial fund entry (one transaction each).
+const initialFundsMax = 255
+
+// initialFunds groups funds into block0 initial entries, initialFundsMax funds per entry.
+func initialFunds(funds []jnode.InitialFund) []jnode.BlockchainInitial {
+	var initial []jnode.BlockchainInitial
+	for i := 0; i < len(funds); i += initialFundsMax {
+		end := i + initialFundsMax
+		if end > len(funds) {
+			end = len(funds)
+		}
+		initial = append(initial, jnode.BlockchainInitial{Fund: funds[i:end]})
+	}
+	return initial
+}
+
+// genesisFund is a block0 initial fund (or legacy fund) of the extra genesis data file.
+type genesisFund struct {
+	Address string `yaml:"address"`
+	Value   uint64 `yaml:"value"`
+}
+
+// extraGenesisEntry is a block0 initial entry of the extra genesis data file,
+// only one of fund, cert or legacy_fund is expected.
+type extraGenesisEntry struct {
+	Fund       []genesisFund `yaml:"fund"`
+	Cert       string        `yaml:"cert"`
+	LegacyFund []genesisFund `yaml:"legacy_fund"`
+}
+
+// InitialSummary totals of block0 initial entries.
+type InitialSummary struct {
+	Funds            int    `json:"funds"`
+	FundsValue       uint64 `json:"funds_value"`
+	LegacyFunds      int    `json:"legacy_funds"`
+	LegacyFundsValue uint64 `json:"legacy_funds_value"`
+	Certificates     int    `json:"certificates"`
+}
+
+// String of the summary, ex: "100 funds (1000000000000), 0 legacy funds (0), 2 certificates".
+func (s InitialSummary) String() string {
+	return fmt.Sprintf("%d funds (%d), %d legacy funds (%d), %d certificates", s.Funds, s.FundsValue, s.LegacyFunds, s.LegacyFundsValue, s.Certificates)
+}
+
+// add the initial entry to the summary, fails if a total overflows.
+func (s *InitialSummary) add(initial jnode.BlockchainInitial) error {
+	for _, fund := range initial.Fund {
+		if fund.Value > math.MaxUint64-s.FundsValue {
+			return fmt.Errorf("[%s: %d] - funds total overflow", fund.Address, fund.Value)
+		}
+		s.Funds++
+		s.FundsValue += fund.Value
+	}
+	for _, fund := range initial.LegacyFund {
+		if fund.Value > math.MaxUint64-s.LegacyFundsValue {
+			return fmt.Errorf("[%s: %d] - legacy funds total overflow", fund.Address, fund.Value)
+		}
+		s.LegacyFunds++
+		s.LegacyFundsValue += fund.Value
+	}
+	if initial.Cert != "" {
+		s.Certificates++
+	}
+	return nil
+}
+
+// GenesisExtraData are the block0 initial entries loaded from the extra genesis data file.
+type GenesisExtraData struct {
+	Initial []jnode.BlockchainInitial
+	Summary InitialSummary
+}
+
+// checkCertificate verifies that cert is a bech32 signed certificate.
+func checkCertificate(cert string) error {
+	hrp, _, err := bech32.Decode(cert)
+	if err != nil {
+		return fmt.Errorf("[%s] - %w", cert, err)
+	}
+	if hrp != "signedcert" {
+		return fmt.Errorf("[%s] - expected a signed certificate (signedcert1...)", cert)
+	}
+	return nil
+}
+
+// parseGenesisExtraData parses the extra genesis data (yaml list of block0 initial entries), verifying:
+//   - each entry has only one of fund, cert or legacy_fund
+//   - each fund address bech32 and discrimination, each legacy fund address base58 (byron) and checksum
+//   - each cert bech32 signed certificate
+//   - no duplicated fund addresses
+func parseGenesisExtraData(data []byte, discrimination string) (*GenesisExtraData, error) {
+	var entries []extraGenesisEntry
+	err := yaml.UnmarshalStrict(data, &entries)
+	if err != nil {
+		return nil, err
+	}
+
+	extra := &GenesisExtraData{}
+	for i, entry := range entries {
+		kinds := 0
+		for _, set := range []bool{len(entry.Fund) > 0, entry.Cert != "", len(entry.LegacyFund) > 0} {
+			if set {
+				kinds++
+			}
+		}
+		if kinds != 1 {
+			return nil, fmt.Errorf("initial [%d] - expected one of (%s, %s, %s) - but %d provided", i, "fund", "cert", "legacy_fund", kinds)
+		}
+
+		initial := jnode.BlockchainInitial{}
+		for j, fund := range entry.Fund {
+			err = checkAddress(fund.Address, discrimination)
+			if err != nil {
+				return nil, fmt.Errorf("initial [%d] fund [%d]: %w", i, j, err)
+			}
+			initial.Fund = append(initial.Fund, jnode.InitialFund{Address: fund.Address, Value: fund.Value})
+		}
+		if entry.Cert != "" {
+			err = checkCertificate(entry.Cert)
+			if err != nil {
+				return nil, fmt.Errorf("initial [%d] cert: %w", i, err)
+			}
+			initial.Cert = entry.Cert
+		}
+		for j, fund := range entry.LegacyFund {
+			err = checkLegacyAddress(fund.Address)
+			if err != nil {
+				return nil, fmt.Errorf("initial [%d] legacy_fund [%d]: %w", i, j, err)
+			}
+			initial.LegacyFund = append(initial.LegacyFund, jnode.InitialFund{Address: fund.Address, Value: fund.Value})
+		}
+
+		err = extra.Summary.add(initial)
+		if err != nil {
+			return nil, fmt.Errorf("initial [%d]: %w", i, err)
+		}
+		extra.Initial = append(extra.Initial, initial)
+	}
+
+	return extra, checkInitialFunds(extra.Initial)
+}
+
+// loadGenesisExtraData reads and parses the extra genesis data file.
+func loadGenesisExtraData(file string, discrimination string) (*GenesisExtraData, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return &GenesisExtraData{}, nil
+	}
+	return parseGenesisExtraData(data, discrimination)
+}
+
+// checkInitialFunds reports the fund (and legacy fund) addresses found more than once in the block0 initial entries.
+func checkInitialFunds(initial []jnode.BlockchainInitial) error {
+	seen := make(map[string]int)
+	var dups []string
+	count := func(funds []jnode.InitialFund) {
+		for _, fund := range funds {
+			seen[fund.Address]++
+			if seen[fund.Address] == 2 {
+				dups = append(dups, fund.Address)
+			}
+		}
+	}
+	for i := range initial {
+		count(initial[i].Fund)
+		count(initial[i].LegacyFund)
+	}
+	if len(dups) > 0 {
+		return fmt.Errorf("duplicated initial fund address(es) - [%s]", strings.Join(dups, ", "))
+	}
+	return nil
+}
+
+// summarizeInitial totals the block0 initial entries.
+func summarizeInitial(initial []jnode.BlockchainInitial) (InitialSummary, error) {
+	var summary InitialSummary
+	for i := range initial {
+		if err := summary.add(initial[i]); err != nil {
+			return summary, fmt.Errorf("initial [%d]: %w", i, err)
+		}
+	}
+	return summary, nil
+}
@@ -0,0 +1,183 @@
+package vitenv
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/input-output-hk/jorvit/internal/bech32"
+)
+
+// testLegacyAddress is a legacy (byron) address.
+const testLegacyAddress = "Ae2tdPwUPEYwrazXRJVK4NgHSZCjP9kLSMrx2awgYiBH61zT8kz6u33Sije"
+
+func TestCheckLegacyAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		errMsg  string
+	}{
+		{"byron", testLegacyAddress, ""},
+		{"empty", "", "not a tagged cbor address"},
+		{"not base58", "Ae2tdPwUPEYwrazXRJVK4NgHSZCjP9kLSMrx2awgYiBH61zT8kz6u33Sij0", "invalid base58 character '0'"},
+		{"checksum", "Ae2tdPwUPEYwrazXRJVK4NgHSZCjP9kLSMrx2awgYiBH61zT8kz6u33Sijf", "expected a legacy (base58) address"},
+		{"changed payload", "Ae2tdPwUPEYwrazXRJVK4NgHSZCjP9kLSMrx2awgYiBH61zT8kz6u33Sjje", "expected a legacy (base58) address"},
+		{"truncated", testLegacyAddress[:40], "expected a legacy (base58) address"},
+		{"bech32 account", testAccountTesting, "expected a legacy (base58) address"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkLegacyAddress(tt.address)
+			switch {
+			case tt.errMsg == "" && err != nil:
+				t.Fatalf("checkLegacyAddress: %v", err)
+			case tt.errMsg != "" && (err == nil || !strings.Contains(err.Error(), tt.errMsg)):
+				t.Fatalf("checkLegacyAddress: got %v, want error containing %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestParseGenesisExtraData(t *testing.T) {
+	cert, err := bech32.Encode("signedcert", []byte("test certificate"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	notCert, err := bech32.Encode("cert", []byte("test certificate"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	production := testAccountProduction(t)
+
+	tests := []struct {
+		name           string
+		data           string
+		discrimination string
+		summary        InitialSummary
+		errMsg         string
+	}{
+		{
+			name: "all kinds",
+			data: "- fund:\n    - address: " + testAccountTesting + "\n      value: 100\n    - address: " + testSingleTesting + "\n      value: 50\n" +
+				"- cert: " + cert + "\n" +
+				"- legacy_fund:\n    - address: " + testLegacyAddress + "\n      value: 7\n",
+			discrimination: DiscriminationTesting,
+			summary:        InitialSummary{Funds: 2, FundsValue: 150, LegacyFunds: 1, LegacyFundsValue: 7, Certificates: 1},
+		},
+		{
+			name:           "production",
+			data:           "- fund:\n    - address: " + production + "\n      value: 1\n",
+			discrimination: DiscriminationProduction,
+			summary:        InitialSummary{Funds: 1, FundsValue: 1},
+		},
+		{
+			name:   "unknown field",
+			data:   "- fund:\n    - address: " + testAccountTesting + "\n      amount: 100\n",
+			errMsg: "field amount not found",
+		},
+		{
+			name:   "unknown entry",
+			data:   "- funds:\n    - address: " + testAccountTesting + "\n",
+			errMsg: "field funds not found",
+		},
+		{
+			name:   "two kinds",
+			data:   "- fund:\n    - address: " + testAccountTesting + "\n      value: 1\n  cert: " + cert + "\n",
+			errMsg: "initial [0] - expected one of (fund, cert, legacy_fund) - but 2 provided",
+		},
+		{
+			name:   "empty entry",
+			data:   "- fund: []\n",
+			errMsg: "initial [0] - expected one of (fund, cert, legacy_fund) - but 0 provided",
+		},
+		{
+			name:           "bad discrimination",
+			data:           "- fund:\n    - address: " + testAccountTesting + "\n      value: 1\n",
+			discrimination: DiscriminationProduction,
+			errMsg:         "initial [0] fund [0]: [" + testAccountTesting + "] - expected production discrimination address",
+		},
+		{
+			name:   "bad address",
+			data:   "- fund:\n    - address: " + testLegacyAddress + "\n      value: 1\n",
+			errMsg: "initial [0] fund [0]: [" + testLegacyAddress + "]",
+		},
+		{
+			name:   "bad cert",
+			data:   "- cert: " + notCert + "\n",
+			errMsg: "initial [0] cert: [" + notCert + "] - expected a signed certificate",
+		},
+		{
+			name:   "bad legacy address",
+			data:   "- legacy_fund:\n    - address: " + testAccountTesting + "\n      value: 1\n",
+			errMsg: "initial [0] legacy_fund [0]: [" + testAccountTesting + "] - expected a legacy (base58) address",
+		},
+		{
+			name:   "legacy address missing",
+			data:   "- legacy_fund:\n    - value: 1\n",
+			errMsg: "initial [0] legacy_fund [0]: [] - expected a legacy (base58) address",
+		},
+		{
+			name:   "duplicate fund",
+			data:   "- fund:\n    - address: " + testAccountTesting + "\n      value: 1\n- fund:\n    - address: " + testAccountTesting + "\n      value: 2\n",
+			errMsg: "duplicated initial fund address(es) - [" + testAccountTesting + "]",
+		},
+		{
+			name:   "duplicate legacy fund",
+			data:   "- legacy_fund:\n    - address: " + testLegacyAddress + "\n      value: 1\n    - address: " + testLegacyAddress + "\n      value: 2\n",
+			errMsg: "duplicated initial fund address(es) - [" + testLegacyAddress + "]",
+		},
+		{
+			name:   "overflow",
+			data:   "- fund:\n    - address: " + testAccountTesting + "\n      value: 18446744073709551615\n    - address: " + testSingleTesting + "\n      value: 1\n",
+			errMsg: "initial [0]: [" + testSingleTesting + ": 1] - funds total overflow",
+		},
+		{
+			name:   "not a list",
+			data:   "fund: []\n",
+			errMsg: "cannot unmarshal",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			discrimination := tt.discrimination
+			if discrimination == "" {
+				discrimination = DiscriminationTesting
+			}
+			extra, err := parseGenesisExtraData([]byte(tt.data), discrimination)
+			if tt.errMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+					t.Fatalf("parseGenesisExtraData: got %v, want error containing %q", err, tt.errMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseGenesisExtraData: %v", err)
+			}
+			if extra.Summary != tt.summary {
+				t.Errorf("summary: got %s, want %s", extra.Summary, tt.summary)
+			}
+			if summary, err := summarizeInitial(extra.Initial); err != nil || summary != tt.summary {
+				t.Errorf("summarizeInitial: got %s (%v), want %s", summary, err, tt.summary)
+			}
+		})
+	}
+}
+
+func TestLoadGenesisExtraData(t *testing.T) {
+	dir := t.TempDir()
+
+	extra, err := loadGenesisExtraData(writeTestFile(t, dir, "empty.yaml", "\n  \n"), DiscriminationTesting)
+	if err != nil || len(extra.Initial) != 0 {
+		t.Errorf("loadGenesisExtraData empty: got %+v (%v)", extra, err)
+	}
+
+	// the repo sample
+	if _, err = loadGenesisExtraData("../../assets/extra_genesis_data.yaml", DiscriminationProduction); err != nil {
+		t.Errorf("loadGenesisExtraData assets: %v", err)
+	}
+
+	if _, err = loadGenesisExtraData(dir+"/missing.yaml", DiscriminationTesting); err == nil {
+		t.Error("loadGenesisExtraData: expected error on missing file")
+	}
+}
@@ -0,0 +1,72 @@
+package vitenv
+
+import (
+	"bytes"
+	"os/exec"
+	"strconv"
+)
+
+// jcliExec executes "stdin | jcli args | stdout" with the environment jcli binary,
+// for the jcli commands not (yet) available from the jorcli package.
+func (env *Environment) jcliExec(stdin []byte, arg ...string) ([]byte, error) {
+	var (
+		stdout bytes.Buffer
+		stderr bytes.Buffer
+	)
+	cmd := exec.Command(env.JcliBin, arg...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if stdin != nil {
+		cmd.Stdin = bytes.NewBuffer(stdin)
+	}
+
+	if err := cmd.Run(); err != nil {
+		return stderr.Bytes(), err
+	}
+	return stdout.Bytes(), nil
+}
+
+// votesTallyDecryptionShares - create the decryption shares of a member for the voteplan encrypted tally.
+//
+//	jcli votes tally decryption-shares --vote-plan <active plans file> --vote-plan-id <id> --key <member sk file> | STDOUT
+func (env *Environment) votesTallyDecryptionShares(votePlanFile string, votePlanID string, memberSKFile string) ([]byte, error) {
+	return env.jcliExec(nil,
+		"votes", "tally", "decryption-shares",
+		"--vote-plan", votePlanFile,
+		"--vote-plan-id", votePlanID,
+		"--key", memberSKFile,
+	)
+}
+
+// votesTallyMergeShares - merge the members decryption shares.
+//
+//	jcli votes tally merge-shares <shares file>... | STDOUT
+func (env *Environment) votesTallyMergeShares(sharesFiles []string) ([]byte, error) {
+	return env.jcliExec(nil, append([]string{"votes", "tally", "merge-shares"}, sharesFiles...)...)
+}
+
+// votesTallyDecryptResults - decrypt the voteplan tally with the merged shares.
+//
+//	jcli votes tally decrypt-results --vote-plan <active plans file> --vote-plan-id <id> --shares <merged shares file> --threshold <threshold> --output-format json | STDOUT
+func (env *Environment) votesTallyDecryptResults(votePlanFile string, votePlanID string, sharesFile string, threshold uint) ([]byte, error) {
+	return env.jcliExec(nil,
+		"votes", "tally", "decrypt-results",
+		"--vote-plan", votePlanFile,
+		"--vote-plan-id", votePlanID,
+		"--shares", sharesFile,
+		"--threshold", strconv.FormatUint(uint64(threshold), 10),
+		"--output-format", "json",
+	)
+}
+
+// certificateNewVoteTallyPrivate - create the private vote tally certificate, with the merged shares.
+//
+//	jcli certificate new vote-tally private --shares <merged shares file> --vote-plan <active plans file> --vote-plan-id <id> | STDOUT
+func (env *Environment) certificateNewVoteTallyPrivate(votePlanFile string, votePlanID string, sharesFile string) ([]byte, error) {
+	return env.jcliExec(nil,
+		"certificate", "new", "vote-tally", "private",
+		"--shares", sharesFile,
+		"--vote-plan", votePlanFile,
+		"--vote-plan-id", votePlanID,
+	)
+}
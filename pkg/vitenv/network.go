@@ -0,0 +1,249 @@
+package vitenv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/input-output-hk/jorvit/internal/kit"
+	"github.com/rinor/jorcli/jnode"
+)
+
+// NetworkNode is a jörmungandr node of the environment, with its own storage, ports and leader(s) secret.
+type NetworkNode struct {
+	Dir               string   `json:"dir"`
+	ConfigFile        string   `json:"config_file"`
+	StorageDir        string   `json:"storage_dir"`
+	RestAddress       string   `json:"rest_address"`
+	P2PListenAddress  string   `json:"p2p_listen_address"`
+	SecretConfigFiles []string `json:"secret_config_files,omitempty"`
+
+	Node *jnode.Jnode `json:"-"`
+
+	started bool
+	logs    []*os.File // node stdout/stderr, open while started
+}
+
+// closeLogs closes the node stdout/stderr log files.
+func (n *NetworkNode) closeLogs() error {
+	var errs []error
+	for _, f := range n.logs {
+		if err := f.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	n.logs = nil
+	if len(errs) > 0 {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
+
+// nodeDir of the i-th network node, the first one uses the main working dir.
+func nodeDir(workingDir string, i int) string {
+	if i == 0 {
+		return workingDir
+	}
+	return filepath.Join(workingDir, "node_"+strconv.Itoa(i))
+}
+
+// p2pAddress in multiaddr format.
+func p2pAddress(addr string, port int) string {
+	return "/ip4/" + addr + "/tcp/" + strconv.Itoa(port)
+}
+
+// secretConfigFiles run by the nodes: the stake pools ones with genesis_praos, the leaders ones otherwise.
+// Leaders without a secret key can't run a node.
+func (env *Environment) secretConfigFiles() []string {
+	var files []string
+	if env.Config.Consensus == "genesis_praos" {
+		for i := range env.StakePools {
+			files = append(files, env.StakePools[i].SecretConfigFile)
+		}
+		return files
+	}
+	for i := range env.Leaders {
+		if env.Leaders[i].SecretConfigFile != "" {
+			files = append(files, env.Leaders[i].SecretConfigFile)
+		}
+	}
+	return files
+}
+
+// nodesLeaders splits the secrets (leaders or stake pools) among the nodes: a single node runs all of them,
+// with multiNode each one gets its own node. If none is available a single passive node is used.
+func nodesLeaders(secrets int, multiNode bool) []int {
+	if !multiNode || secrets <= 1 {
+		return []int{secrets}
+	}
+	leaders := make([]int, secrets)
+	for i := range leaders {
+		leaders[i] = 1
+	}
+	return leaders
+}
+
+// planNodes lays out the nodes running the secrets (see nodesLeaders), with REST and P2P ports increased by the
+// node index. The ports have to be unique among all the services, and Config.ProxyNode one of the nodes.
+func (cfg *Config) planNodes(secrets int) ([]PlannedNode, error) {
+	nodeAddr, nodePort, err := splitAddrPort("node", cfg.Node)
+	if err != nil {
+		return nil, err
+	}
+	restAddr, restPort, err := splitAddrPort("rest", cfg.Rest)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := map[string]string{
+		"proxy":       cfg.Proxy,
+		"vit-station": cfg.VitStation,
+	}
+	var nodes []PlannedNode
+	for i, leaders := range nodesLeaders(secrets, cfg.MultiNode) {
+		node := PlannedNode{
+			RestAddress:      restAddr + ":" + strconv.Itoa(restPort+i),
+			P2PListenAddress: p2pAddress(nodeAddr, nodePort+i),
+			Leaders:          leaders,
+		}
+		nodes = append(nodes, node)
+		addresses["node_"+strconv.Itoa(i)+" rest"] = node.RestAddress
+		addresses["node_"+strconv.Itoa(i)+" p2p"] = nodeAddr + ":" + strconv.Itoa(nodePort+i)
+	}
+	err = checkPorts(addresses)
+	if err != nil {
+		return nil, err
+	}
+	if int(cfg.ProxyNode) >= len(nodes) {
+		return nil, fmt.Errorf("[%s: %d] - wrong value, %d node(s) available", "proxy-node", cfg.ProxyNode, len(nodes))
+	}
+	return nodes, nil
+}
+
+// checkPorts fails if the same port is used by more than one service.
+func checkPorts(addresses map[string]string) error {
+	used := make(map[int]string, len(addresses))
+	for name, addrPort := range addresses {
+		_, port, err := splitAddrPort(name, addrPort)
+		if err != nil {
+			return err
+		}
+		if other, ok := used[port]; ok {
+			return fmt.Errorf("[%s: %s] - port already used by [%s]", name, addrPort, other)
+		}
+		used[port] = name
+	}
+	return nil
+}
+
+// generateNodes builds the node(s) configs, all from the same block0, laid out by Config.planNodes.
+// With Config.MultiNode there is one node per leader (or stake pool), with the other nodes as trusted peers.
+func (env *Environment) generateNodes() error {
+	cfg := &env.Config
+
+	secrets := env.secretConfigFiles()
+	planned, err := cfg.planNodes(len(secrets))
+	if err != nil {
+		return err
+	}
+
+	env.Nodes = make([]*NetworkNode, len(planned))
+	for i, p := range planned {
+		n := &NetworkNode{
+			Dir:              nodeDir(env.WorkingDir, i),
+			RestAddress:      p.RestAddress,
+			P2PListenAddress: p.P2PListenAddress,
+		}
+		if p.Leaders > 0 {
+			n.SecretConfigFiles, secrets = secrets[:p.Leaders:p.Leaders], secrets[p.Leaders:]
+		}
+		n.ConfigFile = filepath.Join(n.Dir, NodeConfigFile)
+		n.StorageDir = filepath.Join(n.Dir, NodeStorageDir)
+		env.Nodes[i] = n
+	}
+
+	for i, n := range env.Nodes {
+		if n.Dir != env.WorkingDir {
+			err = os.Mkdir(n.Dir, 0755)
+			if err != nil {
+				return kit.ErrorOn(err, "nodeDir")
+			}
+		}
+
+		nodeCfg := jnode.NewNodeConfig()
+
+		nodeCfg.Storage = n.StorageDir
+
+		nodeCfg.SkipBootstrap = cfg.SkipBootstrap
+		nodeCfg.BootstrapFromTrustedPeers = true
+
+		nodeCfg.Rest.Listen = n.RestAddress
+		nodeCfg.Rest.Cors.AllowedOrigins = strings.Split(cfg.Cors, ",")
+		nodeCfg.Rest.Cors.MaxAgeSecs = 0
+
+		nodeCfg.P2P.PublicAddress = n.P2PListenAddress
+		nodeCfg.P2P.ListenAddress = n.P2PListenAddress
+		nodeCfg.P2P.AllowPrivateAddresses = true
+		nodeCfg.P2P.MaxBootstrapAttempts = 5
+
+		for j, peer := range env.Nodes {
+			if j != i {
+				nodeCfg.AddTrustedPeer(peer.P2PListenAddress, "")
+			}
+		}
+
+		nodeCfg.Log.Level = cfg.NodeLogLevel
+
+		nodeCfg.Explorer.Enabled = cfg.Explorer
+
+		for _, secretFile := range n.SecretConfigFiles {
+			nodeCfg.AddSecretFile(secretFile)
+		}
+
+		nodeCfgYaml, err := nodeCfg.ToYaml()
+		if err != nil {
+			return kit.ErrorOn(err, "nodeCfg.ToYaml")
+		}
+
+		// need this file for starting the node (--config)
+		err = writeFile(n.ConfigFile, nodeCfgYaml, 0644)
+		if err != nil {
+			return err
+		}
+
+		n.Node = jnode.NewJnode()
+
+		n.Node.WorkingDir = n.Dir
+		n.Node.GenesisBlock = env.Block0BinFile
+		n.Node.ConfigFile = n.ConfigFile
+
+		for _, secretFile := range n.SecretConfigFiles {
+			n.Node.AddSecretFile(secretFile)
+		}
+	}
+
+	env.setMainNode()
+
+	return nil
+}
+
+// setMainNode reports the first node also as the environment main node.
+func (env *Environment) setMainNode() {
+	main := env.Nodes[0]
+
+	env.Node = main.Node
+	env.NodeConfigFile = main.ConfigFile
+	env.NodeStorageDir = main.StorageDir
+	env.RestAddress = main.RestAddress
+	env.P2PListenAddress = main.P2PListenAddress
+}
+
+// ProxyNode is the node the app proxy forwards the requests to (Config.ProxyNode).
+func (env *Environment) ProxyNode() (*NetworkNode, error) {
+	if int(env.Config.ProxyNode) >= len(env.Nodes) {
+		return nil, fmt.Errorf("[%s: %d] - wrong value, %d node(s) available", "proxy-node", env.Config.ProxyNode, len(env.Nodes))
+	}
+	return env.Nodes[env.Config.ProxyNode], nil
+}
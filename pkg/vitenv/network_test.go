@@ -0,0 +1,186 @@
+package vitenv
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSecretConfigFiles(t *testing.T) {
+	leaders := []Leader{
+		{SecretConfigFile: "leader_0.yaml"},
+		{PublicKey: "public key only"},
+		{SecretConfigFile: "leader_2.yaml"},
+	}
+	pools := []StakePool{{SecretConfigFile: "pool_0.yaml"}, {SecretConfigFile: "pool_1.yaml"}}
+
+	tests := []struct {
+		name      string
+		consensus string
+		leaders   []Leader
+		multiNode bool
+		files     []string
+		want      []int
+	}{
+		{"single node", "bft", leaders, false, []string{"leader_0.yaml", "leader_2.yaml"}, []int{2}},
+		{"multi node", "bft", leaders, true, []string{"leader_0.yaml", "leader_2.yaml"}, []int{1, 1}},
+		{"passive node", "bft", leaders[1:2], true, nil, []int{0}},
+		{"stake pools", "genesis_praos", leaders, true, []string{"pool_0.yaml", "pool_1.yaml"}, []int{1, 1}},
+		{"stake pools single node", "genesis_praos", leaders, false, []string{"pool_0.yaml", "pool_1.yaml"}, []int{2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := &Environment{Config: Config{Consensus: tt.consensus}, Leaders: tt.leaders, StakePools: pools}
+			files := env.secretConfigFiles()
+			if !reflect.DeepEqual(files, tt.files) {
+				t.Errorf("secretConfigFiles: got %v, want %v", files, tt.files)
+			}
+			if got := nodesLeaders(len(files), tt.multiNode); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("nodesLeaders: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func testNetworkEnv(t *testing.T, multiNode bool) *Environment {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.Rest = "127.0.0.1:8001"
+	cfg.MultiNode = multiNode
+	return &Environment{
+		Config:            cfg,
+		WorkingDir:        t.TempDir(),
+		Block0BinFile:     "block0.bin",
+		ProxyAddress:      cfg.Proxy,
+		VitStationAddress: cfg.VitStation,
+		Leaders: []Leader{
+			{SecretConfigFile: "leader_0.yaml"},
+			{SecretConfigFile: "leader_1.yaml"},
+			{SecretConfigFile: "leader_2.yaml"},
+		},
+	}
+}
+
+func TestGenerateNodes(t *testing.T) {
+	env := testNetworkEnv(t, true)
+	if err := env.generateNodes(); err != nil {
+		t.Fatalf("generateNodes: %v", err)
+	}
+	if len(env.Nodes) != 3 {
+		t.Fatalf("nodes: got %d, want 3", len(env.Nodes))
+	}
+
+	want := []struct {
+		dir  string
+		rest string
+		p2p  string
+	}{
+		{env.WorkingDir, "127.0.0.1:8001", "/ip4/127.0.0.1/tcp/9001"},
+		{filepath.Join(env.WorkingDir, "node_1"), "127.0.0.1:8002", "/ip4/127.0.0.1/tcp/9002"},
+		{filepath.Join(env.WorkingDir, "node_2"), "127.0.0.1:8003", "/ip4/127.0.0.1/tcp/9003"},
+	}
+	for i, w := range want {
+		n := env.Nodes[i]
+		if n.Dir != w.dir || n.RestAddress != w.rest || n.P2PListenAddress != w.p2p {
+			t.Errorf("node_%d: got (%s, %s, %s), want (%s, %s, %s)", i, n.Dir, n.RestAddress, n.P2PListenAddress, w.dir, w.rest, w.p2p)
+		}
+		if n.StorageDir != filepath.Join(w.dir, NodeStorageDir) || n.Node == nil || n.Node.WorkingDir != w.dir || n.Node.GenesisBlock != "block0.bin" {
+			t.Errorf("node_%d: got storage %s, node %+v", i, n.StorageDir, n.Node)
+		}
+		if _, err := os.Stat(w.dir); err != nil {
+			t.Errorf("node_%d: %v", i, err)
+		}
+
+		// own secret and listen address, the others as trusted peers
+		nodeCfg, err := ioutil.ReadFile(n.ConfigFile)
+		if err != nil {
+			t.Fatalf("node_%d: %v", i, err)
+		}
+		if !strings.Contains(string(nodeCfg), w.rest) || strings.Count(string(nodeCfg), w.p2p) != 2 {
+			t.Errorf("node_%d config: missing own addresses\n%s", i, nodeCfg)
+		}
+		for j, other := range want {
+			if j != i && !strings.Contains(string(nodeCfg), other.p2p) {
+				t.Errorf("node_%d config: missing trusted peer %s", i, other.p2p)
+			}
+		}
+		if !reflect.DeepEqual(n.SecretConfigFiles, []string{env.Leaders[i].SecretConfigFile}) {
+			t.Errorf("node_%d: got secrets %v", i, n.SecretConfigFiles)
+		}
+	}
+
+	// the first node is the main one
+	if env.Node != env.Nodes[0].Node || env.RestAddress != "127.0.0.1:8001" || env.NodeConfigFile != env.Nodes[0].ConfigFile {
+		t.Errorf("main node: got %s %s", env.RestAddress, env.NodeConfigFile)
+	}
+}
+
+func TestGenerateNodesSingle(t *testing.T) {
+	env := testNetworkEnv(t, false)
+	if err := env.generateNodes(); err != nil {
+		t.Fatalf("generateNodes: %v", err)
+	}
+	if len(env.Nodes) != 1 || len(env.Nodes[0].SecretConfigFiles) != 3 || env.Nodes[0].Dir != env.WorkingDir {
+		t.Fatalf("nodes: got %d, %+v", len(env.Nodes), env.Nodes[0])
+	}
+	nodeCfg, err := ioutil.ReadFile(env.NodeConfigFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(nodeCfg), "/tcp/9002") {
+		t.Errorf("node config: unexpected trusted peer\n%s", nodeCfg)
+	}
+}
+
+func TestGenerateNodesPorts(t *testing.T) {
+	// node_2 rest would take the vit station port
+	env := testNetworkEnv(t, true)
+	env.Config.VitStation = "0.0.0.0:8003"
+	err := env.generateNodes()
+	if err == nil || !strings.Contains(err.Error(), "port already used") {
+		t.Fatalf("generateNodes: got %v, want port already used", err)
+	}
+	if _, err = os.Stat(filepath.Join(env.WorkingDir, "node_1")); !os.IsNotExist(err) {
+		t.Errorf("node_1 dir: got %v, want not created", err)
+	}
+}
+
+func TestCheckPorts(t *testing.T) {
+	tests := []struct {
+		name      string
+		addresses map[string]string
+		errMsg    string
+	}{
+		{"unique", map[string]string{"proxy": "0.0.0.0:8000", "rest": "127.0.0.1:8001"}, ""},
+		{"same port other ip", map[string]string{"proxy": "0.0.0.0:8000", "rest": "127.0.0.1:8000"}, "port already used"},
+		{"wrong format", map[string]string{"proxy": "0.0.0.0"}, "[proxy: 0.0.0.0] - wrong value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkPorts(tt.addresses)
+			switch {
+			case tt.errMsg == "" && err != nil:
+				t.Fatalf("checkPorts: %v", err)
+			case tt.errMsg != "" && (err == nil || !strings.Contains(err.Error(), tt.errMsg)):
+				t.Fatalf("checkPorts: got %v, want error containing %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestProxyNode(t *testing.T) {
+	env := &Environment{Nodes: []*NetworkNode{{RestAddress: "a"}, {RestAddress: "b"}}}
+	env.Config.ProxyNode = 1
+	if n, err := env.ProxyNode(); err != nil || n.RestAddress != "b" {
+		t.Errorf("ProxyNode: got %+v (%v), want node_1", n, err)
+	}
+	env.Config.ProxyNode = 2
+	if _, err := env.ProxyNode(); err == nil {
+		t.Error("ProxyNode: expected error out of range")
+	}
+}
@@ -0,0 +1,429 @@
+package vitenv
+
+import (
+	"crypto/ed25519"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/input-output-hk/jorvit/internal/bech32"
+	"github.com/input-output-hk/jorvit/internal/loader"
+	"github.com/input-output-hk/jorvit/pkg/vresult"
+	"github.com/rinor/jorcli/jnode"
+)
+
+// publicKeyOf returns the bech32 public key of a jcli ed25519 secret key file, empty if not an ed25519_sk.
+func publicKeyOf(skFile string) (string, error) {
+	sk, err := ioutil.ReadFile(skFile)
+	if err != nil {
+		return "", err
+	}
+	hrp, seed, err := bech32.Decode(strings.TrimSpace(string(sk)))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", skFile, err)
+	}
+	if hrp != "ed25519_sk" || len(seed) != ed25519.SeedSize {
+		return "", nil
+	}
+	return bech32.Encode("ed25519_pk", ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey))
+}
+
+// PlannedProposal is a proposal assigned to a voteplan.
+type PlannedProposal struct {
+	Index       uint8  `json:"chain_proposal_index"`
+	InternalID  uint64 `json:"internal_id"`
+	ProposalID  string `json:"proposal_id"`
+	ChallengeID uint32 `json:"challenge_id"`
+	ExternalID  string `json:"chain_proposal_id"`
+}
+
+// PlannedVotePlan is a voteplan that will be generated.
+type PlannedVotePlan struct {
+	Payload      string            `json:"payload"`
+	VoteStart    vresult.ChainTime `json:"vote_start"`
+	VoteEnd      vresult.ChainTime `json:"vote_end"`
+	CommitteeEnd vresult.ChainTime `json:"committee_end"`
+	Proposals    []PlannedProposal `json:"proposals"`
+}
+
+// FundedAccount is an account funded on block0.
+// Account (and PublicKey) are empty when the keys will be generated.
+type FundedAccount struct {
+	Role      string `json:"role"`
+	PublicKey string `json:"public_key"`
+	Account   string `json:"account"`
+	Value     uint64 `json:"value"`
+}
+
+// Plan of what Generate would do with the same Config, built without jcli and without a working directory.
+type Plan struct {
+	Config   Config   `json:"config"` // effective config
+	Schedule Schedule `json:"schedule"`
+
+	FundID    uint64 `json:"fund_id"`
+	FundName  string `json:"fund_name"`
+	Proposals int    `json:"proposals"`
+
+	VotePlans        []PlannedVotePlan  `json:"vote_plans"`
+	Leaders          int                `json:"leaders"`
+	GeneratedLeaders int                `json:"generated_leaders"`
+	FundedAccounts   []FundedAccount    `json:"funded_accounts"`
+	GenesisExtraData *InitialSummary    `json:"genesis_extra_data,omitempty"`
+	VotingPower      *VotingPowerReport `json:"voting_power,omitempty"`
+	PrivacyKeys      int                `json:"privacy_keys"` // 0 with private voteplans means they will be generated
+
+	ProxyAddress      string        `json:"proxy_address"`
+	RestAddress       string        `json:"rest_address"`
+	P2PListenAddress  string        `json:"p2p_listen_address"`
+	VitStationAddress string        `json:"vit_station_address"`
+	Nodes             []PlannedNode `json:"nodes"`
+
+	Warnings []string `json:"warnings"`
+}
+
+// PlannedNode is a network node to be generated.
+type PlannedNode struct {
+	RestAddress      string `json:"rest_address"`
+	P2PListenAddress string `json:"p2p_listen_address"`
+	Leaders          int    `json:"leaders"`
+}
+
+// NewPlan validates cfg and the input CSVs, and computes the voteplans split and schedule.
+func NewPlan(cfg Config) (*Plan, error) {
+	schedule, err := cfg.Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	nodeAddr, nodePort, err := splitAddrPort("node", cfg.Node)
+	if err != nil {
+		return nil, err
+	}
+	for name, addrPort := range map[string]string{"proxy": cfg.Proxy, "rest": cfg.Rest, "vit-station": cfg.VitStation} {
+		if _, _, err = splitAddrPort(name, addrPort); err != nil {
+			return nil, err
+		}
+	}
+
+	plan := &Plan{
+		Config:   cfg,
+		Schedule: *schedule,
+
+		ProxyAddress:      cfg.Proxy,
+		RestAddress:       cfg.Rest,
+		P2PListenAddress:  p2pAddress(nodeAddr, nodePort),
+		VitStationAddress: cfg.VitStation,
+	}
+
+	proposals, err := loadProposals(cfg.Proposals)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", "loadProposals", err)
+	}
+	funds, err := loadFundInfo(cfg.Fund)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", "loadFundInfo", err)
+	}
+	if funds.First() == nil {
+		return nil, fmt.Errorf("%s: [%s] - no fund found", "loadFundInfo", cfg.Fund)
+	}
+	plan.FundID, plan.FundName = funds.First().FundID, funds.First().Name
+	plan.Proposals = proposals.Total()
+
+	plan.Warnings = append(plan.Warnings, checkChallenges(cfg.Challenges, *proposals.All())...)
+
+	/* BFT LEADER(s) */
+
+	leaders, skipped, err := cfg.bftLeaders(func(i int, leader *bftLeader) error {
+		if leader.Generated {
+			return nil
+		}
+		pk, err := publicKeyOf(leader.SecretKeyFile)
+		if err != nil {
+			return err
+		}
+		if pk == "" {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s - not an ed25519_sk, public key will be known only from jcli", leader.SecretKeyFile))
+		}
+		leader.PublicKey = pk
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, pk := range skipped {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("Duplicate BFT Leader skip: %s", pk))
+	}
+
+	// leaders with a secret key, that can run a node
+	var secretLeaders int
+
+	plan.Leaders = len(leaders)
+	for _, leader := range leaders {
+		role := "bft leader (public key)"
+		switch {
+		case leader.Generated:
+			role = "bft leader (generated)"
+			plan.GeneratedLeaders++
+			secretLeaders++
+		case leader.SecretKeyFile != "":
+			role = "bft leader (secret key)"
+			secretLeaders++
+		}
+
+		if cfg.BftLeaderFund > 0 {
+			account := FundedAccount{Role: role, PublicKey: leader.PublicKey, Value: cfg.BftLeaderFund}
+			if leader.PublicKey != "" {
+				acc, err := accountAddress(leader.PublicKey, addressPrefix(cfg.Discrimination), cfg.Discrimination)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", role, err)
+				}
+				account.Account = acc
+			}
+			plan.FundedAccounts = append(plan.FundedAccounts, account)
+		}
+	}
+
+	/* STAKE POOL(s) */
+
+	// nodes run the stake pools with genesis_praos
+	nodesSecrets := secretLeaders
+	if cfg.Consensus == "genesis_praos" {
+		nodesSecrets = int(cfg.StakePools)
+		for i := 0; i < nodesSecrets; i++ {
+			plan.FundedAccounts = append(plan.FundedAccounts, FundedAccount{Role: "stake pool owner (generated)", Value: cfg.StakePoolFund})
+		}
+	}
+
+	/* NODE(s) */
+
+	plan.Nodes, err = cfg.planNodes(nodesSecrets)
+	if err != nil {
+		return nil, err
+	}
+
+	// Global Committee Members list
+	committeeKeys, skipped := cfg.committeeKeys(leaders)
+	for _, pk := range skipped {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("Duplicate Committee member, skip: %s", pk))
+	}
+	if cfg.CommitteeAuthFund > 0 {
+		for _, pk := range committeeKeys {
+			acc, err := accountAddress(pk, addressPrefix(cfg.Discrimination), cfg.Discrimination)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", "committee-auth-public-key", err)
+			}
+			plan.FundedAccounts = append(plan.FundedAccounts, FundedAccount{Role: "committee", PublicKey: pk, Account: acc, Value: cfg.CommitteeAuthFund})
+		}
+	}
+
+	// Voteplans
+	votePlans, err := cfg.planVotePlans(*proposals.All(), schedule, secretLeaders)
+	if err != nil {
+		return nil, err
+	}
+	plan.PrivacyKeys = len(cfg.CommitteePrivacyPublicKeys)
+
+	byExternalID := make(map[string]*loader.ProposalData, proposals.Total())
+	for _, p := range *proposals.All() {
+		byExternalID[p.ChainProposal.ExternalID] = p
+	}
+	for _, vp := range votePlans {
+		planned := PlannedVotePlan{
+			Payload:      vp.Payload,
+			VoteStart:    vp.VoteStart,
+			VoteEnd:      vp.VoteEnd,
+			CommitteeEnd: vp.CommitteeEnd,
+		}
+		for i, vpp := range vp.Proposals {
+			p := byExternalID[vpp.ExternalID]
+			planned.Proposals = append(planned.Proposals, PlannedProposal{
+				Index:       uint8(i),
+				InternalID:  p.InternalID,
+				ProposalID:  p.Proposal.ID,
+				ChallengeID: p.ChallengeID,
+				ExternalID:  vpp.ExternalID,
+			})
+		}
+		plan.VotePlans = append(plan.VotePlans, planned)
+	}
+
+	// duplicates against the accounts already known, the generated ones are unique
+	var initial []jnode.BlockchainInitial
+	for _, fa := range plan.FundedAccounts {
+		if fa.Account != "" {
+			initial = append(initial, jnode.BlockchainInitial{Fund: []jnode.InitialFund{{Address: fa.Account, Value: fa.Value}}})
+		}
+	}
+	if cfg.GenesisExtraData != "" {
+		extraData, err := loadGenesisExtraData(cfg.GenesisExtraData, cfg.Discrimination)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", "genesis-extra-data", err)
+		}
+		plan.GenesisExtraData = &extraData.Summary
+		initial = append(initial, extraData.Initial...)
+	}
+	if cfg.VotingPowerSnapshot != "" {
+		plan.VotingPower, err = importVotingPower(cfg.VotingPowerSnapshot, cfg.Discrimination, uint64(funds.First().VotingPowerThreshold))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", "voting-power-snapshot", err)
+		}
+		initial = append(initial, plan.VotingPower.initial()...)
+	}
+	err = checkInitialFunds(initial)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", "block0 initial", err)
+	}
+
+	return plan, nil
+}
+
+// checkChallenges reports the proposals challenge ids missing from the challenges CSV ("id" column).
+func checkChallenges(file string, proposals []*loader.ProposalData) []string {
+	cf, err := os.Open(file)
+	if err != nil {
+		return []string{fmt.Sprintf("%s - %v (needed only by vit-servicing-station-cli)", "challenges", err)}
+	}
+	defer cf.Close()
+
+	records, err := csv.NewReader(cf).ReadAll()
+	if err != nil || len(records) == 0 {
+		return []string{fmt.Sprintf("%s - %s: can't be read as CSV %v", "challenges", file, err)}
+	}
+
+	idCol := -1
+	for i, col := range records[0] {
+		if col == "id" {
+			idCol = i
+		}
+	}
+	if idCol < 0 {
+		return []string{fmt.Sprintf("%s - %s: no [%s] column found", "challenges", file, "id")}
+	}
+
+	challenges := make(map[string]bool, len(records))
+	for _, rec := range records[1:] {
+		challenges[rec[idCol]] = true
+	}
+
+	var warnings []string
+	missing := make(map[uint32]bool)
+	for _, p := range proposals {
+		if !challenges[strconv.FormatUint(uint64(p.ChallengeID), 10)] && !missing[p.ChallengeID] {
+			missing[p.ChallengeID] = true
+			warnings = append(warnings, fmt.Sprintf("%s - challenge_id [%d] used by proposal [%d] not found in %s", "challenges", p.ChallengeID, p.InternalID, file))
+		}
+	}
+	return warnings
+}
+
+// Print the plan in a human readable format.
+func (plan *Plan) Print(w io.Writer) {
+	s := &plan.Schedule
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "VIT - DRY RUN PLAN\n\n")
+
+	fmt.Fprintf(tw, "SCHEDULE\n")
+	fmt.Fprintf(tw, "  genesis\t%s\tslot %s, epoch %s (%d slots)\n", s.GenesisTime.Format(plan.Config.TimeFormat), s.SlotDuration, s.EpochDuration, s.SlotsPerEpoch())
+	fmt.Fprintf(tw, "  vote start\t%s\t%s\n", s.VoteStartTime.Format(plan.Config.TimeFormat), s.VoteStart)
+	fmt.Fprintf(tw, "  vote end\t%s\t%s\n", s.VoteEndTime.Format(plan.Config.TimeFormat), s.VoteEnd)
+	fmt.Fprintf(tw, "  committee end\t%s\t%s\n", s.CommitteeEndTime.Format(plan.Config.TimeFormat), s.CommitteeEnd)
+	fmt.Fprintf(tw, "\n")
+
+	fmt.Fprintf(tw, "PORTS\n")
+	fmt.Fprintf(tw, "  proxy\t%s\n", plan.ProxyAddress)
+	if len(plan.Nodes) > 1 {
+		for i, node := range plan.Nodes {
+			fmt.Fprintf(tw, "  node_%d rest\t%s\tleaders: %d\n", i, node.RestAddress, node.Leaders)
+			fmt.Fprintf(tw, "  node_%d p2p\t%s\n", i, node.P2PListenAddress)
+		}
+		fmt.Fprintf(tw, "  proxy target\tnode_%d\n", plan.Config.ProxyNode)
+	} else {
+		fmt.Fprintf(tw, "  node rest\t%s\n", plan.RestAddress)
+		fmt.Fprintf(tw, "  node p2p\t%s\n", plan.P2PListenAddress)
+	}
+	fmt.Fprintf(tw, "  vit station\t%s\n", plan.VitStationAddress)
+	fmt.Fprintf(tw, "\n")
+
+	fmt.Fprintf(tw, "FUND\n")
+	fmt.Fprintf(tw, "  %s (id: %d)\tproposals: %d\tvoteplans: %d\n", plan.FundName, plan.FundID, plan.Proposals, len(plan.VotePlans))
+	fmt.Fprintf(tw, "\n")
+
+	fmt.Fprintf(tw, "BFT LEADERS\n")
+	fmt.Fprintf(tw, "  total: %d\tgenerated: %d\tblock0 voteplans: %v\n", plan.Leaders, plan.GeneratedLeaders, plan.Config.Block0VotePlan)
+	if plan.Config.SubmitVotePlans {
+		fmt.Fprintf(tw, "  voteplans\tsubmitted as transactions once the node is up\n")
+	}
+	fmt.Fprintf(tw, "  discrimination\t%s\taccounts prefix: %s\n", plan.Config.Discrimination, addressPrefix(plan.Config.Discrimination))
+	if plan.Config.Consensus == "genesis_praos" {
+		fmt.Fprintf(tw, "  consensus\tgenesis_praos\tstake pools: %d (active slot coeff %v, kes update speed %ds)\n", plan.Config.StakePools, plan.Config.PraosActiveSlotCoeff, plan.Config.KesUpdateSpeed)
+	}
+	switch {
+	case plan.Config.KeyDir != "" && plan.Config.KeySeed != "":
+		fmt.Fprintf(tw, "  generated keys\tkey dir %s, seeded\n", plan.Config.KeyDir)
+	case plan.Config.KeyDir != "":
+		fmt.Fprintf(tw, "  generated keys\tkey dir %s\n", plan.Config.KeyDir)
+	case plan.Config.KeySeed != "":
+		fmt.Fprintf(tw, "  generated keys\tseeded\n")
+	}
+	fmt.Fprintf(tw, "\n")
+	_ = tw.Flush()
+
+	for i, vp := range plan.VotePlans {
+		fmt.Fprintf(tw, "VOTEPLAN %d - %s - %d proposals - vote %s -> %s - committee end %s\n", i+1, vp.Payload, len(vp.Proposals), vp.VoteStart, vp.VoteEnd, vp.CommitteeEnd)
+		if vp.Payload == "private" {
+			if plan.PrivacyKeys > 0 {
+				fmt.Fprintf(tw, "  privacy committee keys: %d\n", plan.PrivacyKeys)
+			} else {
+				fmt.Fprintf(tw, "  privacy committee keys: %d (generated, threshold %d)\n", plan.Config.CommitteePrivacyMembers, plan.Config.CommitteePrivacyThreshold)
+			}
+		}
+		fmt.Fprintf(tw, "  index\tinternal_id\tproposal_id\tchallenge_id\tchain_proposal_id\n")
+		for _, p := range vp.Proposals {
+			fmt.Fprintf(tw, "  %d\t%d\t%s\t%d\t%s\n", p.Index, p.InternalID, p.ProposalID, p.ChallengeID, p.ExternalID)
+		}
+		fmt.Fprintf(tw, "\n")
+		_ = tw.Flush()
+	}
+
+	fmt.Fprintf(tw, "FUNDED ACCOUNTS (block0)\n")
+	if len(plan.FundedAccounts) == 0 {
+		fmt.Fprintf(tw, "  none\n")
+	}
+	for _, fa := range plan.FundedAccounts {
+		account := fa.Account
+		if account == "" {
+			account = "<generated>"
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%d\n", fa.Role, account, fa.Value)
+	}
+	if plan.Config.GenesisExtraData != "" {
+		fmt.Fprintf(tw, "  extra genesis data\t%s\t%s\n", plan.Config.GenesisExtraData, plan.GenesisExtraData)
+	}
+	if plan.VotingPower != nil {
+		fmt.Fprintf(tw, "  voting power snapshot\t%s\t%s\n", plan.Config.VotingPowerSnapshot, plan.VotingPower)
+	}
+	if plan.Config.Voters > 0 {
+		if plan.Config.VoterFundMax > plan.Config.VoterFund {
+			fmt.Fprintf(tw, "  test voters (generated)\t%d\t%d - %d (random)\n", plan.Config.Voters, plan.Config.VoterFund, plan.Config.VoterFundMax)
+		} else {
+			fmt.Fprintf(tw, "  test voters (generated)\t%d\t%d\n", plan.Config.Voters, plan.Config.VoterFund)
+		}
+	}
+	fmt.Fprintf(tw, "\n")
+
+	if len(plan.Warnings) > 0 {
+		fmt.Fprintf(tw, "WARNINGS\n")
+		for _, warn := range plan.Warnings {
+			fmt.Fprintf(tw, "  %s\n", warn)
+		}
+		fmt.Fprintf(tw, "\n")
+	}
+
+	_ = tw.Flush()
+}
@@ -0,0 +1,371 @@
+package vitenv
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/input-output-hk/jorvit/internal/loader"
+	"github.com/rinor/jorcli/jcli"
+	"github.com/rinor/jorcli/jnode"
+)
+
+func writeTestFile(t *testing.T, dir string, name string, content string) string {
+	t.Helper()
+	file := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+const testFundCSV = "id,fund_name,fund_goal,voting_power_threshold\n" +
+	"3,Fund3,test goal,450\n"
+
+// testProposalsCSV returns n proposals, internal id from 1, alternating the payload when private.
+func testProposalsCSV(n int, private bool, challengeID int) string {
+	var b strings.Builder
+	b.WriteString("internal_id,proposal_id,proposal_title,challenge_id,chain_vote_options,chain_vote_type\n")
+	for i := 1; i <= n; i++ {
+		payload := "public"
+		if private && i%2 == 0 {
+			payload = "private"
+		}
+		b.WriteString(strconv.Itoa(i) + "," + strconv.Itoa(1000+i) + ",proposal " + strconv.Itoa(i) + "," + strconv.Itoa(challengeID) + ",\"blank,yes,no\"," + payload + "\n")
+	}
+	return b.String()
+}
+
+func testPlanConfig(t *testing.T, proposals string) Config {
+	t.Helper()
+	dir := t.TempDir()
+
+	cfg := DefaultConfig()
+	cfg.Proposals = writeTestFile(t, dir, "proposals.csv", proposals)
+	cfg.Fund = writeTestFile(t, dir, "fund.csv", testFundCSV)
+	cfg.Challenges = writeTestFile(t, dir, "challenges.csv", "id,title\n1,challenge 1\n")
+	cfg.GenesisExtraData = ""
+	cfg.GenesisTime = "2021-01-01T00:00:00Z"
+	cfg.VoteStart = "2021-01-02T00:00:00Z"
+	cfg.VoteDuration = "48h"
+	cfg.CommitteeDuration = "24h"
+	cfg.Discrimination = DiscriminationTesting
+	return cfg
+}
+
+func TestNewPlan(t *testing.T) {
+	cfg := testPlanConfig(t, testProposalsCSV(5, true, 1))
+	cfg.VotePlanProposalsMax = 2
+	cfg.BftLeaderFund = 100
+	cfg.BftLeaderMin = 2
+
+	plan, err := NewPlan(cfg)
+	if err != nil {
+		t.Fatalf("NewPlan: %v", err)
+	}
+
+	if plan.FundID != 3 || plan.FundName != "Fund3" || plan.Proposals != 5 {
+		t.Errorf("fund: got (%d, %s, %d proposals)", plan.FundID, plan.FundName, plan.Proposals)
+	}
+	if got := [3]string{plan.Schedule.VoteStart.String(), plan.Schedule.VoteEnd.String(), plan.Schedule.CommitteeEnd.String()}; got != [3]string{"1.0", "3.0", "4.0"} {
+		t.Errorf("schedule: got %v", got)
+	}
+	if plan.Leaders != 2 || plan.GeneratedLeaders != 2 || len(plan.FundedAccounts) != 2 {
+		t.Errorf("leaders: got %d (%d generated), %d funded accounts", plan.Leaders, plan.GeneratedLeaders, len(plan.FundedAccounts))
+	}
+	if len(plan.Warnings) != 0 {
+		t.Errorf("warnings: got %v", plan.Warnings)
+	}
+
+	// private: 2, 4 - public: 1, 3 and 5
+	want := []struct {
+		payload   string
+		proposals []uint64
+	}{
+		{"private", []uint64{2, 4}},
+		{"public", []uint64{1, 3}},
+		{"public", []uint64{5}},
+	}
+	if len(plan.VotePlans) != len(want) {
+		t.Fatalf("voteplans: got %d, want %d", len(plan.VotePlans), len(want))
+	}
+	for i, w := range want {
+		vp := plan.VotePlans[i]
+		if vp.Payload != w.payload || len(vp.Proposals) != len(w.proposals) {
+			t.Errorf("voteplan %d: got %s with %d proposals, want %s with %d", i, vp.Payload, len(vp.Proposals), w.payload, len(w.proposals))
+			continue
+		}
+		if vp.VoteStart != plan.Schedule.VoteStart || vp.VoteEnd != plan.Schedule.VoteEnd || vp.CommitteeEnd != plan.Schedule.CommitteeEnd {
+			t.Errorf("voteplan %d: got times %s %s %s", i, vp.VoteStart, vp.VoteEnd, vp.CommitteeEnd)
+		}
+		for j, p := range vp.Proposals {
+			if p.Index != uint8(j) || p.InternalID != w.proposals[j] || p.ProposalID != strconv.FormatUint(1000+w.proposals[j], 10) || p.ChallengeID != 1 || p.ExternalID == "" {
+				t.Errorf("voteplan %d proposal %d: got %+v", i, j, p)
+			}
+		}
+	}
+}
+
+func TestNewPlanErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		csv    string
+		modify func(cfg *Config)
+		errMsg string
+	}{
+		{
+			name:   "duplicate internal id",
+			csv:    testProposalsCSV(2, false, 1) + "2,1002,proposal 2,1,\"blank,yes,no\",public\n",
+			errMsg: "duplicate internal_id [2]",
+		},
+		{
+			name:   "privacy keys without private proposals",
+			csv:    testProposalsCSV(2, false, 1),
+			modify: func(cfg *Config) { cfg.CommitteePrivacyPublicKeys = []string{"key"} },
+			errMsg: "no private proposals found",
+		},
+		{
+			name:   "proxy node out of range",
+			csv:    testProposalsCSV(2, false, 1),
+			modify: func(cfg *Config) { cfg.MultiNode, cfg.ProxyNode = true, 1 },
+			errMsg: "[proxy-node: 1] - wrong value, 1 node(s) available",
+		},
+		{
+			name:   "same port",
+			csv:    testProposalsCSV(2, false, 1),
+			modify: func(cfg *Config) { cfg.VitStation = "0.0.0.0:8000" },
+			errMsg: "port already used",
+		},
+		{
+			name:   "wrong node address",
+			csv:    testProposalsCSV(2, false, 1),
+			modify: func(cfg *Config) { cfg.Node = "127.0.0.1" },
+			errMsg: "[node: 127.0.0.1] - wrong value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testPlanConfig(t, tt.csv)
+			if tt.modify != nil {
+				tt.modify(&cfg)
+			}
+			_, err := NewPlan(cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Fatalf("NewPlan: got %v, want error containing %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestCheckChallenges(t *testing.T) {
+	dir := t.TempDir()
+	proposals := []*loader.ProposalData{
+		{InternalID: 1, ChallengeID: 1},
+		{InternalID: 2, ChallengeID: 2},
+		{InternalID: 3, ChallengeID: 3},
+		{InternalID: 4, ChallengeID: 3},
+	}
+
+	tests := []struct {
+		name     string
+		file     string
+		warnings []string
+	}{
+		{
+			name: "all found",
+			file: writeTestFile(t, dir, "all.csv", "title,id\nc1,1\nc2,2\nc3,3\n"),
+		},
+		{
+			name:     "missing reported once",
+			file:     writeTestFile(t, dir, "missing.csv", "id,title\n1,c1\n"),
+			warnings: []string{"challenge_id [2] used by proposal [2]", "challenge_id [3] used by proposal [3]"},
+		},
+		{
+			name:     "no id column",
+			file:     writeTestFile(t, dir, "noid.csv", "challenge_id,title\n1,c1\n"),
+			warnings: []string{"no [id] column found"},
+		},
+		{
+			name:     "not a csv",
+			file:     writeTestFile(t, dir, "wrong.csv", "id,title\n1,\"c1\n"),
+			warnings: []string{"can't be read as CSV"},
+		},
+		{
+			name:     "no file",
+			file:     filepath.Join(dir, "none.csv"),
+			warnings: []string{"needed only by vit-servicing-station-cli"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings := checkChallenges(tt.file, proposals)
+			if len(warnings) != len(tt.warnings) {
+				t.Fatalf("checkChallenges: got %v, want %d warnings", warnings, len(tt.warnings))
+			}
+			for i, w := range tt.warnings {
+				if !strings.Contains(warnings[i], w) {
+					t.Errorf("warning %d: got %q, want %q", i, warnings[i], w)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitVotePlans(t *testing.T) {
+	payloadProposals := make(map[string][]*loader.ProposalData)
+	for i, payload := range []string{"public", "public", "public", "private", "private", "other"} {
+		p := &loader.ProposalData{InternalID: uint64(i + 1), ChallengeID: 7}
+		p.VoteType = payload
+		p.ChainProposal.VoteOptions = loader.ChainVoteOptions{"blank": 0, "yes": 1, "no": 2}
+		payloadProposals[payload] = append(payloadProposals[payload], p)
+	}
+
+	tests := []struct {
+		max      int
+		payloads []string
+		sizes    []int
+	}{
+		{max: 255, payloads: []string{"other", "private", "public"}, sizes: []int{1, 2, 3}},
+		{max: 2, payloads: []string{"other", "private", "public", "public"}, sizes: []int{1, 2, 2, 1}},
+		{max: 1, payloads: []string{"other", "private", "private", "public", "public", "public"}, sizes: []int{1, 1, 1, 1, 1, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.max), func(t *testing.T) {
+			votePlans := splitVotePlans(payloadProposals, tt.max)
+			if len(votePlans) != len(tt.payloads) {
+				t.Fatalf("splitVotePlans: got %d voteplans, want %d", len(votePlans), len(tt.payloads))
+			}
+			externalIDs := make(map[string]bool)
+			for i, vp := range votePlans {
+				if vp.Payload != tt.payloads[i] || len(vp.Proposals) != tt.sizes[i] {
+					t.Errorf("voteplan %d: got %s with %d proposals, want %s with %d", i, vp.Payload, len(vp.Proposals), tt.payloads[i], tt.sizes[i])
+				}
+				for _, p := range vp.Proposals {
+					if p.Options != 3 || p.ChallengeID != 7 || externalIDs[p.ExternalID] {
+						t.Errorf("voteplan %d: got proposal %+v", i, p)
+					}
+					externalIDs[p.ExternalID] = true
+				}
+			}
+		})
+	}
+
+	// the external id only depends on the proposal
+	first := payloadProposals["public"][0].ChainProposal.ExternalID
+	splitVotePlans(payloadProposals, 255)
+	if payloadProposals["public"][0].ChainProposal.ExternalID != first || len(first) != 64 {
+		t.Errorf("external id: got %s, want %s", payloadProposals["public"][0].ChainProposal.ExternalID, first)
+	}
+}
+
+// fakeJcliGenerate answers the jcli commands used by Generate, keys and ids are unique per call.
+const fakeJcliGenerate = `#!/bin/sh
+out=""
+prev=""
+for arg in "$@"; do
+	[ "$prev" = "--output" ] && out="$arg"
+	prev="$arg"
+done
+case "$1 $2" in
+"key generate") echo "sk_$$" ;;
+"key to-public") read sk; echo "pk_$sk" ;;
+"address account") echo "ta1_$3" ;;
+"certificate show") read cert; echo "id_$cert" ;;
+"certificate new") cat > /dev/null; echo "cert_$$" ;;
+"genesis hash") echo "block0_hash" ;;
+"genesis encode"|"genesis decode") cat > /dev/null; echo "$2" > "$out" ;;
+*) cat > /dev/null; echo "$1" ;;
+esac
+`
+
+// useFakeBins puts a fake jcli and jormungandr first in PATH, Generate looks them up there.
+func useFakeBins(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake jcli is a shell script")
+	}
+	dir := t.TempDir()
+	for name, script := range map[string]string{"jcli": fakeJcliGenerate, "jormungandr": "#!/bin/sh\necho jormungandr\n"} {
+		if err := os.Chmod(writeTestFile(t, dir, name, script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := os.Getenv("PATH")
+	if err := os.Setenv("PATH", dir+string(os.PathListSeparator)+path); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = os.Setenv("PATH", path)
+		jcli.BinName("jcli")
+		jnode.BinName("jormungandr")
+	})
+}
+
+func TestNewPlanGenerate(t *testing.T) {
+	useFakeBins(t)
+
+	cfg := testPlanConfig(t, testProposalsCSV(5, true, 1))
+	cfg.BaseDir = t.TempDir()
+	cfg.VotePlanProposalsMax = 2
+	cfg.BftLeaderFund = 100
+	cfg.BftLeaderMin = 3
+	cfg.BftLeaderPublicKeys = []string{testPublicKey}
+	cfg.CommitteePrivacyPublicKeys = []string{"member_pk"}
+	cfg.Block0VotePlan = true
+	cfg.MultiNode = true
+
+	plan, err := NewPlan(cfg)
+	if err != nil {
+		t.Fatalf("NewPlan: %v", err)
+	}
+	env, err := Generate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	if !reflect.DeepEqual(plan.Schedule, env.Schedule) {
+		t.Errorf("schedule: got %+v, want %+v", env.Schedule, plan.Schedule)
+	}
+	if len(env.Leaders) != plan.Leaders || plan.GeneratedLeaders != 2 {
+		t.Errorf("leaders: got %d, plan %d (%d generated)", len(env.Leaders), plan.Leaders, plan.GeneratedLeaders)
+	}
+
+	if len(env.Nodes) != len(plan.Nodes) {
+		t.Fatalf("nodes: got %d, plan %d", len(env.Nodes), len(plan.Nodes))
+	}
+	for i, p := range plan.Nodes {
+		n := env.Nodes[i]
+		if n.RestAddress != p.RestAddress || n.P2PListenAddress != p.P2PListenAddress || len(n.SecretConfigFiles) != p.Leaders {
+			t.Errorf("node_%d: got (%s, %s, %d leaders), plan %+v", i, n.RestAddress, n.P2PListenAddress, len(n.SecretConfigFiles), p)
+		}
+	}
+
+	if len(env.VotePlans) != len(plan.VotePlans) {
+		t.Fatalf("voteplans: got %d, plan %d", len(env.VotePlans), len(plan.VotePlans))
+	}
+	proposals := make(map[uint64]*loader.ProposalData)
+	for _, p := range *env.Proposals.All() {
+		proposals[p.InternalID] = p
+	}
+	for i, p := range plan.VotePlans {
+		vp := env.VotePlans[i]
+		if vp.Payload != p.Payload || vp.Proposals != len(p.Proposals) || vp.VoteStart != p.VoteStart || vp.VoteEnd != p.VoteEnd || vp.CommitteeEnd != p.CommitteeEnd {
+			t.Errorf("voteplan %d: got %+v, plan %+v", i, vp, p)
+		}
+		for _, pp := range p.Proposals {
+			gp := proposals[pp.InternalID]
+			if gp == nil || gp.ChainProposal.ExternalID != pp.ExternalID || gp.ChainProposal.Index != pp.Index || gp.ChainVotePlan.VotePlanID != vp.ID {
+				t.Errorf("voteplan %d proposal %d: got %+v, plan %+v", i, pp.InternalID, gp, pp)
+			}
+		}
+	}
+}
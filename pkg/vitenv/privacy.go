@@ -0,0 +1,130 @@
+package vitenv
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/input-output-hk/jorvit/internal/kit"
+	"github.com/rinor/jorcli/jcli"
+)
+
+// VoteEncryptionKeyFile within the voteplans dir, with the private voteplans vote encryption key
+const VoteEncryptionKeyFile = "vote_encryption_key.pk"
+
+// PrivacyCommittee keys used to build the private voteplans vote encryption key.
+// The crs and members files are set only when the keys are generated.
+type PrivacyCommittee struct {
+	CRSFile               string          `json:"crs_file,omitempty"`
+	Threshold             uint            `json:"threshold,omitempty"`
+	Members               []PrivacyMember `json:"members,omitempty"`
+	MemberPublicKeys      []string        `json:"member_public_keys"`
+	VoteEncryptionKey     string          `json:"vote_encryption_key,omitempty"`
+	VoteEncryptionKeyFile string          `json:"vote_encryption_key_file,omitempty"`
+}
+
+// PrivacyMember is a generated privacy committee member, with its keys dumped in its own dir.
+type PrivacyMember struct {
+	Index               int    `json:"index"`
+	Dir                 string `json:"dir"`
+	CommunicationSKFile string `json:"communication_sk_file"`
+	CommunicationPKFile string `json:"communication_pk_file"`
+	MemberSKFile        string `json:"member_sk_file"`
+	MemberPKFile        string `json:"member_pk_file"`
+	MemberPublicKey     string `json:"member_public_key"`
+}
+
+// privacyMemberDir of the i-th privacy committee member, within the voteplans dir.
+func privacyMemberDir(votePlanDir string, i int) string {
+	return filepath.Join(votePlanDir, "committee_member_"+strconv.Itoa(i))
+}
+
+// generatePrivacyCommittee runs the privacy committee key ceremony, with Config.CommitteePrivacyMembers members
+// and Config.CommitteePrivacyThreshold threshold:
+//   - a shared crs, dumped in the voteplans dir
+//   - a communication key for each member
+//   - a member key for each member, built from the crs and all the communication public keys
+//
+// Each member keys are dumped in its own dir (vote_plans/committee_member_N).
+func (env *Environment) generatePrivacyCommittee() error {
+	cfg := &env.Config
+
+	env.Privacy.CRSFile = filepath.Join(env.VotePlanDir, "committee.crs")
+	crs, err := env.generateKey("committee.crs", func(seed string) ([]byte, error) {
+		return jcli.VotesCRSGenerate(seed, "")
+	})
+	if err != nil {
+		return err
+	}
+	err = writeFile(env.Privacy.CRSFile, crs, 0644)
+	if err != nil {
+		return err
+	}
+
+	env.Privacy.Threshold = cfg.CommitteePrivacyThreshold
+	env.Privacy.Members = make([]PrivacyMember, cfg.CommitteePrivacyMembers)
+
+	// communication keys, all of them are needed to build each member key
+	commPKs := make([]string, len(env.Privacy.Members))
+	for i := range env.Privacy.Members {
+		member := &env.Privacy.Members[i]
+		member.Index = i
+		member.Dir = privacyMemberDir(env.VotePlanDir, i)
+		err = os.Mkdir(member.Dir, 0755)
+		if err != nil {
+			return kit.ErrorOn(err, "privacyMemberDir")
+		}
+
+		member.CommunicationSKFile = filepath.Join(member.Dir, "communication_key.sk")
+		member.CommunicationPKFile = filepath.Join(member.Dir, "communication_key.pk")
+
+		commSK, err := env.generateKey("committee_member_"+strconv.Itoa(i)+"_communication_key.sk", func(seed string) ([]byte, error) {
+			return jcli.VotesCommitteeCommunicationKeyGenerate(seed, "")
+		})
+		if err != nil {
+			return err
+		}
+		err = writeFile(member.CommunicationSKFile, commSK, 0600)
+		if err != nil {
+			return err
+		}
+		commPK, err := jcli.VotesCommitteeCommunicationKeyToPublic(nil, member.CommunicationSKFile, member.CommunicationPKFile)
+		if err != nil {
+			return kit.ErrorOn(err, "jcli.VotesCommitteeCommunicationKeyToPublic", kit.B2S(commPK))
+		}
+		commPKs[i] = kit.B2S(commPK)
+	}
+
+	// member keys
+	for i := range env.Privacy.Members {
+		member := &env.Privacy.Members[i]
+
+		member.MemberSKFile = filepath.Join(member.Dir, "member_key.sk")
+		member.MemberPKFile = filepath.Join(member.Dir, "member_key.pk")
+
+		memberSK, err := env.generateKey("committee_member_"+strconv.Itoa(i)+"_member_key.sk", func(seed string) ([]byte, error) {
+			return jcli.VotesCommitteeMemberKeyGenerate(kit.B2S(crs), uint8(cfg.CommitteePrivacyThreshold), commPKs, uint8(i), seed, "" /* memberSKFile */)
+		})
+		if err != nil {
+			return err
+		}
+		memberPK, err := jcli.VotesCommitteeMemberKeyToPublic(memberSK, "", "")
+		if err != nil {
+			return kit.ErrorOn(err, "jcli.VotesCommitteeMemberKeyToPublic", kit.B2S(memberPK))
+		}
+
+		err = writeFile(member.MemberSKFile, memberSK, 0600)
+		if err != nil {
+			return err
+		}
+		err = writeFile(member.MemberPKFile, memberPK, 0644)
+		if err != nil {
+			return err
+		}
+		member.MemberPublicKey = kit.B2S(memberPK)
+
+		env.Privacy.MemberPublicKeys = append(env.Privacy.MemberPublicKeys, member.MemberPublicKey)
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package vitenv
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/rinor/jorcli/jcli"
+)
+
+// fakeJcliVotes answers the jcli votes commands used by the privacy committee key ceremony.
+const fakeJcliVotes = `#!/bin/sh
+case "$1 $2 $3 $4" in
+"votes crs generate"*) echo "crs_generated" ;;
+"votes committee communication-key generate") echo "comm_sk" ;;
+"votes committee communication-key to-public") echo "comm_pk" > "$7"; echo "comm_pk" ;;
+"votes committee member-key generate") echo "member_sk crs=$6 threshold=$8 index=${10}" ;;
+"votes committee member-key to-public") echo "member_pk" ;;
+*) exit 1 ;;
+esac
+`
+
+func useFakeJcli(t *testing.T, script string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake jcli is a shell script")
+	}
+	bin := writeTestFile(t, t.TempDir(), "jcli", script)
+	if err := os.Chmod(bin, 0755); err != nil {
+		t.Fatal(err)
+	}
+	jcli.BinName(bin)
+	t.Cleanup(func() { jcli.BinName("jcli") })
+}
+
+func TestGeneratePrivacyCommittee(t *testing.T) {
+	useFakeJcli(t, fakeJcliVotes)
+
+	tests := []struct {
+		name string
+		crs  string // already in the key dir
+		want string
+	}{
+		{name: "generated", want: "crs_generated"},
+		{name: "from key dir", crs: "crs_from_key_dir", want: "crs_from_key_dir"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyDir := t.TempDir()
+			if tt.crs != "" {
+				writeTestFile(t, keyDir, "committee.crs", tt.crs)
+			}
+			env := &Environment{VotePlanDir: t.TempDir()}
+			env.Config.KeyDir = keyDir
+			env.Config.CommitteePrivacyMembers = 3
+			env.Config.CommitteePrivacyThreshold = 2
+
+			if err := env.generatePrivacyCommittee(); err != nil {
+				t.Fatalf("generatePrivacyCommittee: %v", err)
+			}
+
+			if env.Privacy.CRSFile != filepath.Join(env.VotePlanDir, "committee.crs") {
+				t.Errorf("crs file: got %s", env.Privacy.CRSFile)
+			}
+			for _, file := range []string{env.Privacy.CRSFile, filepath.Join(keyDir, "committee.crs")} {
+				if crs, err := ioutil.ReadFile(file); err != nil || strings.TrimSpace(string(crs)) != tt.want {
+					t.Errorf("%s: got %q (%v), want %q", file, crs, err, tt.want)
+				}
+			}
+
+			if env.Privacy.Threshold != 2 || len(env.Privacy.Members) != 3 || len(env.Privacy.MemberPublicKeys) != 3 {
+				t.Fatalf("privacy: got %+v", env.Privacy)
+			}
+			for i, member := range env.Privacy.Members {
+				if member.Dir != privacyMemberDir(env.VotePlanDir, i) || member.MemberPublicKey != "member_pk" {
+					t.Errorf("member %d: got %+v", i, member)
+				}
+				// each member key is built from the shared crs
+				sk, err := ioutil.ReadFile(member.MemberSKFile)
+				want := "member_sk crs=" + tt.want + " threshold=2 index=" + strconv.Itoa(i)
+				if err != nil || strings.TrimSpace(string(sk)) != want {
+					t.Errorf("member %d sk: got %q (%v), want %q", i, sk, err, want)
+				}
+				if pk, err := ioutil.ReadFile(member.CommunicationPKFile); err != nil || strings.TrimSpace(string(pk)) != "comm_pk" {
+					t.Errorf("member %d communication pk: got %q (%v)", i, pk, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,313 @@
+package vitenv
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/input-output-hk/jorvit/internal/datastore"
+	"github.com/input-output-hk/jorvit/internal/kit"
+	"github.com/input-output-hk/jorvit/internal/loader"
+	"github.com/input-output-hk/jorvit/pkg/vstation"
+	"github.com/rinor/jorcli/jcli"
+	"github.com/rinor/jorcli/jnode"
+	"gopkg.in/yaml.v2"
+)
+
+// nodeConfigFile are the node config settings needed to resume the node.
+type nodeConfigFile struct {
+	Storage     string   `yaml:"storage"`
+	SecretFiles []string `yaml:"secret_files"`
+	Rest        struct {
+		Listen string `yaml:"listen"`
+	} `yaml:"rest"`
+	P2P struct {
+		ListenAddress string `yaml:"listen_address"`
+	} `yaml:"p2p"`
+}
+
+// block0TxtFile are the block0 settings needed to resume the environment info.
+type block0TxtFile struct {
+	BlockchainConfiguration struct {
+		ConsensusLeaderIds []string `yaml:"consensus_leader_ids"`
+		Committees         []string `yaml:"committees"`
+	} `yaml:"blockchain_configuration"`
+}
+
+// readYaml decodes the yaml file into out.
+func readYaml(file string, out interface{}) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	err = yaml.Unmarshal(data, out)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	return nil
+}
+
+// Resume loads the VIT environment previously generated in dir, to be started again (see Start)
+// on the existing node storage. Keys, block0 and configs are reused as they are, nothing is regenerated.
+func Resume(dir string) (*Environment, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	env := &Environment{
+		Config:        DefaultConfig(),
+		WorkingDir:    dir,
+		VotePlanDir:   filepath.Join(dir, VotePlanDir),
+		VitStationDir: filepath.Join(dir, VitStationDir),
+		ConfigFile:    filepath.Join(dir, ConfigFile),
+
+		Block0BinFile: filepath.Join(dir, Block0BinFile),
+		Block0TxtFile: filepath.Join(dir, Block0TxtFile),
+	}
+	env.FundsCsvFile = filepath.Join(env.VitStationDir, FundsCsvFile)
+	env.VotePlansCsvFile = filepath.Join(env.VitStationDir, VotePlansCsvFile)
+	env.ProposalsCsvFile = filepath.Join(env.VitStationDir, ProposalsCsvFile)
+	env.VitConfigFile = filepath.Join(env.VitStationDir, VitConfigFile)
+
+	// effective config used to generate the environment
+	err = readYaml(env.ConfigFile, &env.Config)
+	if err != nil {
+		return nil, kit.ErrorOn(err, "config")
+	}
+	// the genesis and vote times are already resolved, only the schedule is needed.
+	// The leaders keys are not used again, so no need for them to be still around.
+	cfg := env.Config
+	cfg.BftLeaderSecretKeys = nil
+	schedule, err := cfg.Resolve()
+	if err != nil {
+		return nil, kit.ErrorOn(err, "config")
+	}
+	env.Schedule = *schedule
+
+	// Check for jcli binary. Local folder first (jor_bins), then PATH
+	env.JcliBin, err = kit.FindExecutable("jcli", "jor_bins")
+	if err != nil {
+		return nil, err
+	}
+	jcli.BinName(env.JcliBin)
+
+	jcliVersion, err := jcli.VersionFull()
+	if err != nil {
+		return nil, kit.ErrorOn(err, kit.B2S(jcliVersion))
+	}
+	env.JcliVersion = kit.B2S(jcliVersion)
+
+	/* block0 */
+
+	env.Block0Bin, err = ioutil.ReadFile(env.Block0BinFile)
+	if err != nil {
+		return nil, kit.ErrorOn(err, "block0")
+	}
+	block0Hash, err := jcli.GenesisHash(env.Block0Bin, "")
+	if err != nil {
+		return nil, kit.ErrorOn(err, kit.B2S(block0Hash))
+	}
+	env.Block0Hash = kit.B2S(block0Hash)
+
+	var block0Txt block0TxtFile
+	err = readYaml(env.Block0TxtFile, &block0Txt)
+	if err != nil {
+		return nil, kit.ErrorOn(err, "block0 yaml")
+	}
+	// the leaders are also committee members, the generated (or provided) secret keys are in the working dir
+	secretFiles, err := filepath.Glob(filepath.Join(dir, "*_bft_secret.key"))
+	if err != nil {
+		return nil, kit.ErrorOn(err, "bft secret keys")
+	}
+	secrets := make(map[string]string, len(secretFiles))
+	for _, file := range secretFiles {
+		pk, err := jcli.KeyToPublic(nil, file, "")
+		if err != nil {
+			return nil, kit.ErrorOn(err, "bft secret key", file, kit.B2S(pk))
+		}
+		secrets[kit.B2S(pk)] = file
+	}
+	leaders := make(map[string]bool)
+	for _, pk := range block0Txt.BlockchainConfiguration.ConsensusLeaderIds {
+		account, err := jcli.AddressAccount(pk, addressPrefix(env.Config.Discrimination), jcliDiscrimination(env.Config.Discrimination))
+		if err != nil {
+			return nil, kit.ErrorOn(err, kit.B2S(account))
+		}
+		env.Leaders = append(env.Leaders, Leader{PublicKey: pk, Account: kit.B2S(account), SecretKeyFile: secrets[pk]})
+		leaders[pk] = true
+	}
+	for _, pk := range block0Txt.BlockchainConfiguration.Committees {
+		if !leaders[pk] {
+			env.Committee = append(env.Committee, CommitteeMember{PublicKey: pk})
+		}
+	}
+
+	// generated test voters, if any
+	if voters, err := LoadVoters(filepath.Join(dir, VotersJSONFile)); err == nil {
+		env.Voters = voters
+		env.VotersJSONFile = filepath.Join(dir, VotersJSONFile)
+		env.VotersCsvFile = filepath.Join(dir, VotersCsvFile)
+	} else if !os.IsNotExist(err) {
+		return nil, kit.ErrorOn(err, "voters")
+	}
+	if _, err = os.Stat(filepath.Join(env.VotePlanDir, VoteEncryptionKeyFile)); err == nil {
+		env.Privacy.VoteEncryptionKeyFile = filepath.Join(env.VotePlanDir, VoteEncryptionKeyFile)
+	}
+	if _, err = os.Stat(filepath.Join(dir, VotingPowerReportFile)); err == nil {
+		env.VotingPowerReportFile = filepath.Join(dir, VotingPowerReportFile)
+	}
+
+	/* node(s) */
+
+	// Check for jörmungandr binary. Local folder first, then PATH
+	env.JnodeBin, err = kit.FindExecutable("jormungandr", "jor_bins")
+	if err != nil {
+		return nil, err
+	}
+	jnode.BinName(env.JnodeBin)
+
+	jormungandrVersion, err := jnode.VersionFull()
+	if err != nil {
+		return nil, kit.ErrorOn(err, kit.B2S(jormungandrVersion))
+	}
+	env.JnodeVersion = kit.B2S(jormungandrVersion)
+
+	// main node in the working dir, then the multi node network ones (node_1, node_2, ...) if any
+	for i := 0; ; i++ {
+		n := &NetworkNode{Dir: nodeDir(dir, i)}
+		n.ConfigFile = filepath.Join(n.Dir, NodeConfigFile)
+		if _, err = os.Stat(n.ConfigFile); i > 0 && os.IsNotExist(err) {
+			break
+		}
+
+		var nodeCfg nodeConfigFile
+		err = readYaml(n.ConfigFile, &nodeCfg)
+		if err != nil {
+			return nil, kit.ErrorOn(err, "node config")
+		}
+		n.StorageDir = nodeCfg.Storage
+		n.RestAddress = nodeCfg.Rest.Listen
+		n.P2PListenAddress = nodeCfg.P2P.ListenAddress
+		n.SecretConfigFiles = nodeCfg.SecretFiles
+
+		n.Node = jnode.NewJnode()
+
+		n.Node.WorkingDir = n.Dir
+		n.Node.GenesisBlock = env.Block0BinFile
+		n.Node.ConfigFile = n.ConfigFile
+
+		for _, secretFile := range n.SecretConfigFiles {
+			n.Node.AddSecretFile(secretFile)
+		}
+
+		env.Nodes = append(env.Nodes, n)
+	}
+	env.setMainNode()
+
+	/* vit station data */
+
+	proposals := &datastore.Proposals{}
+	err = proposals.InitializeDump(env.ProposalsCsvFile)
+	if err != nil {
+		return nil, kit.ErrorOn(err, "proposals dump")
+	}
+	env.Proposals = proposals
+
+	funds := &datastore.Funds{}
+	err = funds.InitializeDump(env.FundsCsvFile)
+	if err != nil {
+		return nil, kit.ErrorOn(err, "funds dump")
+	}
+	if funds.First() == nil {
+		return nil, fmt.Errorf("[%s] - no fund found", env.FundsCsvFile)
+	}
+	env.Funds = funds
+
+	votePlansCsv, err := os.Open(env.VotePlansCsvFile)
+	if err != nil {
+		return nil, kit.ErrorOn(err, "voteplans dump")
+	}
+	defer votePlansCsv.Close()
+	fund := funds.First()
+	fund.VotePlans, err = loader.LoadVotePlanData(votePlansCsv)
+	if err != nil {
+		return nil, kit.ErrorOn(err, "voteplans dump")
+	}
+
+	// link the proposals to the fund voteplans, as when generated
+	votePlans := make(map[string]*loader.ChainVotePlan, len(fund.VotePlans))
+	for i := range fund.VotePlans {
+		votePlans[fund.VotePlans[i].VotePlanID] = &fund.VotePlans[i]
+		vpFile := filepath.Join(env.VotePlanDir, fund.VotePlans[i].Payload+"_voteplan_"+fund.VotePlans[i].VotePlanID)
+		votePlan := VotePlan{
+			ID:               fund.VotePlans[i].VotePlanID,
+			Payload:          fund.VotePlans[i].Payload,
+			VoteStart:        env.Schedule.VoteStart,
+			VoteEnd:          env.Schedule.VoteEnd,
+			CommitteeEnd:     env.Schedule.CommitteeEnd,
+			ConfigFile:       vpFile + ".json",
+			CertUnsignedFile: vpFile + ".cert-unsigned",
+		}
+		if _, err = os.Stat(vpFile + ".cert-signed"); err == nil {
+			votePlan.CertSignedFile = vpFile + ".cert-signed"
+		}
+		env.VotePlans = append(env.VotePlans, votePlan)
+	}
+	for _, proposal := range *env.Proposals.All() {
+		if proposal.ChainVotePlan == nil {
+			continue
+		}
+		vp, ok := votePlans[proposal.VotePlanID]
+		if !ok {
+			return nil, fmt.Errorf("proposal [%d] - voteplan [%s] not found", proposal.InternalID, proposal.VotePlanID)
+		}
+		proposal.ChainVotePlan = vp
+		for i := range env.VotePlans {
+			if env.VotePlans[i].ID == vp.VotePlanID {
+				env.VotePlans[i].Proposals++
+			}
+		}
+	}
+
+	/* vit station */
+
+	vs := vstation.NewVstation()
+	vsJson, err := ioutil.ReadFile(env.VitConfigFile)
+	if err != nil {
+		return nil, kit.ErrorOn(err, "vit station config")
+	}
+	err = json.Unmarshal(vsJson, vs)
+	if err != nil {
+		return nil, kit.ErrorOn(err, "vit station config", env.VitConfigFile)
+	}
+	vs.WorkingDir = env.VitStationDir
+	env.Station = vs
+	env.VitStationAddress = vs.Address
+
+	if _, err = os.Stat(vs.DbUrl); err == nil {
+		env.VitDbFile = vs.DbUrl
+	}
+
+	// Check for vit-servicing-station-server binary. Local folder first (vit_bins), then PATH
+	vstationBin, err := kit.FindExecutable("vit-servicing-station-server", "vit_bins")
+	if err != nil {
+		log.Printf("***** %s", err.Error())
+	} else {
+		env.VstationBin = vstationBin
+		vstation.BinName(vstationBin)
+
+		vstationVersion, err := vstation.Version()
+		if err != nil {
+			return nil, kit.ErrorOn(err, kit.B2S(vstationVersion))
+		}
+		env.VstationVersion = kit.B2S(vstationVersion)
+	}
+
+	env.ProxyAddress = env.Config.Proxy
+
+	return env, nil
+}
@@ -0,0 +1,187 @@
+package vitenv
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/input-output-hk/jorvit/internal/kit"
+	"github.com/input-output-hk/jorvit/internal/webproxy"
+)
+
+// Start the environment services: the node(s) (if Config.StartNode),
+// the vit station (if Config.StartVit and the binary is available) and the app proxy.
+// On failure the services started by this call are stopped, none is left running.
+func (env *Environment) Start() (err error) {
+	proxyNode, err := env.ProxyNode()
+	if err != nil {
+		return err
+	}
+
+	var (
+		nodesStarted []int
+		vitStarted   bool
+	)
+	defer func() {
+		if err == nil {
+			return
+		}
+		if vitStarted {
+			if stopErr := env.stopStation(); stopErr != nil {
+				log.Printf("Start FAILED - %v", stopErr)
+			}
+		}
+		for _, i := range nodesStarted {
+			if stopErr := env.stopNode(i); stopErr != nil {
+				log.Printf("Start FAILED - %v", stopErr)
+			}
+		}
+	}()
+
+	// Run the node(s) (Start + Wait)
+	if env.Config.StartNode {
+		err = os.Setenv("RUST_BACKTRACE", "full")
+		if err != nil {
+			return kit.ErrorOn(err, "Failed to set env (RUST_BACKTRACE=full)")
+		}
+	}
+	for i, n := range env.Nodes {
+		if !env.Config.StartNode || n.started {
+			continue
+		}
+
+		stdout, err := os.Create(filepath.Join(n.Dir, "stdout.log"))
+		if err != nil {
+			return kit.ErrorOn(err, "node stdout")
+		}
+		n.logs = append(n.logs, stdout)
+		stderr, err := os.Create(filepath.Join(n.Dir, "stderr.log"))
+		if err != nil {
+			_ = n.closeLogs()
+			return kit.ErrorOn(err, "node stderr")
+		}
+		n.logs = append(n.logs, stderr)
+		n.Node.Stdout, n.Node.Stderr = stdout, stderr
+
+		err = n.Node.Run()
+		if err != nil {
+			_ = n.closeLogs()
+			return fmt.Errorf("node_%d.Run FAILED: %w", i, err)
+		}
+		n.started = true
+		nodesStarted = append(nodesStarted, i)
+	}
+
+	if env.Config.StartVit && env.VstationBin != "" && !env.vitStarted {
+		err = env.Station.Run()
+		if err != nil {
+			return fmt.Errorf("vs.Run FAILED: %w", err)
+		}
+		env.vitStarted, vitStarted = true, true
+	}
+
+	////////////////////
+	// internal proxy //
+	////////////////////
+
+	if env.proxy == nil {
+		ln, err := net.Listen("tcp", env.ProxyAddress)
+		if err != nil {
+			return kit.ErrorOn(err, "Proxy Listen")
+		}
+
+		env.proxy = webproxy.NewServer(env.Proposals, env.Funds, &env.Block0Bin, env.ProxyAddress, "http://"+proxyNode.RestAddress)
+		env.proxyStopped = make(chan struct{})
+		go func() {
+			defer close(env.proxyStopped)
+			err := env.proxy.Serve(ln)
+			if err != nil && err != http.ErrServerClosed {
+				log.Printf("Proxy Run - %v", err)
+			}
+		}()
+	}
+
+	return nil
+}
+
+// Wait for the started node(s) and vit station to stop.
+func (env *Environment) Wait() {
+	if env.vitStarted {
+		env.Station.Wait() // Wait for the vit station to stop.
+	}
+	for _, n := range env.Nodes {
+		if n.started {
+			n.Node.Wait() // Wait for the node to stop.
+		}
+	}
+}
+
+// Stop the app proxy, the vit station and the node(s) if started from Start.
+func (env *Environment) Stop(ctx context.Context) error {
+	var errs []error
+
+	if env.proxy != nil {
+		err := env.proxy.Shutdown(ctx)
+		if err != nil {
+			errs = append(errs, kit.ErrorOn(err, "Proxy Shutdown"))
+		}
+		<-env.proxyStopped
+		env.proxy = nil
+	}
+
+	if env.vitStarted {
+		if err := env.stopStation(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for i, n := range env.Nodes {
+		if !n.started {
+			continue
+		}
+		if err := env.stopNode(i); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
+
+// stopStation stops the started vit station and waits for it.
+func (env *Environment) stopStation() error {
+	err := env.Station.Stop()
+	env.Station.Wait()
+	env.vitStarted = false
+	return kit.ErrorOn(err, "vs.Stop")
+}
+
+// stopNode stops the started node i, waits for it and closes its logs.
+func (env *Environment) stopNode(i int) error {
+	n := env.Nodes[i]
+	var errs []error
+
+	err := n.Node.Stop()
+	if err != nil {
+		errs = append(errs, kit.ErrorOn(err, "node_"+strconv.Itoa(i)+".Stop"))
+	}
+	n.Node.Wait()
+	n.started = false
+
+	err = n.closeLogs()
+	if err != nil {
+		errs = append(errs, kit.ErrorOn(err, "node_"+strconv.Itoa(i)+" logs"))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package vitenv
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rinor/jorcli/jnode"
+)
+
+func TestNodeCloseLogs(t *testing.T) {
+	dir := t.TempDir()
+	n := &NetworkNode{Dir: dir}
+	for _, name := range []string{"stdout.log", "stderr.log"} {
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		n.logs = append(n.logs, f)
+	}
+	logs := n.logs
+
+	if err := n.closeLogs(); err != nil {
+		t.Fatalf("closeLogs: %v", err)
+	}
+	if n.logs != nil {
+		t.Errorf("closeLogs: got %d logs left", len(n.logs))
+	}
+	for _, f := range logs {
+		if _, err := f.Write([]byte("x")); err == nil {
+			t.Errorf("%s: still open", f.Name())
+		}
+	}
+
+	// nothing left to close
+	if err := n.closeLogs(); err != nil {
+		t.Errorf("closeLogs again: %v", err)
+	}
+}
+
+func useFakeJnode(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake jormungandr is a shell script")
+	}
+	bin := writeTestFile(t, t.TempDir(), "jormungandr", "#!/bin/sh\nexec sleep 60\n")
+	if err := os.Chmod(bin, 0755); err != nil {
+		t.Fatal(err)
+	}
+	jnode.BinName(bin)
+	t.Cleanup(func() { jnode.BinName("jormungandr") })
+}
+
+// testRunEnv with n nodes to start, the proxy address is a free local port.
+func testRunEnv(t *testing.T, n int) *Environment {
+	t.Helper()
+	env := &Environment{ProxyAddress: "127.0.0.1:0"}
+	env.Config.StartNode = true
+	for i := 0; i < n; i++ {
+		node := jnode.NewJnode()
+		node.WorkingDir = t.TempDir()
+		env.Nodes = append(env.Nodes, &NetworkNode{Dir: node.WorkingDir, RestAddress: "127.0.0.1:8001", Node: node})
+	}
+	return env
+}
+
+// checkNodesStopped checks the started nodes were stopped, their process gone.
+func checkNodesStopped(t *testing.T, nodes []*NetworkNode) {
+	t.Helper()
+	for i, n := range nodes {
+		if n.started || n.logs != nil {
+			t.Errorf("node_%d: still started", i)
+		}
+		exited := make(chan struct{})
+		go func() {
+			n.Node.Wait()
+			close(exited)
+		}()
+		select {
+		case <-exited:
+		case <-time.After(time.Second):
+			t.Errorf("node_%d: process %d still running", i, n.Node.Pid())
+		}
+	}
+}
+
+func TestStartProxyFailure(t *testing.T) {
+	useFakeJnode(t)
+
+	// proxy address already in use
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	env := testRunEnv(t, 1)
+	env.ProxyAddress = ln.Addr().String()
+	if err = env.Start(); err == nil || !strings.Contains(err.Error(), "Proxy Listen") {
+		t.Fatalf("Start: got %v, want proxy listen error", err)
+	}
+	checkNodesStopped(t, env.Nodes)
+
+	// nothing left to stop
+	if err = env.Stop(context.Background()); err != nil {
+		t.Errorf("Stop: %v", err)
+	}
+}
+
+func TestStartNodeFailure(t *testing.T) {
+	useFakeJnode(t)
+
+	for _, k := range []int{0, 1, 3} {
+		t.Run("node_"+strconv.Itoa(k), func(t *testing.T) {
+			env := testRunEnv(t, 4)
+			// node k can't start, its working dir doesn't exist
+			env.Nodes[k].Node.WorkingDir = filepath.Join(env.Nodes[k].Dir, "missing")
+
+			err := env.Start()
+			if err == nil || !strings.Contains(err.Error(), "node_"+strconv.Itoa(k)+".Run FAILED") {
+				t.Fatalf("Start: got %v, want node_%d.Run error", err, k)
+			}
+			checkNodesStopped(t, env.Nodes[:k])
+			for i, n := range env.Nodes[k:] {
+				if n.started || n.logs != nil {
+					t.Errorf("node_%d: started after node_%d failed", k+i, k)
+				}
+			}
+			if env.proxy != nil {
+				t.Error("proxy: started after node failure")
+			}
+		})
+	}
+}
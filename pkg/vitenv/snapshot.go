@@ -0,0 +1,97 @@
+package vitenv
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gocarina/gocsv"
+	"github.com/rinor/jorcli/jnode"
+)
+
+// VotingPowerReportFile within the main working dir, with the voting power snapshot import report
+const VotingPowerReportFile = "voting_power.json"
+
+// snapshotEntry is a voting power snapshot account stake.
+type snapshotEntry struct {
+	Address string `json:"address" csv:"address"`
+	Stake   uint64 `json:"stake"   csv:"stake"`
+}
+
+// VotingPowerReport of the voting power snapshot import, the eligible voters are funded on block0.
+type VotingPowerReport struct {
+	Snapshot         string `json:"snapshot"`
+	Threshold        uint64 `json:"voting_power_threshold"`
+	Entries          int    `json:"entries"`
+	EligibleVoters   int    `json:"eligible_voters"`
+	TotalVotingPower uint64 `json:"total_voting_power"`
+	Dropped          int    `json:"dropped"`
+	DroppedStake     uint64 `json:"dropped_stake"`
+
+	Funds []jnode.InitialFund `json:"-"`
+}
+
+// String of the report, ex: "3/5 eligible voters, total voting power 30000000 (threshold 500)".
+func (r VotingPowerReport) String() string {
+	return fmt.Sprintf("%d/%d eligible voters, total voting power %d (threshold %d)", r.EligibleVoters, r.Entries, r.TotalVotingPower, r.Threshold)
+}
+
+// initial block0 entries of the eligible voters funds.
+func (r *VotingPowerReport) initial() []jnode.BlockchainInitial {
+	return initialFunds(r.Funds)
+}
+
+// loadSnapshot reads the voting power snapshot, a JSON list (.json) or a CSV with address,stake columns.
+func loadSnapshot(file string) ([]snapshotEntry, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries := make([]snapshotEntry, 0)
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		err = json.NewDecoder(f).Decode(&entries)
+	} else {
+		err = gocsv.Unmarshal(f, &entries)
+	}
+	return entries, err
+}
+
+// importVotingPower loads the voting power snapshot and keeps the entries with at least threshold stake.
+// Each address is checked for bech32 and discrimination, duplicated addresses are rejected.
+func importVotingPower(file string, discrimination string, threshold uint64) (*VotingPowerReport, error) {
+	entries, err := loadSnapshot(file)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &VotingPowerReport{Snapshot: file, Threshold: threshold, Entries: len(entries)}
+	seen := make(map[string]int, len(entries))
+	for i, entry := range entries {
+		err = checkAddress(entry.Address, discrimination)
+		if err != nil {
+			return nil, fmt.Errorf("entry [%d]: %w", i, err)
+		}
+		if j, ok := seen[entry.Address]; ok {
+			return nil, fmt.Errorf("entry [%d]: [%s] - duplicated address, already at entry [%d]", i, entry.Address, j)
+		}
+		seen[entry.Address] = i
+
+		if entry.Stake == 0 || entry.Stake < threshold {
+			report.Dropped++
+			report.DroppedStake += entry.Stake
+			continue
+		}
+		if entry.Stake > math.MaxUint64-report.TotalVotingPower {
+			return nil, fmt.Errorf("entry [%d]: [%s: %d] - total voting power overflow", i, entry.Address, entry.Stake)
+		}
+		report.EligibleVoters++
+		report.TotalVotingPower += entry.Stake
+		report.Funds = append(report.Funds, jnode.InitialFund{Address: entry.Address, Value: entry.Stake})
+	}
+	return report, nil
+}
@@ -0,0 +1,154 @@
+package vitenv
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/input-output-hk/jorvit/internal/bech32"
+)
+
+// testAccount is the i-th generated test account address of discrimination.
+func testAccount(t *testing.T, i int, discrimination string) string {
+	t.Helper()
+	pk, err := bech32.Encode("ed25519_pk", bytes.Repeat([]byte{byte(i)}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := accountAddress(pk, addressPrefix(discrimination), discrimination)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return account
+}
+
+// testSnapshotCSV with the i-th test account for each stake.
+func testSnapshotCSV(t *testing.T, stakes ...uint64) string {
+	t.Helper()
+	var b strings.Builder
+	b.WriteString("address,stake\n")
+	for i, stake := range stakes {
+		b.WriteString(testAccount(t, i, DiscriminationTesting) + "," + strconv.FormatUint(stake, 10) + "\n")
+	}
+	return b.String()
+}
+
+func TestImportVotingPowerThreshold(t *testing.T) {
+	stakes := []uint64{0, 499, 500, 501, 10_000}
+
+	tests := []struct {
+		threshold uint64
+		eligible  []int // stakes index
+	}{
+		{threshold: 0, eligible: []int{1, 2, 3, 4}},
+		{threshold: 1, eligible: []int{1, 2, 3, 4}},
+		{threshold: 500, eligible: []int{2, 3, 4}},
+		{threshold: 501, eligible: []int{3, 4}},
+		{threshold: 10_001, eligible: nil},
+	}
+
+	file := writeTestFile(t, t.TempDir(), "snapshot.csv", testSnapshotCSV(t, stakes...))
+	for _, tt := range tests {
+		t.Run(strconv.FormatUint(tt.threshold, 10), func(t *testing.T) {
+			report, err := importVotingPower(file, DiscriminationTesting, tt.threshold)
+			if err != nil {
+				t.Fatalf("importVotingPower: %v", err)
+			}
+
+			var total, all uint64
+			for _, s := range stakes {
+				all += s
+			}
+			if len(report.Funds) != len(tt.eligible) {
+				t.Fatalf("funds: got %d, want %d", len(report.Funds), len(tt.eligible))
+			}
+			for j, i := range tt.eligible {
+				if report.Funds[j].Address != testAccount(t, i, DiscriminationTesting) || report.Funds[j].Value != stakes[i] {
+					t.Errorf("fund %d: got %+v, want stake %d", j, report.Funds[j], stakes[i])
+				}
+				total += stakes[i]
+			}
+
+			if report.Entries != len(stakes) || report.EligibleVoters != len(tt.eligible) || report.TotalVotingPower != total {
+				t.Errorf("report: got %s", report)
+			}
+			if report.Dropped != len(stakes)-len(tt.eligible) || report.DroppedStake != all-total {
+				t.Errorf("dropped: got %d (%d)", report.Dropped, report.DroppedStake)
+			}
+			if report.Threshold != tt.threshold {
+				t.Errorf("threshold: got %d, want %d", report.Threshold, tt.threshold)
+			}
+		})
+	}
+}
+
+func TestImportVotingPowerJSON(t *testing.T) {
+	entries := []snapshotEntry{
+		{Address: testAccount(t, 1, DiscriminationProduction), Stake: 100},
+		{Address: testAccount(t, 2, DiscriminationProduction), Stake: 50},
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := writeTestFile(t, t.TempDir(), "snapshot.JSON", string(data))
+
+	report, err := importVotingPower(file, DiscriminationProduction, 60)
+	if err != nil {
+		t.Fatalf("importVotingPower: %v", err)
+	}
+	if report.String() != "1/2 eligible voters, total voting power 100 (threshold 60)" {
+		t.Errorf("report: got %s", report)
+	}
+	if initial := report.initial(); len(initial) != 1 || !reflect.DeepEqual(initial[0].Fund, report.Funds) {
+		t.Errorf("initial: got %+v", initial)
+	}
+}
+
+func TestImportVotingPowerErrors(t *testing.T) {
+	a0, a1 := testAccount(t, 0, DiscriminationTesting), testAccount(t, 1, DiscriminationTesting)
+
+	tests := []struct {
+		name   string
+		csv    string
+		errMsg string
+	}{
+		{"duplicate", "address,stake\n" + a0 + ",1\n" + a1 + ",2\n" + a0 + ",3\n", "entry [2]: [" + a0 + "] - duplicated address, already at entry [0]"},
+		{"discrimination", "address,stake\n" + testAccount(t, 0, DiscriminationProduction) + ",1\n", "entry [0]: [" + testAccount(t, 0, DiscriminationProduction) + "] - expected testing discrimination"},
+		{"not an address", "address,stake\nnot_an_address,1\n", "entry [0]: [not_an_address]"},
+		{"overflow", "address,stake\n" + a0 + ",18446744073709551615\n" + a1 + ",1\n", "entry [1]: [" + a1 + ": 1] - total voting power overflow"},
+		{"wrong stake", "address,stake\n" + a0 + ",lots\n", "lots"},
+	}
+
+	dir := t.TempDir()
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := writeTestFile(t, dir, "snapshot_"+strconv.Itoa(i)+".csv", tt.csv)
+			_, err := importVotingPower(file, DiscriminationTesting, 0)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Fatalf("importVotingPower: got %v, want error containing %q", err, tt.errMsg)
+			}
+		})
+	}
+
+	if _, err := importVotingPower(dir+"/missing.csv", DiscriminationTesting, 0); err == nil {
+		t.Error("importVotingPower: expected error on missing file")
+	}
+}
+
+func TestPlanVotingPowerThreshold(t *testing.T) {
+	// fund voting_power_threshold 450 ADA
+	cfg := testPlanConfig(t, testProposalsCSV(1, false, 1))
+	cfg.VotingPowerSnapshot = writeTestFile(t, t.TempDir(), "snapshot.csv", testSnapshotCSV(t, 449_999_999, 450_000_000))
+
+	plan, err := NewPlan(cfg)
+	if err != nil {
+		t.Fatalf("NewPlan: %v", err)
+	}
+	if plan.VotingPower == nil || plan.VotingPower.Threshold != 450_000_000 || plan.VotingPower.EligibleVoters != 1 {
+		t.Errorf("voting power: got %+v", plan.VotingPower)
+	}
+}
@@ -0,0 +1,163 @@
+package vitenv
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/input-output-hk/jorvit/internal/kit"
+	"github.com/rinor/jorcli/jcli"
+	"github.com/rinor/jorcli/jnode"
+)
+
+// StakePoolsDir within the main working dir, with a sub directory for each stake pool
+const StakePoolsDir = "stake_pools"
+
+// StakePool is a generated genesis_praos stake pool, registered and delegated to (by its owner) in block0.
+type StakePool struct {
+	ID               string `json:"id"`
+	Dir              string `json:"dir"`
+	OwnerPublicKey   string `json:"owner_public_key"`
+	OwnerAccount     string `json:"owner_account"`
+	OwnerSKFile      string `json:"owner_sk_file"`
+	KESPublicKey     string `json:"kes_public_key"`
+	VRFPublicKey     string `json:"vrf_public_key"`
+	RegistrationFile string `json:"registration_file"`
+	DelegationFile   string `json:"delegation_file"`
+	SecretConfigFile string `json:"secret_config_file"`
+}
+
+// poolKey generates (or loads from Config.KeyDir) the pool key of keyType, dumped in the pool dir as name.
+func (env *Environment) poolKey(pool *StakePool, i int, name string, keyType string) (sk []byte, pk []byte, err error) {
+	sk, err = env.generateKey("pool_"+strconv.Itoa(i)+"_"+name+".sk", func(seed string) ([]byte, error) {
+		return jcli.KeyGenerate(seed, keyType, "")
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	err = writeFile(filepath.Join(pool.Dir, name+".sk"), sk, 0600)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pk, err = jcli.KeyToPublic(sk, "", filepath.Join(pool.Dir, name+".pk"))
+	if err != nil {
+		return nil, nil, kit.ErrorOn(err, kit.B2S(pk))
+	}
+	return sk, pk, nil
+}
+
+// generateStakePools builds Config.StakePools stake pools, each one with:
+//   - owner (ed25519), KES and VRF keys
+//   - owner account funded in block0 with Config.StakePoolFund
+//   - owner signed registration and delegation certificates in block0
+//   - node secret config (genesis: sig_key, vrf_key, node_id)
+func (env *Environment) generateStakePools(block0cfg *jnode.Block0Config, prefix string, discrimination string) error {
+	cfg := &env.Config
+
+	err := os.Mkdir(filepath.Join(env.WorkingDir, StakePoolsDir), 0755)
+	if err != nil {
+		return kit.ErrorOn(err, "stakePoolsDir")
+	}
+
+	env.StakePools = make([]StakePool, cfg.StakePools)
+	for i := range env.StakePools {
+		pool := &env.StakePools[i]
+
+		pool.Dir = filepath.Join(env.WorkingDir, StakePoolsDir, "pool_"+strconv.Itoa(i))
+		err = os.Mkdir(pool.Dir, 0755)
+		if err != nil {
+			return kit.ErrorOn(err, "stakePoolDir")
+		}
+
+		_, ownerPK, err := env.poolKey(pool, i, "owner", "Ed25519")
+		if err != nil {
+			return err
+		}
+		kesSK, kesPK, err := env.poolKey(pool, i, "kes", "SumEd25519_12")
+		if err != nil {
+			return err
+		}
+		vrfSK, vrfPK, err := env.poolKey(pool, i, "vrf", "Curve25519_2HashDH")
+		if err != nil {
+			return err
+		}
+		pool.OwnerSKFile = filepath.Join(pool.Dir, "owner.sk")
+		pool.OwnerPublicKey = kit.B2S(ownerPK)
+		pool.KESPublicKey = kit.B2S(kesPK)
+		pool.VRFPublicKey = kit.B2S(vrfPK)
+
+		// owner account, needs to be funded (before the delegation) for the pool to have stake
+		ownerACC, err := jcli.AddressAccount(pool.OwnerPublicKey, prefix, discrimination)
+		if err != nil {
+			return kit.ErrorOn(err, kit.B2S(ownerACC))
+		}
+		pool.OwnerAccount = kit.B2S(ownerACC)
+
+		err = block0cfg.AddInitialFund(pool.OwnerAccount, cfg.StakePoolFund)
+		if err != nil {
+			return kit.ErrorOn(err, "AddInitialFund")
+		}
+
+		// registration
+		regCert, err := jcli.CertificateNewStakePoolRegistration(
+			pool.KESPublicKey, pool.VRFPublicKey,
+			0, 1, []string{pool.OwnerPublicKey}, nil,
+			0, "", 0, "",
+			"",
+		)
+		if err != nil {
+			return kit.ErrorOn(err, "jcli.CertificateNewStakePoolRegistration", kit.B2S(regCert))
+		}
+		pool.RegistrationFile = filepath.Join(pool.Dir, "registration.signedcert")
+		regSigned, err := jcli.CertificateSign(regCert, []string{pool.OwnerSKFile}, "", pool.RegistrationFile)
+		if err != nil {
+			return kit.ErrorOn(err, "jcli.CertificateSign", kit.B2S(regSigned))
+		}
+
+		poolID, err := jcli.CertificateShowStakePoolID(regSigned, "", "")
+		if err != nil {
+			return kit.ErrorOn(err, "jcli.CertificateShowStakePoolID", kit.B2S(poolID))
+		}
+		pool.ID = kit.B2S(poolID)
+
+		// owner delegation to its own pool
+		delegCert, err := jcli.CertificateNewStakeDelegation(pool.OwnerPublicKey, []string{pool.ID}, "")
+		if err != nil {
+			return kit.ErrorOn(err, "jcli.CertificateNewStakeDelegation", kit.B2S(delegCert))
+		}
+		pool.DelegationFile = filepath.Join(pool.Dir, "delegation.signedcert")
+		delegSigned, err := jcli.CertificateSign(delegCert, []string{pool.OwnerSKFile}, "", pool.DelegationFile)
+		if err != nil {
+			return kit.ErrorOn(err, "jcli.CertificateSign", kit.B2S(delegSigned))
+		}
+
+		err = block0cfg.AddInitialCertificate(kit.B2S(regSigned))
+		if err != nil {
+			return kit.ErrorOn(err, "AddInitialCertificate")
+		}
+		err = block0cfg.AddInitialCertificate(kit.B2S(delegSigned))
+		if err != nil {
+			return kit.ErrorOn(err, "AddInitialCertificate")
+		}
+
+		// node secret config (--secret)
+		secretCfg := jnode.NewSecretConfig()
+
+		secretCfg.Genesis.SigKey = kit.B2S(kesSK)
+		secretCfg.Genesis.VrfKey = kit.B2S(vrfSK)
+		secretCfg.Genesis.NodeID = pool.ID
+
+		secretCfgYaml, err := secretCfg.ToYaml()
+		if err != nil {
+			return kit.ErrorOn(err, "secretCfg.ToYaml")
+		}
+		pool.SecretConfigFile = filepath.Join(pool.Dir, "node_secret.yaml")
+		err = writeFile(pool.SecretConfigFile, secretCfgYaml, 0600)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package vitenv
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStakePoolConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *Config)
+		errMsg string
+	}{
+		{"bft", func(cfg *Config) {}, ""},
+		{"genesis praos", func(cfg *Config) { cfg.Consensus, cfg.StakePools = "genesis_praos", 3 }, ""},
+		{"bft ignores pools", func(cfg *Config) { cfg.StakePools, cfg.StakePoolFund = 0, 0 }, ""},
+		{"wrong consensus", func(cfg *Config) { cfg.Consensus = "praos" }, "consensus - expected to be one of (bft, genesis_praos) - but [praos] provided"},
+		{"no pools", func(cfg *Config) { cfg.Consensus, cfg.StakePools = "genesis_praos", 0 }, "[stake-pools: 0] - wrong value, expected > 0 with genesis_praos"},
+		{"no pool fund", func(cfg *Config) { cfg.Consensus, cfg.StakePoolFund = "genesis_praos", 0 }, "[stake-pool-fund] - cannot be 0 with genesis_praos"},
+		{"slot coeff min", func(cfg *Config) { cfg.PraosActiveSlotCoeff = 0.0009 }, "[praos-active-slot-coeff: 0.0009] - wrong value"},
+		{"slot coeff max", func(cfg *Config) { cfg.PraosActiveSlotCoeff = 1.1 }, "[praos-active-slot-coeff: 1.1] - wrong value"},
+		{"slot coeff bounds", func(cfg *Config) { cfg.PraosActiveSlotCoeff = 1 }, ""},
+		{"kes speed min", func(cfg *Config) { cfg.KesUpdateSpeed = 59 }, "[kes-update-speed: 59] - wrong value"},
+		{"kes speed max", func(cfg *Config) { cfg.KesUpdateSpeed = 365*24*3600 + 1 }, "[kes-update-speed: 31536001] - wrong value"},
+		{"stability depth", func(cfg *Config) { cfg.EpochStabilityDepth = 0 }, "[epoch-stability-depth] - cannot be 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.GenesisTime = "2021-01-01T00:00:00Z"
+			tt.modify(&cfg)
+
+			_, err := cfg.Resolve()
+			switch {
+			case tt.errMsg == "" && err != nil:
+				t.Fatalf("Resolve: %v", err)
+			case tt.errMsg != "" && (err == nil || !strings.Contains(err.Error(), tt.errMsg)):
+				t.Fatalf("Resolve: got %v, want error containing %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestStakePoolPlan(t *testing.T) {
+	tests := []struct {
+		name      string
+		multiNode bool
+		nodes     []int // leaders (pools) per node
+	}{
+		{"single node", false, []int{3}},
+		{"multi node", true, []int{1, 1, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testPlanConfig(t, testProposalsCSV(2, false, 1))
+			cfg.Consensus = "genesis_praos"
+			cfg.StakePools = 3
+			cfg.StakePoolFund = 500
+			cfg.MultiNode = tt.multiNode
+
+			plan, err := NewPlan(cfg)
+			if err != nil {
+				t.Fatalf("NewPlan: %v", err)
+			}
+
+			if len(plan.Nodes) != len(tt.nodes) {
+				t.Fatalf("nodes: got %d, want %d", len(plan.Nodes), len(tt.nodes))
+			}
+			for i, leaders := range tt.nodes {
+				if plan.Nodes[i].Leaders != leaders {
+					t.Errorf("node_%d: got %d leaders, want %d", i, plan.Nodes[i].Leaders, leaders)
+				}
+			}
+
+			var owners int
+			for _, fa := range plan.FundedAccounts {
+				if fa.Role == "stake pool owner (generated)" {
+					owners++
+					if fa.Value != 500 || fa.Account != "" {
+						t.Errorf("owner account: got %+v", fa)
+					}
+				}
+			}
+			if owners != 3 {
+				t.Errorf("owner accounts: got %d, want 3", owners)
+			}
+		})
+	}
+}
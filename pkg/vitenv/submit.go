@@ -0,0 +1,92 @@
+package vitenv
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+
+	"github.com/input-output-hk/jorvit/internal/kit"
+	"github.com/input-output-hk/jorvit/pkg/vresult"
+)
+
+// VotePlansTxDir within the main working dir, with the voteplans transactions files
+const VotePlansTxDir = "vote_plans_tx"
+
+// votePlanListed reports whether the voteplan id is listed in the node active voteplans.
+func votePlanListed(votePlans []byte, id string) bool {
+	vps, err := vresult.DecodeVotePlans(bytes.NewReader(votePlans))
+	if err != nil {
+		return false
+	}
+	for _, vp := range vps {
+		if vp.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// SubmitVotePlans submits (node REST) the voteplans not included in block0 (Config.Block0VotePlan disabled),
+// each one as a transaction carrying its certificate, signed by the first leader with a secret key (also paying
+// the fees, if any), then waits for the node to list them all in the active voteplans.
+// Voteplans already listed by the node are not submitted again.
+func (env *Environment) SubmitVotePlans(ctx context.Context, node *NetworkNode) error {
+	if env.Config.Block0VotePlan || len(env.VotePlans) == 0 {
+		return nil
+	}
+
+	signer, err := env.certSigner()
+	if err != nil {
+		return fmt.Errorf("%s: %w", "submit-voteplans", err)
+	}
+
+	// the node needs to be up, the voteplans are expected before the vote start
+	log.Printf("VOTEPLANS - %d voteplans, waiting for node [%s]", len(env.VotePlans), node.RestAddress)
+	now, err := waitChainTime(ctx, "VOTEPLANS", node.RestAddress, vresult.ChainTime{})
+	if err != nil {
+		return err
+	}
+	if !chainTimeBefore(now, env.Schedule.VoteStart) {
+		log.Printf("VOTEPLANS - ***** chain time %s - vote already started at %s, the node might reject them *****", now, env.Schedule.VoteStart)
+	}
+
+	listed, err := activeVotePlans(node.RestAddress)
+	if err != nil {
+		return kit.ErrorOn(err, "active voteplans")
+	}
+
+	certFee := env.Config.FeesCertificateVotePlan
+	if certFee == 0 {
+		certFee = env.Config.FeesCertificate
+	}
+
+	txDir := filepath.Join(env.WorkingDir, VotePlansTxDir)
+	ids := make([]string, 0, len(env.VotePlans))
+	for i, vp := range env.VotePlans {
+		ids = append(ids, vp.ID)
+		if votePlanListed(listed, vp.ID) {
+			log.Printf("VOTEPLANS - %d/%d [%s] already listed, skip", i+1, len(env.VotePlans), vp.ID)
+			continue
+		}
+
+		cert, err := ioutil.ReadFile(vp.CertUnsignedFile)
+		if err != nil {
+			return kit.ErrorOn(err, "voteplan certificate")
+		}
+		fragmentID, err := env.submitCertificate(node.RestAddress, txDir, vp.Payload+"_voteplan_"+vp.ID, kit.B2S(cert), certFee, signer, true)
+		if err != nil {
+			return fmt.Errorf("voteplan [%s]: %w", vp.ID, err)
+		}
+		log.Printf("VOTEPLANS - %d/%d [%s] submitted, fragment: %s", i+1, len(env.VotePlans), vp.ID, fragmentID)
+	}
+
+	_, err = waitVotePlans(ctx, "VOTEPLANS", node.RestAddress, ids, "listed", votePlanListed)
+	if err != nil {
+		return err
+	}
+	log.Printf("VOTEPLANS - %d voteplans active", len(ids))
+	return nil
+}
@@ -0,0 +1,57 @@
+package vitenv
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestVotePlanListed(t *testing.T) {
+	tests := []struct {
+		votePlans string
+		id        string
+		want      bool
+	}{
+		{testVotePlans, "vp1", true},
+		{testVotePlans, "vp3", true},
+		{testVotePlans, "vp4", false},
+		{testVotePlans, "", false},
+		{`[]`, "vp1", false},
+		{`not json`, "vp1", false},
+	}
+
+	for _, tt := range tests {
+		if got := votePlanListed([]byte(tt.votePlans), tt.id); got != tt.want {
+			t.Errorf("votePlanListed(%q): got %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestSubmitVotePlans(t *testing.T) {
+	votePlans := []VotePlan{{ID: "vp1", Payload: "public"}, {ID: "vp3", Payload: "public"}}
+	leaders := []Leader{{PublicKey: "public key only"}, {Account: "ta1leader", SecretKeyFile: "leader.sk"}}
+
+	// nothing to submit, no node needed
+	for _, env := range []*Environment{
+		{Config: Config{Block0VotePlan: true}, VotePlans: votePlans},
+		{},
+	} {
+		if err := env.SubmitVotePlans(context.Background(), &NetworkNode{RestAddress: "127.0.0.1:1"}); err != nil {
+			t.Errorf("SubmitVotePlans: %v", err)
+		}
+	}
+
+	// no signer
+	env := &Environment{VotePlans: votePlans, Leaders: leaders[:1]}
+	err := env.SubmitVotePlans(context.Background(), &NetworkNode{RestAddress: "127.0.0.1:1"})
+	if err == nil || !strings.Contains(err.Error(), "submit-voteplans: no [bft leader SK (secret key)] available") {
+		t.Errorf("SubmitVotePlans: got %v, want no signer error", err)
+	}
+
+	// already listed voteplans are not submitted again
+	restAddress := testNodeRest(t, []string{`{"state": "Running", "lastBlockDate": "0.1"}`}, []string{testVotePlans})
+	env = &Environment{VotePlans: votePlans, Leaders: leaders, WorkingDir: t.TempDir()}
+	if err = env.SubmitVotePlans(context.Background(), &NetworkNode{RestAddress: restAddress}); err != nil {
+		t.Errorf("SubmitVotePlans: %v", err)
+	}
+}
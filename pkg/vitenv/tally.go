@@ -0,0 +1,149 @@
+package vitenv
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/input-output-hk/jorvit/internal/kit"
+	"github.com/input-output-hk/jorvit/pkg/vresult"
+	"github.com/rinor/jorcli/jcli"
+)
+
+// TallyDir within the main working dir, with the tally transactions files
+const TallyDir = "tally"
+
+// tallyPollInterval between the node REST queries while waiting for the chain.
+var tallyPollInterval = 10 * time.Second
+
+// certSigner is the first leader with a secret key, the leaders are also committee members.
+func (env *Environment) certSigner() (*certTxSigner, error) {
+	for i := range env.Leaders {
+		if env.Leaders[i].SecretKeyFile != "" {
+			return &certTxSigner{Account: env.Leaders[i].Account, SecretKeyFile: env.Leaders[i].SecretKeyFile}, nil
+		}
+	}
+	return nil, fmt.Errorf("no [%s] available to sign the certificate(s)", "bft leader SK (secret key)")
+}
+
+// waitChainTime polls the node until its last block date reaches at, logging the progress with tag.
+func waitChainTime(ctx context.Context, tag string, restAddress string, at vresult.ChainTime) (vresult.ChainTime, error) {
+	for {
+		now, err := nodeChainTime(restAddress)
+		switch {
+		case err != nil:
+			log.Printf("%s - node [%s] not ready: %v", tag, restAddress, err)
+		case !chainTimeBefore(now, at):
+			return now, nil
+		default:
+			log.Printf("%s - chain time %s, waiting for %s", tag, now, at)
+		}
+
+		select {
+		case <-ctx.Done():
+			return vresult.ChainTime{}, ctx.Err()
+		case <-time.After(tallyPollInterval):
+		}
+	}
+}
+
+// waitVotePlans polls the node active voteplans (raw json) until done reports all the ids, returns the last ones.
+// The progress is logged with tag.
+func waitVotePlans(ctx context.Context, tag string, restAddress string, ids []string, what string, done func(votePlans []byte, id string) bool) ([]byte, error) {
+	for {
+		votePlans, err := activeVotePlans(restAddress)
+		if err != nil {
+			log.Printf("%s - active voteplans: %v", tag, err)
+		} else {
+			ready := 0
+			for _, id := range ids {
+				if done(votePlans, id) {
+					ready++
+				}
+			}
+			log.Printf("%s - voteplans %s: %d/%d", tag, what, ready, len(ids))
+			if ready == len(ids) {
+				return votePlans, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(tallyPollInterval):
+		}
+	}
+}
+
+// votePlanTallied reports whether the voteplan id is listed with all its proposals tally.
+func votePlanTallied(votePlans []byte, id string) bool {
+	vps, err := vresult.DecodeVotePlans(bytes.NewReader(votePlans))
+	if err != nil {
+		return false
+	}
+	for _, vp := range vps {
+		if vp.ID != id {
+			continue
+		}
+		for _, p := range vp.Proposals {
+			if len(p.Tally) == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// TallyPublic waits for the vote end on the node, then submits (node REST) a vote tally certificate transaction
+// for each public voteplan, signed by the first leader with a secret key, and waits for the node to report the
+// tally in the active voteplans.
+func (env *Environment) TallyPublic(ctx context.Context, node *NetworkNode) error {
+	var ids []string
+	for i := range env.VotePlans {
+		if env.VotePlans[i].Payload == "public" {
+			ids = append(ids, env.VotePlans[i].ID)
+		}
+	}
+	if len(ids) == 0 {
+		log.Printf("TALLY - no %s voteplans found", "public")
+		return nil
+	}
+
+	signer, err := env.certSigner()
+	if err != nil {
+		return err
+	}
+
+	log.Printf("TALLY - %d %s voteplans, waiting for the vote end %s on node [%s]", len(ids), "public", env.Schedule.VoteEnd, node.RestAddress)
+	now, err := waitChainTime(ctx, "TALLY", node.RestAddress, env.Schedule.VoteEnd)
+	if err != nil {
+		return err
+	}
+	if !chainTimeBefore(now, env.Schedule.CommitteeEnd) {
+		return fmt.Errorf("chain time %s - committee period already ended at %s", now, env.Schedule.CommitteeEnd)
+	}
+
+	txDir := filepath.Join(env.WorkingDir, TallyDir)
+	for _, id := range ids {
+		cert, err := jcli.CertificateNewVoteTally(id, "")
+		if err != nil {
+			return kit.ErrorOn(err, "jcli.CertificateNewVoteTally", kit.B2S(cert))
+		}
+		fragmentID, err := env.submitCertificate(node.RestAddress, txDir, "public_tally_"+id, kit.B2S(cert), env.Config.FeesCertificate, signer, true)
+		if err != nil {
+			return fmt.Errorf("voteplan [%s] tally: %w", id, err)
+		}
+		log.Printf("TALLY - voteplan [%s] tally submitted, fragment: %s", id, fragmentID)
+	}
+
+	_, err = waitVotePlans(ctx, "TALLY", node.RestAddress, ids, "tallied", votePlanTallied)
+	if err != nil {
+		return err
+	}
+	log.Printf("TALLY - %d %s voteplans tallied", len(ids), "public")
+	return nil
+}
@@ -0,0 +1,185 @@
+package vitenv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/input-output-hk/jorvit/internal/kit"
+	"github.com/rinor/jorcli/jcli"
+)
+
+// privateTallyState of the voteplan id proposals (Encrypted, Decrypted), empty if not tallied yet or not the same for all.
+func privateTallyState(votePlans []byte, id string) string {
+	var vps []struct {
+		ID        string `json:"id"`
+		Proposals []struct {
+			Tally struct {
+				Private struct {
+					State map[string]json.RawMessage `json:"state"`
+				} `json:"Private"`
+			} `json:"tally"`
+		} `json:"proposals"`
+	}
+	if err := json.Unmarshal(votePlans, &vps); err != nil {
+		return ""
+	}
+	for _, vp := range vps {
+		if vp.ID != id {
+			continue
+		}
+		state := ""
+		for i, p := range vp.Proposals {
+			if len(p.Tally.Private.State) != 1 {
+				return ""
+			}
+			for s := range p.Tally.Private.State {
+				if i > 0 && s != state {
+					return ""
+				}
+				state = s
+			}
+		}
+		return state
+	}
+	return ""
+}
+
+// privacyMemberKeys lists the generated privacy committee member secret keys (vote_plans/committee_member_N/member_key.sk).
+func (env *Environment) privacyMemberKeys() ([]string, error) {
+	return filepath.Glob(filepath.Join(env.VotePlanDir, "committee_member_*", "member_key.sk"))
+}
+
+// TallyPrivate runs the private voteplans tally workflow, once the vote period ends on the node:
+//   - submits the encrypted vote tally certificate and waits for the node to report the encrypted tally
+//   - creates the decryption shares of each privacy committee member key found in the voteplans dir
+//   - merges the shares and verifies them, decrypting the results with Config.CommitteePrivacyThreshold
+//   - submits the private vote tally certificate and waits for the node to report the decrypted tally
+//
+// The certificates are signed by the first leader with a secret key, the files are kept in the tally dir.
+func (env *Environment) TallyPrivate(ctx context.Context, node *NetworkNode) error {
+	var ids []string
+	for i := range env.VotePlans {
+		if env.VotePlans[i].Payload == "private" {
+			ids = append(ids, env.VotePlans[i].ID)
+		}
+	}
+	if len(ids) == 0 {
+		log.Printf("TALLY - no %s voteplans found", "private")
+		return nil
+	}
+
+	memberKeys, err := env.privacyMemberKeys()
+	if err != nil {
+		return kit.ErrorOn(err, "privacy member keys")
+	}
+	threshold := env.Config.CommitteePrivacyThreshold
+	if uint(len(memberKeys)) < threshold {
+		return fmt.Errorf("%d privacy committee member key(s) found in %s - %d needed (%s)", len(memberKeys), env.VotePlanDir, threshold, "committee-privacy-threshold")
+	}
+
+	signer, err := env.certSigner()
+	if err != nil {
+		return err
+	}
+
+	log.Printf("TALLY - %d %s voteplans, %d member keys (threshold %d), waiting for the vote end %s on node [%s]", len(ids), "private", len(memberKeys), threshold, env.Schedule.VoteEnd, node.RestAddress)
+	now, err := waitChainTime(ctx, "TALLY", node.RestAddress, env.Schedule.VoteEnd)
+	if err != nil {
+		return err
+	}
+	if !chainTimeBefore(now, env.Schedule.CommitteeEnd) {
+		return fmt.Errorf("chain time %s - committee period already ended at %s", now, env.Schedule.CommitteeEnd)
+	}
+
+	txDir := filepath.Join(env.WorkingDir, TallyDir)
+
+	// encrypted tally
+	for _, id := range ids {
+		cert, err := jcli.CertificateNewEncryptedVoteTally(id, "")
+		if err != nil {
+			return kit.ErrorOn(err, "jcli.CertificateNewEncryptedVoteTally", kit.B2S(cert))
+		}
+		fragmentID, err := env.submitCertificate(node.RestAddress, txDir, "encrypted_tally_"+id, kit.B2S(cert), env.Config.FeesCertificate, signer, true)
+		if err != nil {
+			return fmt.Errorf("voteplan [%s] encrypted tally: %w", id, err)
+		}
+		log.Printf("TALLY - voteplan [%s] encrypted tally submitted, fragment: %s", id, fragmentID)
+	}
+
+	votePlans, err := waitVotePlans(ctx, "TALLY", node.RestAddress, ids, "encrypted", func(votePlans []byte, id string) bool {
+		return privateTallyState(votePlans, id) == "Encrypted"
+	})
+	if err != nil {
+		return err
+	}
+	votePlansFile := filepath.Join(txDir, "encrypted_tally_vote_plans.json")
+	err = writeFile(votePlansFile, votePlans, 0644)
+	if err != nil {
+		return err
+	}
+
+	// decryption shares, merge, verify and private tally
+	for _, id := range ids {
+		sharesDir := filepath.Join(txDir, "shares_"+id)
+		err = os.MkdirAll(sharesDir, 0755)
+		if err != nil {
+			return kit.ErrorOn(err, "sharesDir")
+		}
+
+		sharesFiles := make([]string, len(memberKeys))
+		for i, memberKey := range memberKeys {
+			shares, err := env.votesTallyDecryptionShares(votePlansFile, id, memberKey)
+			if err != nil {
+				return kit.ErrorOn(err, "jcli votes tally decryption-shares", memberKey, kit.B2S(shares))
+			}
+			sharesFiles[i] = filepath.Join(sharesDir, filepath.Base(filepath.Dir(memberKey))+".shares")
+			err = writeFile(sharesFiles[i], shares, 0644)
+			if err != nil {
+				return err
+			}
+		}
+
+		merged, err := env.votesTallyMergeShares(sharesFiles)
+		if err != nil {
+			return kit.ErrorOn(err, "jcli votes tally merge-shares", kit.B2S(merged))
+		}
+		mergedFile := filepath.Join(sharesDir, "merged.shares")
+		err = writeFile(mergedFile, merged, 0644)
+		if err != nil {
+			return err
+		}
+
+		results, err := env.votesTallyDecryptResults(votePlansFile, id, mergedFile, threshold)
+		if err != nil {
+			return kit.ErrorOn(err, "jcli votes tally decrypt-results", kit.B2S(results))
+		}
+		err = writeFile(filepath.Join(sharesDir, "results.json"), results, 0644)
+		if err != nil {
+			return err
+		}
+		log.Printf("TALLY - voteplan [%s] %d member shares merged and verified", id, len(sharesFiles))
+
+		cert, err := env.certificateNewVoteTallyPrivate(votePlansFile, id, mergedFile)
+		if err != nil {
+			return kit.ErrorOn(err, "jcli certificate new vote-tally private", kit.B2S(cert))
+		}
+		fragmentID, err := env.submitCertificate(node.RestAddress, txDir, "private_tally_"+id, kit.B2S(cert), env.Config.FeesCertificate, signer, true)
+		if err != nil {
+			return fmt.Errorf("voteplan [%s] private tally: %w", id, err)
+		}
+		log.Printf("TALLY - voteplan [%s] private tally submitted, fragment: %s", id, fragmentID)
+	}
+
+	_, err = waitVotePlans(ctx, "TALLY", node.RestAddress, ids, "decrypted", func(votePlans []byte, id string) bool {
+		return privateTallyState(votePlans, id) == "Decrypted"
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("TALLY - %d %s voteplans tallied", len(ids), "private")
+	return nil
+}
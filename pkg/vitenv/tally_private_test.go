@@ -0,0 +1,78 @@
+package vitenv
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// testPrivateVotePlans are the node active voteplans: vp1 encrypted, vp2 decrypted, vp3 mixed, vp4 not tallied.
+const testPrivateVotePlans = `[
+  {"id": "vp1", "payload": "private", "proposals": [
+    {"index": 0, "tally": {"Private": {"state": {"Encrypted": {"encrypted_tally": "aa"}}}}},
+    {"index": 1, "tally": {"Private": {"state": {"Encrypted": {"encrypted_tally": "bb"}}}}}
+  ]},
+  {"id": "vp2", "payload": "private", "proposals": [
+    {"index": 0, "tally": {"Private": {"state": {"Decrypted": {"result": {"results": [0, 1, 0]}}}}}}
+  ]},
+  {"id": "vp3", "payload": "private", "proposals": [
+    {"index": 0, "tally": {"Private": {"state": {"Decrypted": {"result": {"results": [0, 1, 0]}}}}}},
+    {"index": 1, "tally": {"Private": {"state": {"Encrypted": {"encrypted_tally": "bb"}}}}}
+  ]},
+  {"id": "vp4", "payload": "private", "proposals": [
+    {"index": 0, "tally": null}
+  ]}
+]`
+
+func TestPrivateTallyState(t *testing.T) {
+	tests := []struct {
+		votePlans string
+		id        string
+		want      string
+	}{
+		{testPrivateVotePlans, "vp1", "Encrypted"},
+		{testPrivateVotePlans, "vp2", "Decrypted"},
+		{testPrivateVotePlans, "vp3", ""},
+		{testPrivateVotePlans, "vp4", ""},
+		{testPrivateVotePlans, "vp5", ""},
+		{testVotePlans, "vp1", ""}, // public tally
+		{`[{"id": "vp1", "proposals": [{"tally": {"Private": {"state": {"Encrypted": {}, "Decrypted": {}}}}}]}]`, "vp1", ""},
+		{`not json`, "vp1", ""},
+	}
+
+	for _, tt := range tests {
+		if got := privateTallyState([]byte(tt.votePlans), tt.id); got != tt.want {
+			t.Errorf("privateTallyState(%s): got %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestPrivacyMemberKeys(t *testing.T) {
+	env := &Environment{VotePlanDir: t.TempDir()}
+	keys, err := env.privacyMemberKeys()
+	if err != nil || len(keys) != 0 {
+		t.Fatalf("privacyMemberKeys: got %v (%v), want none", keys, err)
+	}
+
+	var want []string
+	for i := 0; i < 3; i++ {
+		dir := privacyMemberDir(env.VotePlanDir, i)
+		if err = os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeTestFile(t, dir, "communication_key.sk", "comm_sk")
+		if i != 1 {
+			want = append(want, writeTestFile(t, dir, "member_key.sk", "member_sk"))
+		}
+	}
+	writeTestFile(t, env.VotePlanDir, "member_key.sk", "not a member dir")
+
+	keys, err = env.privacyMemberKeys()
+	if err != nil || !reflect.DeepEqual(keys, want) {
+		t.Errorf("privacyMemberKeys: got %v (%v), want %v", keys, err, want)
+	}
+	if filepath.Base(filepath.Dir(keys[1])) != "committee_member_2" {
+		t.Errorf("privacyMemberKeys: got %s", keys[1])
+	}
+}
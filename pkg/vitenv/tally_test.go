@@ -0,0 +1,183 @@
+package vitenv
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/input-output-hk/jorvit/pkg/vresult"
+)
+
+// testVotePlans are the node active voteplans: vp1 tallied, vp2 partially tallied, vp3 not tallied.
+const testVotePlans = `[
+  {"id": "vp1", "payload": "public", "proposals": [
+    {"index": 0, "tally": {"Public": {"result": {"results": [0, 1, 0], "options": {"start": 0, "end": 3}}}}, "votes_cast": 1},
+    {"index": 1, "tally": {"Public": {"result": {"results": [0, 0, 0], "options": {"start": 0, "end": 3}}}}, "votes_cast": 0}
+  ]},
+  {"id": "vp2", "payload": "public", "proposals": [
+    {"index": 0, "tally": {"Public": {"result": {"results": [0, 1, 0], "options": {"start": 0, "end": 3}}}}, "votes_cast": 1},
+    {"index": 1, "tally": null, "votes_cast": 0}
+  ]},
+  {"id": "vp3", "payload": "public", "proposals": [
+    {"index": 0, "tally": null, "votes_cast": 2}
+  ]}
+]`
+
+func TestVotePlanTallied(t *testing.T) {
+	tests := []struct {
+		votePlans string
+		id        string
+		want      bool
+	}{
+		{testVotePlans, "vp1", true},
+		{testVotePlans, "vp2", false},
+		{testVotePlans, "vp3", false},
+		{testVotePlans, "vp4", false},
+		{`[{"id": "vp1", "proposals": []}]`, "vp1", true},
+		{`{"error": "not json list"}`, "vp1", false},
+		{``, "vp1", false},
+	}
+
+	for _, tt := range tests {
+		if got := votePlanTallied([]byte(tt.votePlans), tt.id); got != tt.want {
+			t.Errorf("votePlanTallied(%s): got %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
+
+// testNodeRest serves the node stats and active voteplans, each call from the responses (the last one repeated).
+func testNodeRest(t *testing.T, stats []string, votePlans []string) string {
+	t.Helper()
+	var statsCalls, votePlansCalls int32
+	next := func(responses []string, calls *int32) string {
+		i := int(atomic.AddInt32(calls, 1)) - 1
+		if i >= len(responses) {
+			i = len(responses) - 1
+		}
+		return responses[i]
+	}
+	node := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		var body string
+		switch req.URL.Path {
+		case "/api/v0/node/stats":
+			body = next(stats, &statsCalls)
+		case "/api/v0/vote/active/plans":
+			body = next(votePlans, &votePlansCalls)
+		}
+		if body == "" {
+			http.Error(res, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = res.Write([]byte(body))
+	}))
+	t.Cleanup(node.Close)
+
+	interval := tallyPollInterval
+	tallyPollInterval = time.Millisecond
+	t.Cleanup(func() { tallyPollInterval = interval })
+
+	return strings.TrimPrefix(node.URL, "http://")
+}
+
+func TestWaitChainTime(t *testing.T) {
+	restAddress := testNodeRest(t,
+		[]string{"", `{"state": "Running", "lastBlockDate": "0.10"}`, `{"state": "Running", "lastBlockDate": "1.2"}`},
+		nil,
+	)
+
+	now, err := waitChainTime(context.Background(), "TEST", restAddress, vresult.ChainTime{Epoch: 1, SlotID: 0})
+	if err != nil || now != (vresult.ChainTime{Epoch: 1, SlotID: 2}) {
+		t.Errorf("waitChainTime: got %s (%v), want 1.2", now, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err = waitChainTime(ctx, "TEST", restAddress, vresult.ChainTime{Epoch: 2, SlotID: 0}); err != context.DeadlineExceeded {
+		t.Errorf("waitChainTime: got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestWaitVotePlans(t *testing.T) {
+	notTallied := `[{"id": "vp1", "proposals": [{"tally": null}]}, {"id": "vp3", "proposals": [{"tally": null}]}]`
+	restAddress := testNodeRest(t, nil, []string{"", notTallied, testVotePlans})
+
+	votePlans, err := waitVotePlans(context.Background(), "TEST", restAddress, []string{"vp1"}, "tallied", votePlanTallied)
+	if err != nil || string(votePlans) != testVotePlans {
+		t.Errorf("waitVotePlans: got %s (%v)", votePlans, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err = waitVotePlans(ctx, "TEST", restAddress, []string{"vp1", "vp3"}, "tallied", votePlanTallied); err != context.DeadlineExceeded {
+		t.Errorf("waitVotePlans: got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+// fakeJcliTx logs its arguments to a file (%s), the account has counter 5 on the node.
+// The transaction staging and witness files are only created.
+const fakeJcliTx = `#!/bin/sh
+echo "$@" >> "%s"
+for arg in "$@"; do
+	case "$arg" in
+	*.staging|*.witness) touch "$arg" ;;
+	esac
+done
+case "$1 $2 $3" in
+"certificate new vote-tally") echo "cert_$5" ;;
+"rest v0 account") echo '{"counter": 5, "value": 1000}' ;;
+"rest v0 message") echo "fragment" ;;
+"transaction data-for-witness "*) echo "tx_id" ;;
+esac
+`
+
+func TestTallyPublicCounter(t *testing.T) {
+	dir := t.TempDir()
+	jcliLog := filepath.Join(dir, "jcli.log")
+	useFakeJcli(t, fmt.Sprintf(fakeJcliTx, jcliLog))
+
+	tallied := `[{"id": "vp1", "proposals": []}, {"id": "vp2", "proposals": []}]`
+	restAddress := testNodeRest(t, []string{`{"state": "Running", "lastBlockDate": "1.0"}`}, []string{tallied})
+	env := &Environment{
+		Config:     Config{FeesConstant: 1, FeesCoefficient: 2, FeesCertificate: 10},
+		Schedule:   Schedule{VoteEnd: vresult.ChainTime{Epoch: 1}, CommitteeEnd: vresult.ChainTime{Epoch: 2}},
+		VotePlans:  []VotePlan{{ID: "vp1", Payload: "public"}, {ID: "vp2", Payload: "public"}},
+		Leaders:    []Leader{{Account: "ta1leader", SecretKeyFile: "leader.sk"}},
+		WorkingDir: dir,
+		Block0Hash: "block0_hash",
+	}
+	if err := env.TallyPublic(context.Background(), &NetworkNode{RestAddress: restAddress}); err != nil {
+		t.Fatalf("TallyPublic: %v", err)
+	}
+
+	calls, err := ioutil.ReadFile(jcliLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the counter is read once, then increased for each transaction
+	if n := strings.Count(string(calls), "rest v0 account get"); n != 1 {
+		t.Errorf("account reads: got %d, want %d", n, 1)
+	}
+	var counters []string
+	for _, call := range strings.Split(string(calls), "\n") {
+		args := strings.Fields(call)
+		for i := range args {
+			if args[i] == "--account-spending-counter" && i+1 < len(args) {
+				counters = append(counters, args[i+1])
+			}
+		}
+	}
+	if !reflect.DeepEqual(counters, []string{"5", "6"}) {
+		t.Errorf("witness counters: got %v, want %v", counters, []string{"5", "6"})
+	}
+	if n := strings.Count(string(calls), "transaction add-account ta1leader 13"); n != 2 {
+		t.Errorf("fee inputs: got %d, want %d", n, 2)
+	}
+}
@@ -0,0 +1,216 @@
+package vitenv
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/input-output-hk/jorvit/internal/kit"
+	"github.com/input-output-hk/jorvit/pkg/vresult"
+	"github.com/rinor/jorcli/jcli"
+)
+
+// restClient used for the node REST api queries.
+var restClient = &http.Client{Timeout: 10 * time.Second}
+
+// restGet decodes the json response of the node REST api path (ex: /v0/node/stats).
+func restGet(restAddress string, path string, out interface{}) error {
+	resp, err := restClient.Get("http://" + restAddress + "/api" + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET [%s] - %d %s - %s", path, resp.StatusCode, http.StatusText(resp.StatusCode), strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, out)
+}
+
+// nodeChainTime is the date of the node last block.
+func nodeChainTime(restAddress string) (vresult.ChainTime, error) {
+	var stats struct {
+		State         string `json:"state"`
+		LastBlockDate string `json:"lastBlockDate"`
+	}
+	err := restGet(restAddress, "/v0/node/stats", &stats)
+	if err != nil {
+		return vresult.ChainTime{}, err
+	}
+	if stats.State != "Running" {
+		return vresult.ChainTime{}, fmt.Errorf("node state [%s] - expected Running", stats.State)
+	}
+	return parseChainTime(stats.LastBlockDate)
+}
+
+// parseChainTime of "epoch.slot" format.
+func parseChainTime(date string) (vresult.ChainTime, error) {
+	parts := strings.Split(date, ".")
+	if len(parts) != 2 {
+		return vresult.ChainTime{}, fmt.Errorf("[%s] - wrong chain time, expected epoch.slot", date)
+	}
+	epoch, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return vresult.ChainTime{}, fmt.Errorf("[%s] - wrong chain time epoch: %w", date, err)
+	}
+	slot, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return vresult.ChainTime{}, fmt.Errorf("[%s] - wrong chain time slot: %w", date, err)
+	}
+	return vresult.ChainTime{Epoch: epoch, SlotID: slot}, nil
+}
+
+// chainTimeBefore reports whether a is before b.
+func chainTimeBefore(a vresult.ChainTime, b vresult.ChainTime) bool {
+	return a.Epoch < b.Epoch || (a.Epoch == b.Epoch && a.SlotID < b.SlotID)
+}
+
+// activeVotePlans as reported by the node, raw json.
+func activeVotePlans(restAddress string) ([]byte, error) {
+	var votePlans json.RawMessage
+	err := restGet(restAddress, "/v0/vote/active/plans", &votePlans)
+	return votePlans, err
+}
+
+// accountCounter returns the account spending counter and value.
+func accountCounter(restAddress string, account string) (counter uint32, value uint64, err error) {
+	state, err := jcli.RestAccount(account, "http://"+restAddress+"/api", "json")
+	if err != nil {
+		return 0, 0, kit.ErrorOn(err, "jcli.RestAccount", kit.B2S(state))
+	}
+	var accState struct {
+		Counter uint32 `json:"counter"`
+		Value   uint64 `json:"value"`
+	}
+	err = json.Unmarshal(state, &accState)
+	if err != nil {
+		return 0, 0, fmt.Errorf("account [%s] - %w", account, err)
+	}
+	return accState.Counter, accState.Value, nil
+}
+
+// certTxSigner pays the certificate transaction fees from its account (witness) and authenticates the certificate.
+// The account counter and value are read from the node on the first paid transaction, then kept locally,
+// since the node reports the new counter only once the previous transaction is in a block.
+type certTxSigner struct {
+	Account       string
+	SecretKeyFile string
+
+	synced  bool
+	counter uint32
+	value   uint64
+}
+
+// certificateFee of a transaction with one account input, no outputs and a certificate with certFee.
+func (cfg *Config) certificateFee(certFee uint64) uint64 {
+	return cfg.FeesConstant + cfg.FeesCoefficient + certFee
+}
+
+// submitCertificate builds, signs and posts (node REST) a transaction carrying cert.
+// The fees (if any) are paid from the signer account, auth is set when the certificate needs it (ex: vote tally).
+// The transaction files are kept in txDir with name as prefix. Returns the fragment id.
+// The signer counter is increased after each transaction posted, on failure it is read again from the node.
+func (env *Environment) submitCertificate(restAddress string, txDir string, name string, cert string, certFee uint64, signer *certTxSigner, auth bool) (string, error) {
+	cfg := &env.Config
+	fee := cfg.certificateFee(certFee)
+
+	err := os.MkdirAll(txDir, 0755)
+	if err != nil {
+		return "", kit.ErrorOn(err, "txDir")
+	}
+	stagingFile := filepath.Join(txDir, name+".staging")
+	_ = os.Remove(stagingFile)
+
+	out, err := jcli.TransactionNew(nil, stagingFile)
+	if err != nil {
+		return "", kit.ErrorOn(err, "jcli.TransactionNew", kit.B2S(out))
+	}
+
+	// no fees, no input needed
+	if fee > 0 {
+		if !signer.synced {
+			signer.counter, signer.value, err = accountCounter(restAddress, signer.Account)
+			if err != nil {
+				return "", err
+			}
+			signer.synced = true
+		}
+		if signer.value < fee {
+			return "", fmt.Errorf("account [%s] - value %d not enough to pay the fee %d", signer.Account, signer.value, fee)
+		}
+		out, err = jcli.TransactionAddAccount(nil, stagingFile, signer.Account, fee)
+		if err != nil {
+			return "", kit.ErrorOn(err, "jcli.TransactionAddAccount", kit.B2S(out))
+		}
+	}
+
+	out, err = jcli.TransactionAddCertificate(nil, stagingFile, cert)
+	if err != nil {
+		return "", kit.ErrorOn(err, "jcli.TransactionAddCertificate", kit.B2S(out))
+	}
+
+	out, err = jcli.TransactionFinalize(nil, stagingFile,
+		cfg.FeesCertificate, cfg.FeesCoefficient, cfg.FeesConstant,
+		cfg.FeesCertificatePoolRegistration, cfg.FeesCertificateStakeDelegation, 0,
+		cfg.FeesCertificateVoteCast, cfg.FeesCertificateVotePlan,
+		"",
+	)
+	if err != nil {
+		return "", kit.ErrorOn(err, "jcli.TransactionFinalize", kit.B2S(out))
+	}
+
+	if fee > 0 {
+		txID, err := jcli.TransactionDataForWitness(nil, stagingFile)
+		if err != nil {
+			return "", kit.ErrorOn(err, "jcli.TransactionDataForWitness", kit.B2S(txID))
+		}
+		witnessFile := filepath.Join(txDir, name+".witness")
+		out, err = jcli.TransactionMakeWitness(nil, kit.B2S(txID), env.Block0Hash, "account", signer.counter, witnessFile, signer.SecretKeyFile)
+		if err != nil {
+			return "", kit.ErrorOn(err, "jcli.TransactionMakeWitness", kit.B2S(out))
+		}
+		out, err = jcli.TransactionAddWitness(nil, stagingFile, witnessFile)
+		if err != nil {
+			return "", kit.ErrorOn(err, "jcli.TransactionAddWitness", kit.B2S(out))
+		}
+	}
+
+	out, err = jcli.TransactionSeal(nil, stagingFile)
+	if err != nil {
+		return "", kit.ErrorOn(err, "jcli.TransactionSeal", kit.B2S(out))
+	}
+
+	if auth {
+		out, err = jcli.TransactionAuth(nil, stagingFile, []string{signer.SecretKeyFile})
+		if err != nil {
+			return "", kit.ErrorOn(err, "jcli.TransactionAuth", kit.B2S(out))
+		}
+	}
+
+	messageFile := filepath.Join(txDir, name+".message")
+	out, err = jcli.TransactionToMessageFile(nil, stagingFile, messageFile)
+	if err != nil {
+		return "", kit.ErrorOn(err, "jcli.TransactionToMessage", kit.B2S(out))
+	}
+
+	fragmentID, err := jcli.RestMessagePost(nil, "http://"+restAddress+"/api", messageFile)
+	if err != nil {
+		signer.synced = false
+		return "", kit.ErrorOn(err, "jcli.RestMessagePost", kit.B2S(fragmentID))
+	}
+	if fee > 0 {
+		signer.counter++
+		signer.value -= fee
+	}
+	return kit.B2S(fragmentID), nil
+}
@@ -0,0 +1,53 @@
+package vitenv
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/input-output-hk/jorvit/pkg/vresult"
+)
+
+func TestParseChainTime(t *testing.T) {
+	tests := []struct {
+		date   string
+		want   vresult.ChainTime
+		errMsg string
+	}{
+		{date: "0.0", want: vresult.ChainTime{}},
+		{date: "12.4319", want: vresult.ChainTime{Epoch: 12, SlotID: 4319}},
+		{date: "12", errMsg: "[12] - wrong chain time, expected epoch.slot"},
+		{date: "1.2.3", errMsg: "expected epoch.slot"},
+		{date: "a.1", errMsg: "[a.1] - wrong chain time epoch"},
+		{date: "1.b", errMsg: "[1.b] - wrong chain time slot"},
+		{date: "", errMsg: "expected epoch.slot"},
+	}
+
+	for _, tt := range tests {
+		got, err := parseChainTime(tt.date)
+		switch {
+		case tt.errMsg == "" && (err != nil || got != tt.want):
+			t.Errorf("parseChainTime(%q): got %s (%v), want %s", tt.date, got, err, tt.want)
+		case tt.errMsg != "" && (err == nil || !strings.Contains(err.Error(), tt.errMsg)):
+			t.Errorf("parseChainTime(%q): got %v, want error containing %q", tt.date, err, tt.errMsg)
+		}
+	}
+}
+
+func TestChainTimeBefore(t *testing.T) {
+	tests := []struct {
+		a, b vresult.ChainTime
+		want bool
+	}{
+		{vresult.ChainTime{Epoch: 0, SlotID: 1}, vresult.ChainTime{Epoch: 0, SlotID: 2}, true},
+		{vresult.ChainTime{Epoch: 0, SlotID: 2}, vresult.ChainTime{Epoch: 0, SlotID: 2}, false},
+		{vresult.ChainTime{Epoch: 0, SlotID: 3}, vresult.ChainTime{Epoch: 0, SlotID: 2}, false},
+		{vresult.ChainTime{Epoch: 0, SlotID: 4000}, vresult.ChainTime{Epoch: 1, SlotID: 0}, true},
+		{vresult.ChainTime{Epoch: 1, SlotID: 0}, vresult.ChainTime{Epoch: 0, SlotID: 4000}, false},
+	}
+
+	for _, tt := range tests {
+		if got := chainTimeBefore(tt.a, tt.b); got != tt.want {
+			t.Errorf("chainTimeBefore(%s, %s): got %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,1132 @@
+// Package vitenv generates (and runs) a VIT environment: keys, voteplans, block0,
+// jörmungandr node and vit-servicing-station configs, and the app proxy.
+package vitenv
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocarina/gocsv"
+	"github.com/input-output-hk/jorvit/internal/datastore"
+	"github.com/input-output-hk/jorvit/internal/kit"
+	"github.com/input-output-hk/jorvit/internal/loader"
+	"github.com/input-output-hk/jorvit/pkg/vcli"
+	"github.com/input-output-hk/jorvit/pkg/vresult"
+	"github.com/input-output-hk/jorvit/pkg/vstation"
+	"github.com/rinor/jorcli/jcli"
+	"github.com/rinor/jorcli/jnode"
+	"golang.org/x/crypto/blake2b"
+	"gopkg.in/yaml.v2"
+)
+
+// Directories and files within the main working dir "jnode_VIT_xxxxx"
+const (
+	VotePlanDir      = "vote_plans"
+	VitStationDir    = "vit_station"
+	ConfigFile       = "vitconfig.yaml"
+	Block0BinFile    = "VIT-block0.bin"
+	Block0TxtFile    = "VIT-block0.yaml"
+	NodeConfigFile   = "node-config.yaml"
+	NodeStorageDir   = "storage"
+	FundsCsvFile     = "sql_funds.csv"
+	VotePlansCsvFile = "sql_voteplans.csv"
+	ProposalsCsvFile = "sql_proposals.csv"
+	VitDbFile        = "database.sqlite3"
+	VitConfigFile    = "vit_cfg.json"
+)
+
+// Leader is a BFT leader, the secret key (and its files) is available only if generated or provided.
+type Leader struct {
+	SecretKey        string `json:"secret_key,omitempty"`
+	PublicKey        string `json:"public_key"`
+	Account          string `json:"account"`
+	SecretKeyFile    string `json:"secret_key_file,omitempty"`
+	SecretConfigFile string `json:"secret_config_file,omitempty"`
+}
+
+// CommitteeMember is a global committee (auth) member.
+type CommitteeMember struct {
+	PublicKey string `json:"public_key"`
+	Account   string `json:"account,omitempty"`
+}
+
+// VotePlan generated for a group of proposals of the same payload type.
+type VotePlan struct {
+	ID               string            `json:"id"`
+	Payload          string            `json:"payload"`
+	VoteStart        vresult.ChainTime `json:"vote_start"`
+	VoteEnd          vresult.ChainTime `json:"vote_end"`
+	CommitteeEnd     vresult.ChainTime `json:"committee_end"`
+	Proposals        int               `json:"proposals"`
+	ConfigFile       string            `json:"config_file"`
+	CertUnsignedFile string            `json:"cert_unsigned_file"`
+	CertSignedFile   string            `json:"cert_signed_file,omitempty"`
+}
+
+// Environment reports all the artifacts of a generated VIT environment.
+type Environment struct {
+	Config   Config   `json:"config"` // effective config
+	Schedule Schedule `json:"schedule"`
+
+	WorkingDir    string `json:"working_dir"`
+	VotePlanDir   string `json:"vote_plan_dir"`
+	VitStationDir string `json:"vit_station_dir"`
+	ConfigFile    string `json:"config_file"`
+
+	Leaders   []Leader          `json:"leaders"`
+	Committee []CommitteeMember `json:"committee"`
+	Privacy   PrivacyCommittee  `json:"privacy"`
+
+	StakePools []StakePool `json:"stake_pools,omitempty"` // genesis_praos only
+	VotePlans  []VotePlan  `json:"vote_plans"`
+
+	Block0BinFile  string `json:"block0_bin_file"`
+	Block0TxtFile  string `json:"block0_txt_file"`
+	Block0Hash     string `json:"block0_hash"`
+	NodeConfigFile string `json:"node_config_file"`
+	NodeStorageDir string `json:"node_storage_dir"`
+
+	// the network nodes, the first one is also reported as the main node (Node, NodeConfigFile, RestAddress, ...)
+	Nodes []*NetworkNode `json:"nodes"`
+
+	VotingPowerReportFile string  `json:"voting_power_report_file,omitempty"`
+	VotersCsvFile         string  `json:"voters_csv_file,omitempty"`
+	VotersJSONFile        string  `json:"voters_json_file,omitempty"`
+	Voters                []Voter `json:"-"`
+
+	FundsCsvFile     string `json:"funds_csv_file"`
+	VotePlansCsvFile string `json:"vote_plans_csv_file"`
+	ProposalsCsvFile string `json:"proposals_csv_file"`
+	VitDbFile        string `json:"vit_db_file,omitempty"` // set only if vit-servicing-station-cli is available
+	VitConfigFile    string `json:"vit_config_file"`
+
+	ProxyAddress      string `json:"proxy_address"`
+	RestAddress       string `json:"rest_address"`
+	P2PListenAddress  string `json:"p2p_listen_address"`
+	VitStationAddress string `json:"vit_station_address"`
+
+	JcliBin         string `json:"jcli_bin"`
+	JcliVersion     string `json:"jcli_version"`
+	JnodeBin        string `json:"jnode_bin"`
+	JnodeVersion    string `json:"jnode_version"`
+	VcliBin         string `json:"vcli_bin,omitempty"`
+	VcliVersion     string `json:"vcli_version,omitempty"`
+	VstationBin     string `json:"vstation_bin,omitempty"`
+	VstationVersion string `json:"vstation_version,omitempty"`
+
+	// memory processing stores, served from the proxy
+	Proposals datastore.ProposalsStore `json:"-"`
+	Funds     datastore.FundsStore     `json:"-"`
+	Block0Bin []byte                   `json:"-"`
+
+	Node    *jnode.Jnode       `json:"-"`
+	Station *vstation.Vstation `json:"-"`
+
+	// running services
+	proxy        *http.Server
+	vitStarted   bool
+	proxyStopped chan struct{}
+}
+
+type jcliProposal struct {
+	ExternalID  string `json:"external_id"`
+	Options     uint8  `json:"options"`
+	Action      string `json:"action"`
+	ChallengeID uint32 `json:"challenge_id"`
+}
+
+type jcliVotePlan struct {
+	Payload                   string            `json:"payload_type"`
+	VoteStart                 vresult.ChainTime `json:"vote_start"`
+	VoteEnd                   vresult.ChainTime `json:"vote_end"`
+	CommitteeEnd              vresult.ChainTime `json:"committee_end"`
+	Proposals                 []jcliProposal    `json:"proposals"`
+	CommitteeMemberPublicKeys []string          `json:"committee_member_public_keys"` // privacy encyption keys
+	VotePlanID                string            `json:"-"`
+	Certificate               string            `json:"-"`
+}
+
+func timeTrack(start time.Time, name string) {
+	elapsed := time.Since(start)
+	log.Printf("%s took %s", name, elapsed)
+}
+
+func loadProposals(file string) (datastore.ProposalsStore, error) {
+	defer timeTrack(time.Now(), "Proposals File load")
+	proposals := &datastore.Proposals{}
+	return proposals, proposals.Initialize(file)
+}
+
+func loadFundInfo(file string) (datastore.FundsStore, error) {
+	defer timeTrack(time.Now(), "Fund File load")
+	funds := &datastore.Funds{}
+	return funds, funds.Initialize(file)
+}
+
+func votePlansNeeded(proposalsTot int, max int) int {
+	votePlansNeeded, more := proposalsTot/max, proposalsTot%max
+	if more > 0 {
+		votePlansNeeded = votePlansNeeded + 1
+	}
+	return votePlansNeeded
+}
+
+// proposalsByPayload groups the proposals per payload type.
+func proposalsByPayload(proposals []*loader.ProposalData) map[string][]*loader.ProposalData {
+	payloadProposals := make(map[string][]*loader.ProposalData)
+	for _, p := range proposals {
+		payloadProposals[p.VoteType] = append(payloadProposals[p.VoteType], p)
+	}
+	return payloadProposals
+}
+
+// splitVotePlans associates the proposals to the voteplans, setting their chain external id.
+// Since there is a limit of proposals a plan can have (255), each payload may need more voteplans.
+// Payloads are processed sorted, so the voteplans order is always the same.
+func splitVotePlans(payloadProposals map[string][]*loader.ProposalData, max int) []jcliVotePlan {
+	payloads := make([]string, 0, len(payloadProposals))
+	for pt := range payloadProposals {
+		payloads = append(payloads, pt)
+	}
+	sort.Strings(payloads)
+
+	// Calculate nr of needed voteplans taking in consideration also payload
+	vpNeeded := 0
+	for _, vpp := range payloadProposals {
+		vpNeeded += votePlansNeeded(len(vpp), max)
+	}
+
+	jcliVotePlans := make([]jcliVotePlan, vpNeeded)
+
+	jcliVotePlansCreated := 0
+	for _, pt := range payloads {
+		vpi := 0
+		// Generate proposals hash and associate it to a voteplan
+		for i, proposal := range payloadProposals[pt] {
+
+			// tmp - hash the proposal (TODO: decide what to hash in production, file bytes ???)
+			externalID := blake2b.Sum256([]byte(proposal.Proposal.ID + strconv.FormatUint(proposal.InternalID, 10) + pt))
+			proposal.ChainProposal.ExternalID = hex.EncodeToString(externalID[:])
+
+			// retrieve the voteplan internal index based on the proposal index we are at
+			// taking in consideration also previous payloads voteplans created
+			vpi = (i / max) + jcliVotePlansCreated
+
+			// Set payload once
+			if jcliVotePlans[vpi].Payload == "" {
+				jcliVotePlans[vpi].Payload = pt
+			}
+
+			// add proposal hash to the respective voteplan internal container
+			jcliVotePlans[vpi].Proposals = append(
+				jcliVotePlans[vpi].Proposals,
+				jcliProposal{
+					ExternalID:  proposal.ChainProposal.ExternalID,
+					Options:     uint8(len(proposal.ChainProposal.VoteOptions)),
+					Action:      proposal.VoteAction,
+					ChallengeID: proposal.ChallengeID,
+				},
+			)
+		}
+		jcliVotePlansCreated = vpi + 1 // vpi is an index (already counting the previous payloads) so we need +1
+	}
+
+	return jcliVotePlans
+}
+
+// bftLeader is a BFT leader key, provided (secret key file or public key) or to be generated.
+type bftLeader struct {
+	SecretKeyFile string // provided secret key
+	SecretKey     string // set by the keys callback, if known
+	PublicKey     string // empty if not known
+	Generated     bool
+}
+
+// bftLeaders picks the BFT leaders: the provided secret keys first, then the public keys, then generated ones
+// up to Config.BftLeaderMin. keys sets the public key (if known) of the i-th leader, from its secret key file
+// or generating a new key. Leaders with an already picked public key are skipped, and reported in skipped.
+func (cfg *Config) bftLeaders(keys func(i int, leader *bftLeader) error) (leaders []bftLeader, skipped []string, err error) {
+	leaders = make([]bftLeader, 0, cfg.BftLeaderMin)
+	pubKeys := make(map[string]bool, cfg.BftLeaderMin)
+
+	var skIdx, pkIdx int
+	for uint(len(leaders)) < cfg.BftLeaderMin {
+		var leader bftLeader
+		switch {
+		case skIdx < len(cfg.BftLeaderSecretKeys):
+			leader.SecretKeyFile = cfg.BftLeaderSecretKeys[skIdx]
+			skIdx++
+			err = keys(len(leaders), &leader)
+		case pkIdx < len(cfg.BftLeaderPublicKeys):
+			leader.PublicKey = cfg.BftLeaderPublicKeys[pkIdx]
+			pkIdx++
+		default:
+			leader.Generated = true
+			err = keys(len(leaders), &leader)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if leader.PublicKey != "" {
+			if pubKeys[leader.PublicKey] {
+				// the same generated key would be picked again
+				if leader.Generated {
+					return nil, nil, fmt.Errorf("generated BFT leader %d - public key already used: %s", len(leaders), leader.PublicKey)
+				}
+				skipped = append(skipped, leader.PublicKey)
+				continue
+			}
+			pubKeys[leader.PublicKey] = true
+		}
+		leaders = append(leaders, leader)
+	}
+	return leaders, skipped, nil
+}
+
+// committeeKeys returns Config.CommitteeAuthPublicKeys without the duplicates and the BFT leaders ones,
+// these are reported in skipped.
+func (cfg *Config) committeeKeys(leaders []bftLeader) (keys []string, skipped []string) {
+	used := make(map[string]bool, len(leaders)+len(cfg.CommitteeAuthPublicKeys))
+	for _, leader := range leaders {
+		if leader.PublicKey != "" {
+			used[leader.PublicKey] = true
+		}
+	}
+	for _, pk := range cfg.CommitteeAuthPublicKeys {
+		if used[pk] {
+			skipped = append(skipped, pk)
+			continue
+		}
+		used[pk] = true
+		keys = append(keys, pk)
+	}
+	return keys, skipped
+}
+
+// planVotePlans checks the proposals and splits them in voteplans (see splitVotePlans) over the schedule vote period.
+// signers is the number of leaders with a secret key, needed when the voteplans go in block0 or are submitted.
+func (cfg *Config) planVotePlans(proposals []*loader.ProposalData, schedule *Schedule, signers int) ([]jcliVotePlan, error) {
+	// proposals internal id needs to be unique, since it's used to match them
+	ids := make(map[uint64]bool, len(proposals))
+	for _, p := range proposals {
+		if ids[p.InternalID] {
+			return nil, fmt.Errorf("%s - duplicate internal_id [%d]", cfg.Proposals, p.InternalID)
+		}
+		ids[p.InternalID] = true
+	}
+
+	payloadProposals := proposalsByPayload(proposals)
+
+	// check if we have privacy committee members when we don't have private voteplans
+	if len(payloadProposals["private"]) == 0 && len(cfg.CommitteePrivacyPublicKeys) > 0 {
+		return nil, fmt.Errorf(" %s provided, but no %s proposals found", "committee-privacy-public-key", "private")
+	}
+
+	if cfg.Block0VotePlan && signers == 0 {
+		return nil, fmt.Errorf("%s: no [%s] available to sign the block0 certificate(s)", "block0-voteplan", "bft leader SK (secret key)")
+	}
+	if cfg.SubmitVotePlans && signers == 0 {
+		return nil, fmt.Errorf("%s: no [%s] available to sign the voteplans transaction(s)", "submit-voteplans", "bft leader SK (secret key)")
+	}
+
+	votePlans := splitVotePlans(payloadProposals, int(cfg.VotePlanProposalsMax))
+	for i := range votePlans {
+		votePlans[i].VoteStart = schedule.VoteStart
+		votePlans[i].VoteEnd = schedule.VoteEnd
+		votePlans[i].CommitteeEnd = schedule.CommitteeEnd
+	}
+	return votePlans, nil
+}
+
+// outputDir creates (if needed) the fixed working directory dir, that has to be empty.
+func outputDir(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return "", err
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(files) > 0 {
+		return "", fmt.Errorf("[%s: %s] - not empty", "output-dir", dir)
+	}
+	return dir, nil
+}
+
+// generateKey returns the key name from Config.KeyDir if already there, otherwise generates it with gen.
+// The seed provided to gen is derived from Config.KeySeed and name (empty for random keys).
+// The new key is stored in Config.KeyDir (if set) so that following runs reuse it.
+func (env *Environment) generateKey(name string, gen func(seed string) ([]byte, error)) ([]byte, error) {
+	var keyFile string
+	if env.Config.KeyDir != "" {
+		keyFile = filepath.Join(env.Config.KeyDir, name)
+		key, err := ioutil.ReadFile(keyFile)
+		switch {
+		case err == nil:
+			return key, nil
+		case !os.IsNotExist(err):
+			return nil, kit.ErrorOn(err, "key-dir", name)
+		}
+	}
+
+	var seed string
+	if env.Config.KeySeed != "" {
+		sum := blake2b.Sum256([]byte(env.Config.KeySeed + "/" + name))
+		seed = hex.EncodeToString(sum[:])
+	}
+
+	key, err := gen(seed)
+	if err != nil {
+		return nil, kit.ErrorOn(err, name, kit.B2S(key))
+	}
+
+	if keyFile != "" {
+		err = os.MkdirAll(env.Config.KeyDir, 0755)
+		if err != nil {
+			return nil, kit.ErrorOn(err, "key-dir")
+		}
+		err = writeFile(keyFile, key, 0600)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// writeFile creates file with data.
+func writeFile(file string, data []byte, perm os.FileMode) error {
+	err := ioutil.WriteFile(file, data, perm)
+	if err != nil {
+		return kit.ErrorOn(err, "WRITE", file)
+	}
+	return nil
+}
+
+// Generate builds a new VIT environment from cfg: keys, voteplans, block0, node and vit station configs.
+// The services are not started, use Start for that.
+func Generate(ctx context.Context, cfg Config) (*Environment, error) {
+	schedule, err := cfg.Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	nodeAddr, nodePort, err := splitAddrPort("node", cfg.Node)
+	if err != nil {
+		return nil, err
+	}
+
+	env := &Environment{
+		Config:   cfg,
+		Schedule: *schedule,
+
+		// Proxy
+		ProxyAddress: cfg.Proxy,
+		// Rest
+		RestAddress: cfg.Rest,
+		// Vit station
+		VitStationAddress: cfg.VitStation,
+	}
+
+	env.Proposals, err = loadProposals(cfg.Proposals)
+	if err != nil {
+		return nil, kit.ErrorOn(err, "loadProposals")
+	}
+	env.Funds, err = loadFundInfo(cfg.Fund)
+	if err != nil {
+		return nil, kit.ErrorOn(err, "loadFundInfo")
+	}
+
+	// parsed and validated upfront, merged into block0 initial entries later on
+	extraData := &GenesisExtraData{}
+	if cfg.GenesisExtraData != "" {
+		extraData, err = loadGenesisExtraData(cfg.GenesisExtraData, cfg.Discrimination)
+		if err != nil {
+			return nil, kit.ErrorOn(err, "genesis-extra-data")
+		}
+	}
+	var votingPower *VotingPowerReport
+	if cfg.VotingPowerSnapshot != "" {
+		votingPower, err = importVotingPower(cfg.VotingPowerSnapshot, cfg.Discrimination, uint64(env.Funds.First().VotingPowerThreshold))
+		if err != nil {
+			return nil, kit.ErrorOn(err, "voting-power-snapshot")
+		}
+	}
+
+	var (
+		discrimination = jcliDiscrimination(cfg.Discrimination) // "" (empty defaults to "production")
+		addrPrefix     = addressPrefix(cfg.Discrimination)
+	)
+	env.P2PListenAddress = p2pAddress(nodeAddr, nodePort)
+
+	// Check for jcli binary. Local folder first (jor_bins), then PATH
+	env.JcliBin, err = kit.FindExecutable("jcli", "jor_bins")
+	if err != nil {
+		return nil, err
+	}
+	jcli.BinName(env.JcliBin)
+
+	// get jcli version
+	jcliVersion, err := jcli.VersionFull()
+	if err != nil {
+		return nil, kit.ErrorOn(err, kit.B2S(jcliVersion))
+	}
+	env.JcliVersion = kit.B2S(jcliVersion)
+
+	/* Working directories */
+
+	// create a new working directory
+	if cfg.BaseDir != "" {
+		err = os.MkdirAll(cfg.BaseDir, 0755)
+		if err != nil {
+			return nil, kit.ErrorOn(err, "BaseDir")
+		}
+	}
+	if cfg.OutputDir != "" {
+		env.WorkingDir, err = outputDir(cfg.OutputDir)
+	} else {
+		env.WorkingDir, err = ioutil.TempDir(cfg.BaseDir, "jnode_VIT_")
+	}
+	if err != nil {
+		return nil, kit.ErrorOn(err, "workingDir")
+	}
+	log.Printf("Working Directory: %s", env.WorkingDir)
+
+	// effective config, to be able to rebuild the same environment
+	cfgYaml, err := yaml.Marshal(&cfg)
+	if err != nil {
+		return nil, kit.ErrorOn(err, "yaml.Marshal Config")
+	}
+	env.ConfigFile = filepath.Join(env.WorkingDir, ConfigFile)
+	err = writeFile(env.ConfigFile, cfgYaml, 0644)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Effective config: %s", env.ConfigFile)
+
+	// directory to dump the voteplan(s) config(s) and certificate(s)
+	env.VotePlanDir = filepath.Join(env.WorkingDir, VotePlanDir)
+	err = os.Mkdir(env.VotePlanDir, 0755)
+	if err != nil {
+		return nil, kit.ErrorOn(err, "votePlanDir")
+	}
+
+	// directory to dump the vit servicing station configs
+	env.VitStationDir = filepath.Join(env.WorkingDir, VitStationDir)
+	err = os.Mkdir(env.VitStationDir, 0755)
+	if err != nil {
+		return nil, kit.ErrorOn(err, "vitStationDir")
+	}
+
+	/* BFT LEADER(s) */
+
+	if err = ctx.Err(); err != nil {
+		return env, err
+	}
+
+	leaders, skipped, err := cfg.bftLeaders(func(i int, leader *bftLeader) error {
+		var (
+			leaderSK []byte
+			err      error
+		)
+		if leader.Generated {
+			leaderSK, err = env.generateKey(strconv.Itoa(i)+"_bft_secret.key", func(seed string) ([]byte, error) {
+				return jcli.KeyGenerate(seed, "Ed25519", "")
+			})
+			if err != nil {
+				return err
+			}
+		} else {
+			leaderSK, err = ioutil.ReadFile(leader.SecretKeyFile)
+			if err != nil {
+				return kit.ErrorOn(err, leader.SecretKeyFile)
+			}
+		}
+		leaderPK, err := jcli.KeyToPublic(leaderSK, "", "")
+		if err != nil {
+			return kit.ErrorOn(err, kit.B2S(leaderPK))
+		}
+		leader.SecretKey, leader.PublicKey = kit.B2S(leaderSK), kit.B2S(leaderPK)
+		return nil
+	})
+	if err != nil {
+		return env, err
+	}
+	for _, pk := range skipped {
+		log.Printf("***** Duplicate BFT Leader skip: %s *****", pk)
+		log.Println()
+	}
+
+	env.Leaders = make([]Leader, 0, len(leaders))
+	for i, leader := range leaders {
+		leaderACC, err := jcli.AddressAccount(leader.PublicKey, addrPrefix, discrimination)
+		if err != nil {
+			return env, kit.ErrorOn(err, kit.B2S(leaderACC))
+		}
+
+		var bftSecretFile string
+		if leader.SecretKey != "" {
+			// Needed later on to sign
+			bftSecretFile = filepath.Join(env.WorkingDir, strconv.Itoa(i)+"_bft_secret.key")
+			err = writeFile(bftSecretFile, []byte(leader.SecretKey), 0744)
+			if err != nil {
+				return env, err
+			}
+		}
+
+		env.Leaders = append(env.Leaders, Leader{
+			SecretKey:     leader.SecretKey,
+			PublicKey:     leader.PublicKey,
+			Account:       kit.B2S(leaderACC),
+			SecretKeyFile: bftSecretFile,
+		})
+	}
+
+	/////////////////////
+	//  block0 config  //
+	/////////////////////
+
+	block0cfg := jnode.NewBlock0Config()
+
+	// set/change config params
+	block0cfg.BlockchainConfiguration.Block0Date = schedule.GenesisTime.Unix()
+	block0cfg.BlockchainConfiguration.Block0Consensus = cfg.Consensus
+	block0cfg.BlockchainConfiguration.Discrimination = block0Discrimination(cfg.Discrimination)
+
+	block0cfg.BlockchainConfiguration.SlotDuration = uint8(schedule.SlotDuration.Seconds())
+	block0cfg.BlockchainConfiguration.SlotsPerEpoch = schedule.SlotsPerEpoch()
+	block0cfg.BlockchainConfiguration.EpochStabilityDepth = cfg.EpochStabilityDepth
+
+	block0cfg.BlockchainConfiguration.ConsensusGenesisPraosActiveSlotCoeff = cfg.PraosActiveSlotCoeff
+	block0cfg.BlockchainConfiguration.KesUpdateSpeed = cfg.KesUpdateSpeed
+
+	block0cfg.BlockchainConfiguration.LinearFees.Certificate = cfg.FeesCertificate
+	block0cfg.BlockchainConfiguration.LinearFees.Coefficient = cfg.FeesCoefficient
+	block0cfg.BlockchainConfiguration.LinearFees.Constant = cfg.FeesConstant
+
+	block0cfg.BlockchainConfiguration.LinearFees.PerCertificateFees.CertificatePoolRegistration = cfg.FeesCertificatePoolRegistration
+	block0cfg.BlockchainConfiguration.LinearFees.PerCertificateFees.CertificateStakeDelegation = cfg.FeesCertificateStakeDelegation
+
+	block0cfg.BlockchainConfiguration.LinearFees.PerVoteCertificateFees.CertificateVoteCast = cfg.FeesCertificateVoteCast
+	block0cfg.BlockchainConfiguration.LinearFees.PerVoteCertificateFees.CertificateVotePlan = cfg.FeesCertificateVotePlan
+
+	block0cfg.BlockchainConfiguration.FeesGoTo = cfg.FeesGoTo
+
+	// Bft Leader
+	for i := range env.Leaders {
+		err = block0cfg.AddConsensusLeader(env.Leaders[i].PublicKey)
+		if err != nil {
+			return env, kit.ErrorOn(err, "AddConsensusLeader")
+		}
+
+		// add bft leader(s) accounts to block0 (with bftLeaderFund value > 0)
+		if cfg.BftLeaderFund > 0 {
+			err = block0cfg.AddInitialFund(env.Leaders[i].Account, cfg.BftLeaderFund)
+			if err != nil {
+				return env, kit.ErrorOn(err, "AddInitialFund")
+			}
+		}
+	}
+
+	// Global Committee Members list
+	committeeKeys, skipped := cfg.committeeKeys(leaders)
+	for _, pk := range skipped {
+		log.Printf("***** Duplicate Committee member, skip: %s *****", pk)
+		log.Println()
+	}
+	for _, committeePK := range committeeKeys {
+		pk, err := jcli.KeyToBytes([]byte(committeePK), "", "")
+		if err != nil {
+			return env, kit.ErrorOn(err, kit.B2S(pk))
+		}
+		block0cfg.AddCommittee(kit.B2S(pk))
+
+		member := CommitteeMember{PublicKey: committeePK}
+
+		// add committee accounts to block0 (with committeeFund value > 0)
+		if cfg.CommitteeAuthFund > 0 {
+			comACC, err := jcli.AddressAccount(committeePK, addrPrefix, discrimination)
+			if err != nil {
+				return env, kit.ErrorOn(err, kit.B2S(comACC))
+			}
+			err = block0cfg.AddInitialFund(kit.B2S(comACC), cfg.CommitteeAuthFund)
+			if err != nil {
+				return env, kit.ErrorOn(err, "AddInitialFund")
+			}
+			member.Account = kit.B2S(comACC)
+		}
+
+		env.Committee = append(env.Committee, member)
+	}
+
+	// Stake Pools (genesis_praos)
+	if cfg.Consensus == "genesis_praos" {
+		err = env.generateStakePools(block0cfg, addrPrefix, discrimination)
+		if err != nil {
+			return env, err
+		}
+	}
+
+	// Test voters wallets
+	if cfg.Voters > 0 {
+		err = env.generateVoters(block0cfg, addrPrefix, discrimination)
+		if err != nil {
+			return env, err
+		}
+		log.Printf("VIT - %d test voter(s) dumped at (%s)", len(env.Voters), env.VotersJSONFile)
+	}
+
+	certSignersFiles := make([]string, 0) //, 0, len(leaders))
+	for i := range env.Leaders {
+		// we need a secret key
+		if env.Leaders[i].SecretKeyFile == "" {
+			continue
+		}
+		certSignersFiles = append(certSignersFiles, env.Leaders[i].SecretKeyFile)
+		break // right now only one key is needed to sign a certificate so bail as soon as we have one
+	}
+
+	jcliVotePlans, err := cfg.planVotePlans(*env.Proposals.All(), schedule, len(certSignersFiles))
+	if err != nil {
+		return env, err
+	}
+	env.Funds.First().VotePlans = make([]loader.ChainVotePlan, len(jcliVotePlans))
+
+	var private bool
+	for i := range jcliVotePlans {
+		private = private || jcliVotePlans[i].Payload == "private"
+	}
+
+	env.Privacy.MemberPublicKeys = append([]string{}, cfg.CommitteePrivacyPublicKeys...)
+
+	// check we have also privacy committee members when we have private voteplans
+	if private && len(env.Privacy.MemberPublicKeys) == 0 {
+		log.Printf("%s proposals found, but no %s provided...building them for you in %s (members: %d, threshold: %d)", "private", "committee-privacy-public-key", env.VotePlanDir, cfg.CommitteePrivacyMembers, cfg.CommitteePrivacyThreshold)
+
+		err = env.generatePrivacyCommittee()
+		if err != nil {
+			return env, err
+		}
+		log.Println()
+	}
+
+	// save vote encryption key
+	if private && len(env.Privacy.MemberPublicKeys) > 0 {
+		env.Privacy.VoteEncryptionKeyFile = filepath.Join(env.VotePlanDir, VoteEncryptionKeyFile)
+
+		voteEncKey, err := jcli.VotesEncryptingVoteKey(env.Privacy.MemberPublicKeys, "" /* voteEncKeyFile */)
+		if err != nil {
+			return env, kit.ErrorOn(err, "jcli.VotesEncryptingVoteKey", kit.B2S(voteEncKey))
+		}
+		err = writeFile(env.Privacy.VoteEncryptionKeyFile, voteEncKey, 0644)
+		if err != nil {
+			return env, err
+		}
+		env.Privacy.VoteEncryptionKey = kit.B2S(voteEncKey)
+	}
+
+	if err = ctx.Err(); err != nil {
+		return env, err
+	}
+
+	// Generate voteplan certificates and id
+	for i := range jcliVotePlans {
+
+		// Add committee privacy public keys if VotePlan payload is private
+		switch jcliVotePlans[i].Payload {
+		case "private":
+			jcliVotePlans[i].CommitteeMemberPublicKeys = env.Privacy.MemberPublicKeys
+		case "public":
+			jcliVotePlans[i].CommitteeMemberPublicKeys = []string{}
+		}
+
+		stdinConfig, err := json.MarshalIndent(jcliVotePlans[i], "", " ")
+		if err != nil {
+			return env, kit.ErrorOn(err, "json.Marshal VotePlan Config")
+		}
+
+		ucert, err := jcli.CertificateNewVotePlan(stdinConfig, "", "")
+		if err != nil {
+			return env, kit.ErrorOn(err, "CertificateNewVotePlan", kit.B2S(ucert))
+		}
+
+		id, err := jcli.CertificateGetVotePlanID(ucert, "", "")
+		if err != nil {
+			return env, kit.ErrorOn(err, "CertificateGetVotePlanID:", kit.B2S(id))
+		}
+
+		jcliVotePlans[i].VotePlanID = kit.B2S(id)
+
+		// Assuming that bft leaders will be part of committee signing keys
+		scert := []byte{}
+		if cfg.Block0VotePlan {
+			scert, err = jcli.CertificateSign(ucert, certSignersFiles, "", "")
+			if err != nil {
+				return env, kit.ErrorOn(err, "CertificateSign:", kit.B2S(scert))
+			}
+
+			jcliVotePlans[i].Certificate = kit.B2S(scert)
+		}
+
+		vpFile := filepath.Join(env.VotePlanDir, jcliVotePlans[i].Payload+"_voteplan_"+kit.B2S(id))
+		votePlan := VotePlan{
+			ID:               jcliVotePlans[i].VotePlanID,
+			Payload:          jcliVotePlans[i].Payload,
+			VoteStart:        jcliVotePlans[i].VoteStart,
+			VoteEnd:          jcliVotePlans[i].VoteEnd,
+			CommitteeEnd:     jcliVotePlans[i].CommitteeEnd,
+			Proposals:        len(jcliVotePlans[i].Proposals),
+			ConfigFile:       vpFile + ".json",
+			CertUnsignedFile: vpFile + ".cert-unsigned",
+		}
+
+		// VotePlan - configuration
+		err = writeFile(votePlan.ConfigFile, stdinConfig, 0644)
+		if err != nil {
+			return env, err
+		}
+
+		// VotePlan - unsigned certificate
+		err = writeFile(votePlan.CertUnsignedFile, ucert, 0644)
+		if err != nil {
+			return env, err
+		}
+
+		// VotePlan - signed certificate
+		if len(scert) > 0 {
+			votePlan.CertSignedFile = vpFile + ".cert-signed"
+			err = writeFile(votePlan.CertSignedFile, scert, 0644)
+			if err != nil {
+				return env, err
+			}
+		}
+
+		env.VotePlans = append(env.VotePlans, votePlan)
+
+		// Update Fund info with VotePlans Data - TODO: when defined update to support multiple funds
+		fund := env.Funds.First()
+		fund.VotePlans[i].VotePlanID = jcliVotePlans[i].VotePlanID
+		fund.VotePlans[i].VoteStart = schedule.VoteStartTime.Format(cfg.TimeFormat)
+		fund.VotePlans[i].VoteEnd = schedule.VoteEndTime.Format(cfg.TimeFormat)
+		fund.VotePlans[i].CommitteeEnd = schedule.CommitteeEndTime.Format(cfg.TimeFormat)
+		fund.VotePlans[i].Payload = jcliVotePlans[i].Payload
+
+		fund.VotePlans[i].FundID = fund.FundID
+		fund.VotePlans[i].VpInternalID = strconv.Itoa(i + 1)
+
+		// set chain_vote_encryption_key for the api
+		if jcliVotePlans[i].Payload == "private" {
+			fund.VotePlans[i].VoteEncryptionKey = env.Privacy.VoteEncryptionKey
+		}
+
+		// Update proposals index and voteplan
+		for pi, prop := range jcliVotePlans[i].Proposals {
+			// TODO: fix this search
+			proposal := datastore.FilterSingle(
+				env.Proposals.All(),
+				func(v *loader.ProposalData) bool {
+					return v.ChainProposal.ExternalID == prop.ExternalID
+				},
+			)
+
+			proposal.ChainProposal.Index = uint8(pi)
+			proposal.ChainVotePlan = &(fund.VotePlans[i])
+		}
+
+		if cfg.Block0VotePlan {
+			// Vote Plans add certificate to block0
+			err = block0cfg.AddInitialCertificate(jcliVotePlans[i].Certificate)
+			if err != nil {
+				return env, kit.ErrorOn(err, "AddInitialCertificate")
+			}
+		}
+	}
+
+	log.Printf("VIT - Voteplan(s) data are dumped at (%s)", env.VotePlanDir)
+	log.Println()
+
+	err = env.dumpVitStationData()
+	if err != nil {
+		return env, err
+	}
+
+	if err = ctx.Err(); err != nil {
+		return env, err
+	}
+
+	if len(extraData.Initial) > 0 {
+		log.Printf("VIT - extra genesis data: %s", extraData.Summary)
+		block0cfg.Initial = append(block0cfg.Initial, extraData.Initial...)
+	}
+	if votingPower != nil {
+		log.Printf("VIT - voting power snapshot: %s", votingPower)
+		block0cfg.Initial = append(block0cfg.Initial, votingPower.initial()...)
+
+		env.VotingPowerReportFile = filepath.Join(env.WorkingDir, VotingPowerReportFile)
+		report, err := json.MarshalIndent(votingPower, "", "  ")
+		if err != nil {
+			return env, kit.ErrorOn(err, "voting power report")
+		}
+		err = writeFile(env.VotingPowerReportFile, report, 0644)
+		if err != nil {
+			return env, err
+		}
+	}
+	err = checkInitialFunds(block0cfg.Initial)
+	if err != nil {
+		return env, kit.ErrorOn(err, "block0 initial")
+	}
+	initialSummary, err := summarizeInitial(block0cfg.Initial)
+	if err != nil {
+		return env, kit.ErrorOn(err, "block0 initial")
+	}
+	log.Printf("VIT - block0 initial: %s", initialSummary)
+	log.Println()
+
+	block0Yaml, err := block0cfg.ToYaml()
+	if err != nil {
+		return env, kit.ErrorOn(err, "block0cfg.ToYaml")
+	}
+
+	// need this file for starting the node (--genesis-block)
+	env.Block0BinFile = filepath.Join(env.WorkingDir, Block0BinFile)
+
+	// keep also the text block0 config
+	env.Block0TxtFile = filepath.Join(env.WorkingDir, Block0TxtFile)
+
+	// block0BinFile will be created by jcli
+	env.Block0Bin, err = jcli.GenesisEncode(block0Yaml, "", env.Block0BinFile)
+	if err != nil {
+		return env, kit.ErrorOn(err, kit.B2S(env.Block0Bin), kit.B2S(block0Yaml))
+	}
+
+	block0Hash, err := jcli.GenesisHash(env.Block0Bin, "")
+	if err != nil {
+		return env, kit.ErrorOn(err, kit.B2S(block0Hash))
+	}
+	env.Block0Hash = kit.B2S(block0Hash)
+
+	// block0TxtFile will be created by jcli
+	block0Txt, err := jcli.GenesisDecode(env.Block0Bin, "", env.Block0TxtFile)
+	if err != nil {
+		return env, kit.ErrorOn(err, kit.B2S(block0Txt))
+	}
+
+	//////////////////////
+	//  secrets config  //
+	//////////////////////
+
+	for i := range env.Leaders {
+		// we need secret key, but only public ones may have been provided
+		if env.Leaders[i].SecretKey == "" {
+			continue
+		}
+
+		secretCfg := jnode.NewSecretConfig()
+
+		secretCfg.Bft.SigningKey = env.Leaders[i].SecretKey
+
+		secretCfgYaml, err := secretCfg.ToYaml()
+		if err != nil {
+			return env, kit.ErrorOn(err, "secretCfg.ToYaml")
+		}
+
+		// need this file for starting the node (--secret)
+		secretCfgFile := env.Leaders[i].SecretKeyFile + ".yaml"
+		err = writeFile(secretCfgFile, secretCfgYaml, 0744)
+		if err != nil {
+			return env, err
+		}
+
+		env.Leaders[i].SecretConfigFile = secretCfgFile
+	}
+
+	///////////////////
+	//  node config  //
+	///////////////////
+
+	// Check for jörmungandr binary. Local folder first, then PATH
+	env.JnodeBin, err = kit.FindExecutable("jormungandr", "jor_bins")
+	if err != nil {
+		return env, err
+	}
+	jnode.BinName(env.JnodeBin)
+
+	// get jörmungandr version
+	jormungandrVersion, err := jnode.VersionFull()
+	if err != nil {
+		return env, kit.ErrorOn(err, kit.B2S(jormungandrVersion))
+	}
+	env.JnodeVersion = kit.B2S(jormungandrVersion)
+
+	err = env.generateNodes()
+	if err != nil {
+		return env, err
+	}
+
+	//////////////////////
+	// VIT station data //
+	//////////////////////
+
+	err = env.setupVitStation()
+	if err != nil {
+		return env, err
+	}
+
+	return env, nil
+}
+
+// dumpVitStationData writes the funds, voteplans and proposals csv files used to populate the vit station database.
+func (env *Environment) dumpVitStationData() error {
+	fund := env.Funds.First()
+	schedule := &env.Schedule
+	timeFormat := env.Config.TimeFormat
+
+	//////////////////////////////////////////////
+	/* TODO: TMP - remove once/if properly defined */
+	if fund.StartTime == "" {
+		fund.StartTime = schedule.VoteStartTime.Format(timeFormat)
+	}
+	if fund.EndTime == "" {
+		fund.EndTime = schedule.VoteEndTime.Format(timeFormat)
+	}
+	if fund.VotingPowerInfo == "" {
+		fund.VotingPowerInfo = fund.StartTime
+	}
+	if fund.RewardsInfo == "" {
+		fund.RewardsInfo = schedule.CommitteeEndTime.Add(7 * schedule.EpochDuration).Format(timeFormat)
+	}
+	if fund.NextStartTime == "" {
+		fund.NextStartTime = schedule.CommitteeEndTime.Add(15 * schedule.EpochDuration).Format(timeFormat)
+	}
+	/* TODO: TMP - remove once/if properly defined */
+	//////////////////////////////////////////////
+
+	// FUNDS - dump
+	env.FundsCsvFile = filepath.Join(env.VitStationDir, FundsCsvFile)
+	f := []*loader.FundData{fund}
+	err := marshalCsvFile(&f, env.FundsCsvFile)
+	if err != nil {
+		return kit.ErrorOn(err, "Funds csv")
+	}
+
+	// VOTEPLANS - dump
+	env.VotePlansCsvFile = filepath.Join(env.VitStationDir, VotePlansCsvFile)
+	vp := fund.VotePlans
+	err = marshalCsvFile(&vp, env.VotePlansCsvFile)
+	if err != nil {
+		return kit.ErrorOn(err, "Voteplans csv")
+	}
+
+	// PROPOSALS - dump
+	env.ProposalsCsvFile = filepath.Join(env.VitStationDir, ProposalsCsvFile)
+	err = marshalCsvFile(env.Proposals.All(), env.ProposalsCsvFile)
+	if err != nil {
+		return kit.ErrorOn(err, "Proposals csv")
+	}
+
+	return nil
+}
+
+func marshalCsvFile(in interface{}, file string) error {
+	cf, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	err = gocsv.MarshalFile(in, cf) // Use this to save the CSV back to the file
+	if err != nil {
+		cf.Close()
+		return err
+	}
+	return cf.Close()
+}
+
+// setupVitStation populates the vit station database (if vit-servicing-station-cli is available)
+// and writes the vit-servicing-station-server config.
+func (env *Environment) setupVitStation() error {
+	vitDb := filepath.Join(env.VitStationDir, VitDbFile)
+
+	// Check for vit-servicing-station-cli binary. Local folder first (vit_bins), then PATH
+	vcliBin, err := kit.FindExecutable("vit-servicing-station-cli", "vit_bins")
+	if err != nil {
+		log.Printf("***** %s - DB data related to %s will NOT be generated", err.Error(), "vit-servicing-station")
+	} else {
+		env.VcliBin = vcliBin
+		vcli.BinName(vcliBin)
+
+		// get vit-servicing-station-cli version
+		vcliVersion, err := vcli.Version()
+		if err != nil {
+			return kit.ErrorOn(err, kit.B2S(vcliVersion))
+		}
+		env.VcliVersion = kit.B2S(vcliVersion)
+
+		// init database
+		out, err := vcli.DbInit(vitDb)
+		if err != nil {
+			return kit.ErrorOn(err, "vcli.DbInit", kit.B2S(out))
+		}
+
+		// populate the database with already dumped data
+		out, err = vcli.CsvDataLoad(vitDb, env.FundsCsvFile, env.ProposalsCsvFile, env.Config.Challenges, env.VotePlansCsvFile)
+		if err != nil {
+			return kit.ErrorOn(err, "vcli.CsvDataLoad", kit.B2S(out))
+		}
+		env.VitDbFile = vitDb
+	}
+
+	// vit-servicing-station-server
+	vs := vstation.NewVstation()
+	vs.WorkingDir = env.VitStationDir
+	vs.Address = env.VitStationAddress
+	vs.Block0Path = env.Block0BinFile
+	vs.DbUrl = vitDb
+	vs.Log.LogLevel = env.Config.VitLogLevel
+	vs.Log.LogOutputPath = filepath.Join(env.VitStationDir, "vit_station.log")
+	vs.Cors.AllowedOrigins = strings.Split(env.Config.Cors, ",")
+
+	vsJson, err := json.MarshalIndent(&vs, "", " ")
+	if err != nil {
+		return kit.ErrorOn(err, "vstation json.MarshalIndent")
+	}
+	env.VitConfigFile = filepath.Join(env.VitStationDir, VitConfigFile)
+	err = writeFile(env.VitConfigFile, vsJson, 0755)
+	if err != nil {
+		return err
+	}
+	env.Station = vs
+
+	// Check for vit-servicing-station-server binary. Local folder first (vit_bins), then PATH
+	vstationBin, err := kit.FindExecutable("vit-servicing-station-server", "vit_bins")
+	if err != nil {
+		log.Printf("***** %s", err.Error())
+		return nil
+	}
+	env.VstationBin = vstationBin
+	vstation.BinName(vstationBin)
+
+	// get vit-servicing-station-server version
+	vstationVersion, err := vstation.Version()
+	if err != nil {
+		return kit.ErrorOn(err, kit.B2S(vstationVersion))
+	}
+	env.VstationVersion = kit.B2S(vstationVersion)
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package vitenv
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// seedGen returns the seed as key, counting the calls.
+func seedGen(calls *int) func(seed string) ([]byte, error) {
+	return func(seed string) ([]byte, error) {
+		*calls++
+		return []byte("key-" + seed), nil
+	}
+}
+
+func TestGenerateKeySeed(t *testing.T) {
+	var calls int
+	env1 := &Environment{Config: Config{KeySeed: "fixture"}}
+	env2 := &Environment{Config: Config{KeySeed: "fixture"}}
+	other := &Environment{Config: Config{KeySeed: "other"}}
+	random := &Environment{}
+
+	key := func(env *Environment, name string) string {
+		t.Helper()
+		k, err := env.generateKey(name, seedGen(&calls))
+		if err != nil {
+			t.Fatalf("generateKey(%s): %v", name, err)
+		}
+		return string(k)
+	}
+
+	leader := key(env1, "leader_0.sk")
+	if leader == "key-" || len(leader) != len("key-")+64 {
+		t.Fatalf("seeded key: got %q, want a 32 bytes hex seed", leader)
+	}
+	if got := key(env2, "leader_0.sk"); got != leader {
+		t.Errorf("same seed and name: got %q, want %q", got, leader)
+	}
+	if got := key(env1, "leader_1.sk"); got == leader {
+		t.Errorf("same seed, other name: got the same key %q", got)
+	}
+	if got := key(other, "leader_0.sk"); got == leader {
+		t.Errorf("other seed, same name: got the same key %q", got)
+	}
+	if got := key(random, "leader_0.sk"); got != "key-" {
+		t.Errorf("no seed: got %q, want an empty seed", got)
+	}
+	if calls != 5 {
+		t.Errorf("gen calls: got %d, want 5", calls)
+	}
+}
+
+func TestGenerateKeyDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "keys")
+	var calls int
+
+	// generated once, then reused also with another seed
+	env := &Environment{Config: Config{KeyDir: dir}}
+	key, err := env.generateKey("committee.sk", seedGen(&calls))
+	if err != nil {
+		t.Fatalf("generateKey: %v", err)
+	}
+	stored, err := ioutil.ReadFile(filepath.Join(dir, "committee.sk"))
+	if err != nil || string(stored) != string(key) {
+		t.Fatalf("stored key: got %q (%v), want %q", stored, err, key)
+	}
+
+	env = &Environment{Config: Config{KeyDir: dir, KeySeed: "fixture"}}
+	again, err := env.generateKey("committee.sk", seedGen(&calls))
+	if err != nil || string(again) != string(key) {
+		t.Errorf("reused key: got %q (%v), want %q", again, err, key)
+	}
+	if calls != 1 {
+		t.Errorf("gen calls: got %d, want 1", calls)
+	}
+
+	// failures are not stored
+	_, err = env.generateKey("failed.sk", func(string) ([]byte, error) { return nil, errors.New("jcli failed") })
+	if err == nil || !strings.Contains(err.Error(), "jcli failed") {
+		t.Errorf("generateKey: got %v, want the gen error", err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "failed.sk")); !os.IsNotExist(err) {
+		t.Errorf("failed key: got %v, want not stored", err)
+	}
+}
+
+func TestReproducibleConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *Config)
+		errMsg string
+	}{
+		{"genesis time", func(cfg *Config) { cfg.GenesisTime = "" }, "[genesis-time] - needs to be pinned"},
+		{"output dir", func(cfg *Config) { cfg.OutputDir = "" }, "[output-dir] - needs to be set"},
+		{"keys", func(cfg *Config) { cfg.KeySeed = "" }, "[key-seed or key-dir] - needs to be set"},
+		{"key dir only", func(cfg *Config) { cfg.KeySeed, cfg.KeyDir = "", "keys" }, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Reproducible = true
+			cfg.GenesisTime = "2021-01-01T00:00:00Z"
+			cfg.OutputDir = "out"
+			cfg.KeySeed = "fixture"
+			tt.modify(&cfg)
+
+			_, err := cfg.Resolve()
+			switch {
+			case tt.errMsg == "" && err != nil:
+				t.Fatalf("Resolve: %v", err)
+			case tt.errMsg != "" && (err == nil || !strings.Contains(err.Error(), tt.errMsg)):
+				t.Fatalf("Resolve: got %v, want error containing %q", err, tt.errMsg)
+			}
+		})
+	}
+
+	// the pinned schedule is the same on every run
+	cfg1, cfg2 := DefaultConfig(), DefaultConfig()
+	cfg1.GenesisTime, cfg2.GenesisTime = "2021-01-01T00:00:00Z", "2021-01-01T00:00:00Z"
+	s1, err1 := cfg1.Resolve()
+	s2, err2 := cfg2.Resolve()
+	if err1 != nil || err2 != nil || *s1 != *s2 {
+		t.Errorf("schedule: got %+v (%v), %+v (%v)", s1, err1, s2, err2)
+	}
+}
+
+func TestOutputDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	got, err := outputDir(dir)
+	if err != nil || got != dir {
+		t.Fatalf("outputDir: got %s (%v), want %s", got, err, dir)
+	}
+	// an empty dir can be reused
+	if _, err = outputDir(dir); err != nil {
+		t.Fatalf("outputDir: %v", err)
+	}
+
+	writeTestFile(t, dir, "block0.bin", "block0")
+	if _, err = outputDir(dir); err == nil || !strings.Contains(err.Error(), "not empty") {
+		t.Errorf("outputDir: got %v, want not empty error", err)
+	}
+}
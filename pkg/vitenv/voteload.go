@@ -0,0 +1,546 @@
+package vitenv
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/input-output-hk/jorvit/internal/kit"
+	"github.com/rinor/jorcli/jcli"
+)
+
+// VoteLoadDir within the main working dir, with the vote casting load transactions files and report
+const VoteLoadDir = "vote_load"
+
+// VoteLoadReportFile within VoteLoadDir
+const VoteLoadReportFile = "report.json"
+
+// voteLoadRateMax fragments per second, the submission ticker period has to be at least 1µs
+const voteLoadRateMax = float64(time.Second / time.Microsecond)
+
+// VoteLoad settings of the vote casting load generator.
+type VoteLoad struct {
+	Proxy   string        // proxy address the fragments are submitted to, Environment.ProxyAddress if empty
+	Votes   int           // votes to cast, at most one per voter and proposal
+	Rate    float64       // submitted fragments per second
+	Workers int           // concurrent fragments builders (jcli)
+	Choices []float64     // weight of each option choice (ex: blank, yes, no), the missing ones weigh as the last one
+	Zipf    float64       // proposals popularity skew (zipf s > 1), 0 for uniform
+	Seed    int64         // votes distribution seed
+	Wait    time.Duration // max wait for the submitted fragments to get in a block
+}
+
+// DefaultVoteLoad settings.
+func DefaultVoteLoad() VoteLoad {
+	return VoteLoad{
+		Votes:   1000,
+		Rate:    10,
+		Workers: 4,
+		Choices: []float64{0.1, 0.6, 0.3},
+		Zipf:    1.2,
+		Seed:    1,
+		Wait:    2 * time.Minute,
+	}
+}
+
+// VoteLoadLatency percentiles of the fragments submission, in milliseconds.
+type VoteLoadLatency struct {
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
+	Max float64 `json:"max"`
+}
+
+// VoteLoadReport of a vote casting load run.
+type VoteLoadReport struct {
+	Votes      int     `json:"votes"`
+	Voters     int     `json:"voters"`
+	Proposals  int     `json:"proposals"`
+	TargetRate float64 `json:"target_rate"`
+	Rate       float64 `json:"rate"`
+	Duration   string  `json:"duration"`
+
+	Submitted int            `json:"submitted"`
+	Accepted  int            `json:"accepted"` // accepted by the proxy/node REST
+	Refused   map[string]int `json:"refused"`  // refused by the proxy/node REST, by reason
+	InABlock  int            `json:"in_a_block"`
+	Rejected  map[string]int `json:"rejected"` // rejected by the node after being accepted, by reason
+	Pending   int            `json:"pending"`
+
+	Choices map[uint8]int   `json:"choices"`
+	Latency VoteLoadLatency `json:"latency_ms"`
+}
+
+// voteTarget is a voteplan proposal that can be voted.
+type voteTarget struct {
+	VotePlanID string
+	Payload    string
+	Index      uint8
+	Options    uint8
+}
+
+// voteCast is a vote of a voter for a target, signed with the voter spending counter.
+type voteCast struct {
+	N       int
+	Voter   *Voter
+	Counter uint32
+	Target  *voteTarget
+	Choice  uint8
+
+	message    []byte
+	fragmentID string
+	latency    time.Duration
+	refused    string
+}
+
+// voteTargets loads the voteplans proposals from the voteplans config files.
+func (env *Environment) voteTargets() ([]voteTarget, error) {
+	var targets []voteTarget
+	for _, vp := range env.VotePlans {
+		var jvp jcliVotePlan
+		data, err := ioutil.ReadFile(vp.ConfigFile)
+		if err != nil {
+			return nil, kit.ErrorOn(err, "voteplan config")
+		}
+		err = json.Unmarshal(data, &jvp)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", vp.ConfigFile, err)
+		}
+		for i, p := range jvp.Proposals {
+			targets = append(targets, voteTarget{VotePlanID: vp.ID, Payload: vp.Payload, Index: uint8(i), Options: p.Options})
+		}
+	}
+	return targets, nil
+}
+
+// weightedChoice picks an option in [0, options) by the choices weights.
+func weightedChoice(rng *rand.Rand, choices []float64, options uint8) uint8 {
+	if options == 0 {
+		return 0
+	}
+	weights := make([]float64, options)
+	total := 0.0
+	for i := range weights {
+		switch {
+		case i < len(choices):
+			weights[i] = choices[i]
+		case len(choices) > 0:
+			weights[i] = choices[len(choices)-1]
+		default:
+			weights[i] = 1
+		}
+		total += weights[i]
+	}
+	if total <= 0 {
+		return uint8(rng.Intn(int(options)))
+	}
+	r := rng.Float64() * total
+	for i, w := range weights {
+		if r < w {
+			return uint8(i)
+		}
+		r -= w
+	}
+	return options - 1
+}
+
+// planVoteCasts distributes the votes: voters in turn, proposals by popularity (zipf) not yet voted by the voter,
+// choices by weight. The voters spending counters start from counters.
+func planVoteCasts(load VoteLoad, voters []Voter, counters []uint32, targets []voteTarget) []*voteCast {
+	rng := rand.New(rand.NewSource(load.Seed))
+
+	// proposals popularity order
+	popular := rng.Perm(len(targets))
+	var zipf *rand.Zipf
+	if load.Zipf > 1 && len(targets) > 1 {
+		zipf = rand.NewZipf(rng, load.Zipf, 1, uint64(len(targets)-1))
+	}
+
+	votes := load.Votes
+	if max := len(voters) * len(targets); votes > max {
+		votes = max
+	}
+
+	voted := make([]map[int]bool, len(voters))
+	casts := make([]*voteCast, 0, votes)
+	for n := 0; len(casts) < votes; n++ {
+		v := n % len(voters)
+		if voted[v] == nil {
+			voted[v] = make(map[int]bool)
+		}
+		if len(voted[v]) == len(targets) {
+			continue
+		}
+
+		var t int
+		if zipf != nil {
+			t = popular[zipf.Uint64()]
+		} else {
+			t = popular[rng.Intn(len(targets))]
+		}
+		// already voted, next one in popularity
+		for i := 0; voted[v][t]; i++ {
+			t = popular[i]
+		}
+		voted[v][t] = true
+
+		casts = append(casts, &voteCast{
+			N:       len(casts),
+			Voter:   &voters[v],
+			Counter: counters[v],
+			Target:  &targets[t],
+			Choice:  weightedChoice(rng, load.Choices, targets[t].Options),
+		})
+		counters[v]++
+	}
+	return casts
+}
+
+// buildVoteCast builds and signs (jcli) the vote cast transaction, the files are kept in txDir.
+func (env *Environment) buildVoteCast(txDir string, cast *voteCast, fee uint64) error {
+	cfg := &env.Config
+	name := filepath.Join(txDir, "vote_"+strconv.Itoa(cast.N))
+	stagingFile := name + ".staging"
+	_ = os.Remove(stagingFile)
+
+	var (
+		cert []byte
+		err  error
+	)
+	if cast.Target.Payload == "private" {
+		cert, err = jcli.CertificateNewVoteCastPrivate(nil, cast.Target.VotePlanID, cast.Target.Index, cast.Choice, cast.Target.Options, env.Privacy.VoteEncryptionKeyFile, "")
+	} else {
+		cert, err = jcli.CertificateNewVoteCastPublic(cast.Target.VotePlanID, cast.Target.Index, cast.Choice, "")
+	}
+	if err != nil {
+		return kit.ErrorOn(err, "jcli.CertificateNewVoteCast", cast.Target.Payload, kit.B2S(cert))
+	}
+
+	out, err := jcli.TransactionNew(nil, stagingFile)
+	if err != nil {
+		return kit.ErrorOn(err, "jcli.TransactionNew", kit.B2S(out))
+	}
+	// the voter account input, also when there are no fees to pay
+	out, err = jcli.TransactionAddAccount(nil, stagingFile, cast.Voter.Address, fee)
+	if err != nil {
+		return kit.ErrorOn(err, "jcli.TransactionAddAccount", kit.B2S(out))
+	}
+	out, err = jcli.TransactionAddCertificate(nil, stagingFile, kit.B2S(cert))
+	if err != nil {
+		return kit.ErrorOn(err, "jcli.TransactionAddCertificate", kit.B2S(out))
+	}
+	out, err = jcli.TransactionFinalize(nil, stagingFile,
+		cfg.FeesCertificate, cfg.FeesCoefficient, cfg.FeesConstant,
+		cfg.FeesCertificatePoolRegistration, cfg.FeesCertificateStakeDelegation, 0,
+		cfg.FeesCertificateVoteCast, cfg.FeesCertificateVotePlan,
+		"",
+	)
+	if err != nil {
+		return kit.ErrorOn(err, "jcli.TransactionFinalize", kit.B2S(out))
+	}
+
+	txID, err := jcli.TransactionDataForWitness(nil, stagingFile)
+	if err != nil {
+		return kit.ErrorOn(err, "jcli.TransactionDataForWitness", kit.B2S(txID))
+	}
+	witnessFile := name + ".witness"
+	out, err = jcli.TransactionMakeWitness(nil, kit.B2S(txID), env.Block0Hash, "account", cast.Counter, witnessFile, cast.Voter.SecretKeyFile)
+	if err != nil {
+		return kit.ErrorOn(err, "jcli.TransactionMakeWitness", kit.B2S(out))
+	}
+	out, err = jcli.TransactionAddWitness(nil, stagingFile, witnessFile)
+	if err != nil {
+		return kit.ErrorOn(err, "jcli.TransactionAddWitness", kit.B2S(out))
+	}
+	out, err = jcli.TransactionSeal(nil, stagingFile)
+	if err != nil {
+		return kit.ErrorOn(err, "jcli.TransactionSeal", kit.B2S(out))
+	}
+
+	message, err := jcli.TransactionToMessageFile(nil, stagingFile, name+".message")
+	if err != nil {
+		return kit.ErrorOn(err, "jcli.TransactionToMessage", kit.B2S(message))
+	}
+	cast.message, err = hex.DecodeString(kit.B2S(message))
+	if err != nil {
+		return fmt.Errorf("vote [%d] message: %w", cast.N, err)
+	}
+	return nil
+}
+
+// postMessage submits the fragment to the proxy /api/v0/message, same as "jcli rest v0 message post".
+func postMessage(proxy string, cast *voteCast) {
+	start := time.Now()
+	resp, err := restClient.Post("http://"+proxy+"/api/v0/message", "application/octet-stream", bytes.NewReader(cast.message))
+	cast.latency = time.Since(start)
+	if err != nil {
+		cast.refused = err.Error()
+		return
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	switch {
+	case err != nil:
+		cast.refused = err.Error()
+	case resp.StatusCode != http.StatusOK:
+		cast.refused = strconv.Itoa(resp.StatusCode) + " " + strings.TrimSpace(string(body))
+	default:
+		cast.fragmentID = strings.Trim(strings.TrimSpace(string(body)), `"`)
+	}
+}
+
+// fragmentStatuses of the node fragment logs, by fragment id: "Pending", "InABlock" or the rejection reason.
+func fragmentStatuses(proxy string) (map[string]string, error) {
+	var logs []struct {
+		FragmentID string          `json:"fragment_id"`
+		Status     json.RawMessage `json:"status"`
+	}
+	err := restGet(proxy, "/v0/fragment/logs", &logs)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make(map[string]string, len(logs))
+	for _, l := range logs {
+		var (
+			pending string
+			status  map[string]struct {
+				Reason string `json:"reason"`
+			}
+		)
+		if json.Unmarshal(l.Status, &pending) == nil {
+			statuses[l.FragmentID] = pending
+			continue
+		}
+		_ = json.Unmarshal(l.Status, &status)
+		for s, v := range status {
+			if s == "Rejected" {
+				s = v.Reason
+			}
+			statuses[l.FragmentID] = s
+		}
+	}
+	return statuses, nil
+}
+
+// percentile (nearest rank) of the sorted latencies, in milliseconds.
+func percentile(sorted []time.Duration, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(p/100*float64(len(sorted))+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return float64(sorted[i]) / float64(time.Millisecond)
+}
+
+// CastVotes runs the vote casting load: the generated test voters (voters.json) vote the voteplans proposals,
+// each vote cast fragment built and signed with jcli, then submitted through the proxy /api/v0/message
+// at load.Rate fragments per second, once the node reaches the vote start.
+// The report (acceptance, rejection reasons and latency percentiles) is also written to vote_load/report.json.
+func (env *Environment) CastVotes(ctx context.Context, node *NetworkNode, load VoteLoad) (*VoteLoadReport, error) {
+	switch {
+	case len(env.Voters) == 0:
+		return nil, fmt.Errorf("no test voters found in [%s] - generate them with %s", env.WorkingDir, "voters")
+	case load.Votes <= 0:
+		return nil, fmt.Errorf("[%s: %d] - wrong value, expected > 0", "votes", load.Votes)
+	case !(load.Rate > 0):
+		return nil, fmt.Errorf("[%s: %v] - wrong value, expected > 0", "rate", load.Rate)
+	case load.Rate > voteLoadRateMax:
+		return nil, fmt.Errorf("[%s: %v] - wrong value, expected <= %v", "rate", load.Rate, voteLoadRateMax)
+	}
+	if load.Workers < 1 {
+		load.Workers = 1
+	}
+	if load.Proxy == "" {
+		load.Proxy = env.ProxyAddress
+	}
+
+	targets, err := env.voteTargets()
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no voteplans proposals found in [%s]", env.VotePlanDir)
+	}
+
+	log.Printf("VOTELOAD - %d voters, %d proposals, waiting for the vote start %s on node [%s]", len(env.Voters), len(targets), env.Schedule.VoteStart, node.RestAddress)
+	now, err := waitChainTime(ctx, "VOTELOAD", node.RestAddress, env.Schedule.VoteStart)
+	if err != nil {
+		return nil, err
+	}
+	if !chainTimeBefore(now, env.Schedule.VoteEnd) {
+		log.Printf("VOTELOAD - ***** chain time %s - vote already ended at %s, the votes will be rejected *****", now, env.Schedule.VoteEnd)
+	}
+
+	// the voters current spending counters
+	counters := make([]uint32, len(env.Voters))
+	for i := range env.Voters {
+		counters[i], _, err = accountCounter(node.RestAddress, env.Voters[i].Address)
+		if err != nil {
+			return nil, err
+		}
+	}
+	casts := planVoteCasts(load, env.Voters, counters, targets)
+
+	// build all the fragments upfront, the submission rate is not bound to jcli
+	txDir := filepath.Join(env.WorkingDir, VoteLoadDir)
+	err = os.MkdirAll(txDir, 0755)
+	if err != nil {
+		return nil, kit.ErrorOn(err, "voteLoadDir")
+	}
+	certFee := env.Config.FeesCertificateVoteCast
+	if certFee == 0 {
+		certFee = env.Config.FeesCertificate
+	}
+	fee := env.Config.certificateFee(certFee)
+
+	log.Printf("VOTELOAD - building %d vote cast fragments (%d workers)", len(casts), load.Workers)
+	var (
+		wg       sync.WaitGroup
+		buildErr error
+		errOnce  sync.Once
+		queue    = make(chan *voteCast)
+	)
+	for w := 0; w < load.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for cast := range queue {
+				if err := env.buildVoteCast(txDir, cast, fee); err != nil {
+					errOnce.Do(func() { buildErr = err })
+				}
+			}
+		}()
+	}
+	for _, cast := range casts {
+		if ctx.Err() != nil {
+			break
+		}
+		queue <- cast
+	}
+	close(queue)
+	wg.Wait()
+	if buildErr != nil {
+		return nil, buildErr
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// submit at the target rate
+	log.Printf("VOTELOAD - submitting %d fragments at %v/s to proxy [%s]", len(casts), load.Rate, load.Proxy)
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / load.Rate))
+	start := time.Now()
+	submitted := 0
+	for _, cast := range casts {
+		select {
+		case <-ctx.Done():
+		case <-ticker.C:
+			wg.Add(1)
+			go func(cast *voteCast) {
+				defer wg.Done()
+				postMessage(load.Proxy, cast)
+			}(cast)
+			submitted++
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	ticker.Stop()
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	report := &VoteLoadReport{
+		Votes:      len(casts),
+		Voters:     len(env.Voters),
+		Proposals:  len(targets),
+		TargetRate: load.Rate,
+		Rate:       float64(submitted) / elapsed.Seconds(),
+		Duration:   elapsed.Round(time.Millisecond).String(),
+		Submitted:  submitted,
+		Refused:    make(map[string]int),
+		Rejected:   make(map[string]int),
+		Choices:    make(map[uint8]int),
+	}
+	var latencies []time.Duration
+	accepted := make(map[string]bool)
+	for _, cast := range casts[:submitted] {
+		latencies = append(latencies, cast.latency)
+		if cast.refused != "" {
+			report.Refused[cast.refused]++
+			continue
+		}
+		report.Accepted++
+		report.Choices[cast.Choice]++
+		accepted[cast.fragmentID] = true
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	report.Latency = VoteLoadLatency{
+		P50: percentile(latencies, 50),
+		P90: percentile(latencies, 90),
+		P95: percentile(latencies, 95),
+		P99: percentile(latencies, 99),
+		Max: percentile(latencies, 100),
+	}
+
+	// the accepted fragments outcome
+	log.Printf("VOTELOAD - %d/%d fragments accepted, waiting (max %s) for them to get in a block", report.Accepted, submitted, load.Wait)
+	deadline := time.Now().Add(load.Wait)
+	for {
+		statuses, err := fragmentStatuses(load.Proxy)
+		if err != nil {
+			log.Printf("VOTELOAD - fragment logs: %v", err)
+		} else {
+			report.InABlock, report.Pending, report.Rejected = 0, 0, make(map[string]int)
+			for id := range accepted {
+				switch status := statuses[id]; status {
+				case "InABlock":
+					report.InABlock++
+				case "Pending", "":
+					report.Pending++
+				default:
+					report.Rejected[status]++
+				}
+			}
+			log.Printf("VOTELOAD - in a block: %d, rejected: %d, pending: %d", report.InABlock, report.Accepted-report.InABlock-report.Pending, report.Pending)
+			if report.Pending == 0 {
+				break
+			}
+		}
+		if time.Now().After(deadline) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			deadline = time.Now()
+		case <-time.After(tallyPollInterval):
+		}
+	}
+
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return report, kit.ErrorOn(err, "vote load report")
+	}
+	return report, writeFile(filepath.Join(txDir, VoteLoadReportFile), reportJSON, 0644)
+}
@@ -0,0 +1,212 @@
+package vitenv
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCastVotesValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		voters []Voter
+		modify func(load *VoteLoad)
+		errMsg string
+	}{
+		{"no voters", nil, func(load *VoteLoad) {}, "no test voters found"},
+		{"no votes", []Voter{{}}, func(load *VoteLoad) { load.Votes = 0 }, "[votes: 0] - wrong value, expected > 0"},
+		{"no rate", []Voter{{}}, func(load *VoteLoad) { load.Rate = 0 }, "[rate: 0] - wrong value, expected > 0"},
+		{"nan rate", []Voter{{}}, func(load *VoteLoad) { load.Rate = math.NaN() }, "[rate: NaN] - wrong value, expected > 0"},
+		{"rate max", []Voter{{}}, func(load *VoteLoad) { load.Rate = 2e9 }, "[rate: 2e+09] - wrong value, expected <= 1e+06"},
+		{"no proposals", []Voter{{}}, func(load *VoteLoad) { load.Rate = voteLoadRateMax }, "no voteplans proposals found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := &Environment{Voters: tt.voters, VotePlanDir: t.TempDir()}
+			load := DefaultVoteLoad()
+			tt.modify(&load)
+
+			_, err := env.CastVotes(context.Background(), &NetworkNode{}, load)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Fatalf("CastVotes: got %v, want error containing %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestWeightedChoice(t *testing.T) {
+	tests := []struct {
+		name    string
+		choices []float64
+		options uint8
+		want    []float64 // expected share of each option
+	}{
+		{"weights", []float64{0.1, 0.6, 0.3}, 3, []float64{0.1, 0.6, 0.3}},
+		{"missing weigh as the last", []float64{0.5, 0.25}, 4, []float64{0.5, 0.25, 0.25, 0.25}},
+		{"extra weights ignored", []float64{1, 1, 8}, 2, []float64{0.5, 0.5}},
+		{"no weights", nil, 4, []float64{0.25, 0.25, 0.25, 0.25}},
+		{"zero weights", []float64{0, 0}, 2, []float64{0.5, 0.5}},
+		{"only one", []float64{0, 1, 0}, 3, []float64{0, 1, 0}},
+	}
+
+	const n = 20000
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(1))
+			got := make([]int, tt.options)
+			for i := 0; i < n; i++ {
+				c := weightedChoice(rng, tt.choices, tt.options)
+				if c >= tt.options {
+					t.Fatalf("weightedChoice: got %d, want < %d", c, tt.options)
+				}
+				got[c]++
+			}
+			// weights are relative to their total
+			total := 0.0
+			for _, w := range tt.want {
+				total += w
+			}
+			for i, w := range tt.want {
+				if share := float64(got[i]) / n; math.Abs(share-w/total) > 0.02 {
+					t.Errorf("option %d: got share %.3f, want %.3f", i, share, w/total)
+				}
+			}
+		})
+	}
+
+	if c := weightedChoice(rand.New(rand.NewSource(1)), []float64{1}, 0); c != 0 {
+		t.Errorf("weightedChoice no options: got %d, want 0", c)
+	}
+}
+
+func testVoteTargets(n int) []voteTarget {
+	targets := make([]voteTarget, n)
+	for i := range targets {
+		targets[i] = voteTarget{VotePlanID: "vp", Payload: "public", Index: uint8(i), Options: 3}
+	}
+	return targets
+}
+
+func TestPlanVoteCasts(t *testing.T) {
+	tests := []struct {
+		name      string
+		votes     int
+		voters    int
+		proposals int
+		zipf      float64
+		want      int
+	}{
+		{"uniform", 10, 3, 5, 0, 10},
+		{"zipf", 10, 3, 5, 1.2, 10},
+		{"capped", 100, 3, 4, 1.2, 12},
+		{"single proposal", 5, 3, 1, 1.2, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			load := DefaultVoteLoad()
+			load.Votes, load.Zipf = tt.votes, tt.zipf
+			voters := make([]Voter, tt.voters)
+			for i := range voters {
+				voters[i].Index = i
+			}
+			counters := make([]uint32, tt.voters)
+			counters[0] = 7
+			targets := testVoteTargets(tt.proposals)
+
+			casts := planVoteCasts(load, voters, counters, targets)
+			if len(casts) != tt.want {
+				t.Fatalf("casts: got %d, want %d", len(casts), tt.want)
+			}
+
+			voted := make(map[[2]int]bool)
+			next := []uint32{7, 0, 0}
+			for i, cast := range casts {
+				v := cast.Voter.Index
+				// voters in turn, while they have proposals left
+				if tt.want == tt.votes && v != i%tt.voters {
+					t.Errorf("cast %d: got voter %d, want %d", i, v, i%tt.voters)
+				}
+				key := [2]int{v, int(cast.Target.Index)}
+				if voted[key] {
+					t.Errorf("cast %d: voter %d already voted proposal %d", i, v, cast.Target.Index)
+				}
+				voted[key] = true
+
+				if cast.N != i || cast.Counter != next[v] || cast.Choice >= cast.Target.Options {
+					t.Errorf("cast %d: got %+v, want counter %d", i, cast, next[v])
+				}
+				next[v]++
+			}
+			// the counters are left to the next spending
+			if !reflect.DeepEqual(counters, next[:tt.voters]) {
+				t.Errorf("counters: got %v, want %v", counters, next[:tt.voters])
+			}
+
+			// same seed, same votes
+			again := planVoteCasts(load, voters, make([]uint32, tt.voters), targets)
+			for i := range casts {
+				if casts[i].Voter != again[i].Voter || casts[i].Target != again[i].Target || casts[i].Choice != again[i].Choice {
+					t.Fatalf("cast %d: got %+v, want %+v with the same seed", i, again[i], casts[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPlanVoteCastsZipf(t *testing.T) {
+	load := DefaultVoteLoad()
+	load.Votes, load.Zipf = 200, 2
+	voters := make([]Voter, 200)
+	targets := testVoteTargets(10)
+
+	votes := make(map[uint8]int)
+	for _, cast := range planVoteCasts(load, voters, make([]uint32, len(voters)), targets) {
+		votes[cast.Target.Index]++
+	}
+	// the most popular proposal takes the largest share of the votes
+	max := 0
+	for _, n := range votes {
+		if n > max {
+			max = n
+		}
+	}
+	if max < 200/len(targets)*3 {
+		t.Errorf("zipf: got max %d votes for a proposal, %v", max, votes)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := make([]time.Duration, 10)
+	for i := range sorted {
+		sorted[i] = time.Duration(i+1) * time.Millisecond
+	}
+
+	tests := []struct {
+		name   string
+		sorted []time.Duration
+		p      float64
+		want   float64
+	}{
+		{"empty", nil, 50, 0},
+		{"p50", sorted, 50, 5},
+		{"p90", sorted, 90, 9},
+		{"p99", sorted, 99, 10},
+		{"max", sorted, 100, 10},
+		{"min", sorted, 0, 1},
+		{"single", []time.Duration{1500 * time.Microsecond}, 50, 1.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := percentile(tt.sorted, tt.p); got != tt.want {
+				t.Errorf("percentile(%v): got %v, want %v", tt.p, got, tt.want)
+			}
+		})
+	}
+}
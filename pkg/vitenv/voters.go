@@ -0,0 +1,128 @@
+package vitenv
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gocarina/gocsv"
+	"github.com/input-output-hk/jorvit/internal/kit"
+	"github.com/rinor/jorcli/jcli"
+	"github.com/rinor/jorcli/jnode"
+	"golang.org/x/crypto/blake2b"
+)
+
+// Test voters files, within the main working dir
+const (
+	VotersDir      = "voters"
+	VotersCsvFile  = "voters.csv"
+	VotersJSONFile = "voters.json"
+)
+
+// Voter is a generated test voter wallet, funded in block0.
+type Voter struct {
+	Index         int    `json:"index"           csv:"index"`
+	Address       string `json:"address"         csv:"address"`
+	PublicKey     string `json:"public_key"      csv:"public_key"`
+	SecretKey     string `json:"secret_key"      csv:"secret_key"`
+	SecretKeyFile string `json:"secret_key_file" csv:"secret_key_file"`
+	Value         uint64 `json:"value"           csv:"value"`
+}
+
+// voterFund is the block0 value of the voter public key, Config.VoterFund or when Config.VoterFundMax is set
+// a value in [VoterFund, VoterFundMax] derived from the public key, random but the same for the same key.
+func (cfg *Config) voterFund(publicKey string) uint64 {
+	if cfg.VoterFundMax <= cfg.VoterFund {
+		return cfg.VoterFund
+	}
+	sum := blake2b.Sum256([]byte(publicKey))
+	span := cfg.VoterFundMax - cfg.VoterFund
+	if span == ^uint64(0) {
+		return cfg.VoterFund + binary.BigEndian.Uint64(sum[:8])
+	}
+	return cfg.VoterFund + binary.BigEndian.Uint64(sum[:8])%(span+1)
+}
+
+// generateVoters builds Config.Voters test voter wallets, each one with:
+//   - ed25519 secret key (voters/voter_N.sk) and account address
+//   - account funded in block0 with Config.VoterFund (or a random value up to Config.VoterFundMax)
+//
+// The wallets, secret keys included, are exported to voters.csv and voters.json.
+func (env *Environment) generateVoters(block0cfg *jnode.Block0Config, prefix string, discrimination string) error {
+	cfg := &env.Config
+
+	dir := filepath.Join(env.WorkingDir, VotersDir)
+	err := os.Mkdir(dir, 0755)
+	if err != nil {
+		return kit.ErrorOn(err, "votersDir")
+	}
+
+	env.Voters = make([]Voter, cfg.Voters)
+	funds := make([]jnode.InitialFund, len(env.Voters))
+	for i := range env.Voters {
+		voter := &env.Voters[i]
+		voter.Index = i
+		name := "voter_" + strconv.Itoa(i) + ".sk"
+
+		sk, err := env.generateKey(name, func(seed string) ([]byte, error) {
+			return jcli.KeyGenerate(seed, "Ed25519", "")
+		})
+		if err != nil {
+			return err
+		}
+		voter.SecretKeyFile = filepath.Join(dir, name)
+		err = writeFile(voter.SecretKeyFile, sk, 0600)
+		if err != nil {
+			return err
+		}
+		voter.SecretKey = kit.B2S(sk)
+
+		pk, err := jcli.KeyToPublic(sk, "", "")
+		if err != nil {
+			return kit.ErrorOn(err, kit.B2S(pk))
+		}
+		voter.PublicKey = kit.B2S(pk)
+
+		acc, err := jcli.AddressAccount(voter.PublicKey, prefix, discrimination)
+		if err != nil {
+			return kit.ErrorOn(err, kit.B2S(acc))
+		}
+		voter.Address = kit.B2S(acc)
+		voter.Value = cfg.voterFund(voter.PublicKey)
+
+		funds[i] = jnode.InitialFund{Address: voter.Address, Value: voter.Value}
+	}
+	block0cfg.Initial = append(block0cfg.Initial, initialFunds(funds)...)
+
+	// export for the wallet test tooling
+	env.VotersCsvFile = filepath.Join(env.WorkingDir, VotersCsvFile)
+	votersCsv, err := gocsv.MarshalBytes(env.Voters)
+	if err != nil {
+		return kit.ErrorOn(err, "voters csv")
+	}
+	err = writeFile(env.VotersCsvFile, votersCsv, 0600)
+	if err != nil {
+		return err
+	}
+
+	env.VotersJSONFile = filepath.Join(env.WorkingDir, VotersJSONFile)
+	votersJSON, err := json.MarshalIndent(env.Voters, "", "  ")
+	if err != nil {
+		return kit.ErrorOn(err, "voters json")
+	}
+	return writeFile(env.VotersJSONFile, votersJSON, 0600)
+}
+
+// LoadVoters reads the test voter wallets exported to file (voters.json).
+func LoadVoters(file string) ([]Voter, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var voters []Voter
+	err = json.Unmarshal(data, &voters)
+	return voters, err
+}
@@ -0,0 +1,114 @@
+package vitenv
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/rinor/jorcli/jnode"
+)
+
+func TestVoterFund(t *testing.T) {
+	tests := []struct {
+		name     string
+		fund     uint64
+		fundMax  uint64
+		min, max uint64
+	}{
+		{"fixed", 1_000, 0, 1_000, 1_000},
+		{"max equal", 1_000, 1_000, 1_000, 1_000},
+		{"max lower", 1_000, 10, 1_000, 1_000},
+		{"range", 1_000, 2_000, 1_000, 2_000},
+		{"one more", 1_000, 1_001, 1_000, 1_001},
+		{"full range", 0, math.MaxUint64, 0, math.MaxUint64},
+		{"top range", math.MaxUint64 - 10, math.MaxUint64, math.MaxUint64 - 10, math.MaxUint64},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{VoterFund: tt.fund, VoterFundMax: tt.fundMax}
+			values := make(map[uint64]bool)
+			for i := 0; i < 100; i++ {
+				pk := "ed25519_pk_voter_" + strconv.Itoa(i)
+				v := cfg.voterFund(pk)
+				if v < tt.min || v > tt.max {
+					t.Fatalf("voterFund(%s): got %d, want [%d - %d]", pk, v, tt.min, tt.max)
+				}
+				if again := cfg.voterFund(pk); again != v {
+					t.Fatalf("voterFund(%s): got %d then %d, want the same", pk, v, again)
+				}
+				values[v] = true
+			}
+
+			// random within the range, unless only one value is possible
+			switch {
+			case tt.min == tt.max && len(values) != 1:
+				t.Errorf("voterFund: got %d values, want 1", len(values))
+			case tt.max-tt.min == 1 && len(values) != 2:
+				t.Errorf("voterFund: got %d values, want 2", len(values))
+			case tt.max-tt.min > 1_000 && len(values) < 90:
+				t.Errorf("voterFund: got %d values out of 100", len(values))
+			}
+		})
+	}
+}
+
+func TestInitialFunds(t *testing.T) {
+	tests := []struct {
+		funds  int
+		groups []int
+	}{
+		{0, nil},
+		{1, []int{1}},
+		{initialFundsMax, []int{initialFundsMax}},
+		{initialFundsMax + 1, []int{initialFundsMax, 1}},
+		{2*initialFundsMax + 10, []int{initialFundsMax, initialFundsMax, 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.funds), func(t *testing.T) {
+			funds := make([]jnode.InitialFund, tt.funds)
+			for i := range funds {
+				funds[i] = jnode.InitialFund{Address: "addr_" + strconv.Itoa(i), Value: uint64(i)}
+			}
+
+			initial := initialFunds(funds)
+			if len(initial) != len(tt.groups) {
+				t.Fatalf("initialFunds: got %d entries, want %d", len(initial), len(tt.groups))
+			}
+			// same funds, in the same order
+			var all []jnode.InitialFund
+			for i, entry := range initial {
+				if len(entry.Fund) != tt.groups[i] || entry.Cert != "" || len(entry.LegacyFund) != 0 {
+					t.Errorf("entry %d: got %d funds, want %d", i, len(entry.Fund), tt.groups[i])
+				}
+				all = append(all, entry.Fund...)
+			}
+			if len(funds) > 0 && !reflect.DeepEqual(all, funds) {
+				t.Errorf("initialFunds: funds not preserved")
+			}
+		})
+	}
+}
+
+func TestLoadVoters(t *testing.T) {
+	voters := []Voter{
+		{Index: 0, Address: testAccount(t, 0, DiscriminationTesting), PublicKey: "pk0", SecretKey: "sk0", SecretKeyFile: "voters/voter_0.sk", Value: 10},
+		{Index: 1, Address: testAccount(t, 1, DiscriminationTesting), PublicKey: "pk1", SecretKey: "sk1", SecretKeyFile: "voters/voter_1.sk", Value: 20},
+	}
+	data, err := json.MarshalIndent(voters, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+
+	got, err := LoadVoters(writeTestFile(t, dir, VotersJSONFile, string(data)))
+	if err != nil || !reflect.DeepEqual(got, voters) {
+		t.Errorf("LoadVoters: got %+v (%v), want %+v", got, err, voters)
+	}
+	if _, err = LoadVoters(writeTestFile(t, dir, "wrong.json", "{")); err == nil {
+		t.Error("LoadVoters: expected error on wrong json")
+	}
+}
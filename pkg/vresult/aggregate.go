@@ -0,0 +1,166 @@
+package vresult
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/input-output-hk/jorvit/internal/loader"
+)
+
+// Participation distribution of the votes cast among the proposals of a group.
+type Participation struct {
+	Min     uint    `json:"min"`
+	Max     uint    `json:"max"`
+	Mean    float64 `json:"mean"`
+	Median  float64 `json:"median"`
+	NoVotes int     `json:"no_votes"` // proposals without any vote cast
+}
+
+// TopProposal is a proposal summary used for the groups ranking.
+type TopProposal struct {
+	InternalID uint64          `json:"internal_id"`
+	ProposalID string          `json:"proposal_id"`
+	Title      string          `json:"proposal_title"`
+	Funds      loader.Lovelace `json:"proposal_funds"`
+	VotesCast  uint            `json:"votes_cast"`
+	Yes        uint            `json:"yes"`
+	No         uint            `json:"no"`
+	Net        int64           `json:"net"`
+	Outcome
+}
+
+// GroupStats aggregates the results of the proposals sharing a challenge or category.
+type GroupStats struct {
+	Group          string          `json:"group"`
+	Proposals      int             `json:"proposals"`
+	RequestedFunds loader.Lovelace `json:"requested_funds"`
+	VotesCast      uint            `json:"votes_cast"`
+	Participation  Participation   `json:"participation"`
+	Top            []TopProposal   `json:"top_proposals"`
+}
+
+// Aggregates of the results per challenge and per category.
+type Aggregates struct {
+	Challenges []GroupStats `json:"challenges"`
+	Categories []GroupStats `json:"categories"`
+}
+
+// Aggregate groups the results by challenge and by category, keeping the top ranked proposals of each group.
+//
+// The proposals are ordered following the Rules ranking, the ones without quorum last.
+func Aggregate(results []ProposalsResult, top int) Aggregates {
+	return Aggregates{
+		Challenges: groupStats(results, top, func(r *ProposalsResult) string {
+			return strconv.FormatUint(uint64(r.ChallengeID), 10)
+		}),
+		Categories: groupStats(results, top, func(r *ProposalsResult) string {
+			return r.CategoryName
+		}),
+	}
+}
+
+func groupStats(results []ProposalsResult, top int, groupOf func(*ProposalsResult) string) []GroupStats {
+	groups := make(map[string][]*ProposalsResult)
+	for i := range results {
+		g := groupOf(&results[i])
+		groups[g] = append(groups[g], &results[i])
+	}
+
+	names := make([]string, 0, len(groups))
+	for g := range groups {
+		names = append(names, g)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return groupLess(names[i], names[j])
+	})
+
+	stats := make([]GroupStats, 0, len(groups))
+	for _, g := range names {
+		members := groups[g]
+
+		gs := GroupStats{
+			Group:     g,
+			Proposals: len(members),
+			Top:       []TopProposal{},
+		}
+
+		votes := make([]uint, 0, len(members))
+		for _, m := range members {
+			gs.RequestedFunds += m.Funds
+			gs.VotesCast += m.VotesCast
+			votes = append(votes, m.VotesCast)
+			if m.VotesCast == 0 {
+				gs.Participation.NoVotes++
+			}
+		}
+		gs.Participation = participation(votes, gs.Participation.NoVotes)
+
+		sort.SliceStable(members, func(i, j int) bool {
+			return rankLess(members[i], members[j])
+		})
+
+		ranked := make([]TopProposal, 0, len(members))
+		for _, m := range members {
+			ranked = append(ranked, TopProposal{
+				InternalID: m.InternalID,
+				ProposalID: m.Proposal.ID,
+				Title:      m.Title,
+				Funds:      m.Funds,
+				VotesCast:  m.VotesCast,
+				Yes:        m.Tally01,
+				No:         m.Tally02,
+				Net:        m.NetVotes(),
+				Outcome:    m.Outcome,
+			})
+		}
+		if top >= 0 && len(ranked) > top {
+			ranked = ranked[:top]
+		}
+		gs.Top = append(gs.Top, ranked...)
+
+		stats = append(stats, gs)
+	}
+
+	return stats
+}
+
+// groupLess orders the groups numerically when both are numbers (challenge ids), by name otherwise.
+func groupLess(a string, b string) bool {
+	an, aErr := strconv.ParseUint(a, 10, 64)
+	bn, bErr := strconv.ParseUint(b, 10, 64)
+	switch {
+	case aErr == nil && bErr == nil:
+		return an < bn
+	case aErr == nil || bErr == nil:
+		return aErr == nil // numbers first
+	default:
+		return a < b
+	}
+}
+
+func participation(votes []uint, noVotes int) Participation {
+	p := Participation{NoVotes: noVotes}
+	if len(votes) == 0 {
+		return p
+	}
+
+	sort.Slice(votes, func(i, j int) bool { return votes[i] < votes[j] })
+
+	var total uint
+	for _, v := range votes {
+		total += v
+	}
+
+	p.Min = votes[0]
+	p.Max = votes[len(votes)-1]
+	p.Mean = float64(total) / float64(len(votes))
+
+	mid := len(votes) / 2
+	if len(votes)%2 == 0 {
+		p.Median = float64(votes[mid-1]+votes[mid]) / 2
+	} else {
+		p.Median = float64(votes[mid])
+	}
+
+	return p
+}
@@ -0,0 +1,117 @@
+package vresult
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/input-output-hk/jorvit/internal/loader"
+)
+
+func testResult(internalID uint64, challengeID uint32, category string, votesCast uint, yes uint, no uint) ProposalsResult {
+	var pr ProposalsResult
+	pr.InternalID = internalID
+	pr.ChallengeID = challengeID
+	pr.CategoryName = category
+	pr.Funds = loader.Lovelace(internalID * 1_000_000)
+	pr.VotesCast = votesCast
+	pr.Tally01, pr.Tally02 = yes, no
+	return pr
+}
+
+func TestAggregate(t *testing.T) {
+	results := []ProposalsResult{
+		testResult(1, 10, "dapps", 4, 3, 1),
+		testResult(2, 2, "dapps", 0, 0, 0),
+		testResult(3, 10, "dapps", 1, 1, 0),
+		testResult(4, 1, "community", 2, 2, 0),
+		testResult(5, 10, "dapps", 3, 2, 1),
+	}
+	// same order for any ranking rule
+	for i, score := range []loader.Score{500, 100, 100, 100, 300} {
+		results[i].ImpactScore = score
+	}
+	results[1].Outcome = Outcome{Outcome: OutcomeNoQuorum, DecidedBy: RuleQuorumVotes}
+
+	aggregates := Aggregate(results, 2)
+
+	var challenges []string
+	for _, g := range aggregates.Challenges {
+		challenges = append(challenges, g.Group)
+	}
+	if want := []string{"1", "2", "10"}; !reflect.DeepEqual(challenges, want) {
+		t.Errorf("challenges: got %v, want %v", challenges, want)
+	}
+
+	var categories []string
+	for _, g := range aggregates.Categories {
+		categories = append(categories, g.Group)
+	}
+	if want := []string{"community", "dapps"}; !reflect.DeepEqual(categories, want) {
+		t.Errorf("categories: got %v, want %v", categories, want)
+	}
+
+	c10 := aggregates.Challenges[2]
+	if c10.Proposals != 3 || c10.VotesCast != 8 || c10.RequestedFunds != 9_000_000 {
+		t.Errorf("challenge 10: got %d proposals, %d votes cast, %d funds", c10.Proposals, c10.VotesCast, c10.RequestedFunds)
+	}
+	if want := (Participation{Min: 1, Max: 4, Mean: 8.0 / 3, Median: 3}); c10.Participation != want {
+		t.Errorf("challenge 10 participation: got %+v, want %+v", c10.Participation, want)
+	}
+	if len(c10.Top) != 2 || c10.Top[0].InternalID != 1 || c10.Top[1].InternalID != 5 {
+		t.Errorf("challenge 10 top: got %+v", c10.Top)
+	}
+	if c10.Top[0].Net != 2 || c10.Top[0].Yes != 3 || c10.Top[0].No != 1 {
+		t.Errorf("challenge 10 top votes: got %+v", c10.Top[0])
+	}
+
+	// ranked proposals first, no quorum last
+	dapps := aggregates.Categories[1]
+	all := Aggregate(results, -1).Categories[1]
+	if dapps.Proposals != 4 || dapps.Participation.NoVotes != 1 || len(all.Top) != 4 {
+		t.Fatalf("dapps: got %d proposals, %d no votes, %d top", dapps.Proposals, dapps.Participation.NoVotes, len(all.Top))
+	}
+	if last := all.Top[len(all.Top)-1]; last.InternalID != 2 {
+		t.Errorf("dapps last: got %d, want %d", last.InternalID, 2)
+	}
+}
+
+func TestParticipation(t *testing.T) {
+	tests := []struct {
+		votes []uint
+		want  Participation
+	}{
+		{nil, Participation{}},
+		{[]uint{5}, Participation{Min: 5, Max: 5, Mean: 5, Median: 5}},
+		{[]uint{4, 0, 2, 6}, Participation{Min: 0, Max: 6, Mean: 3, Median: 3, NoVotes: 1}},
+	}
+	for _, tt := range tests {
+		noVotes := 0
+		for _, v := range tt.votes {
+			if v == 0 {
+				noVotes++
+			}
+		}
+		if got := participation(tt.votes, noVotes); got != tt.want {
+			t.Errorf("participation(%v): got %+v, want %+v", tt.votes, got, tt.want)
+		}
+	}
+}
+
+func TestGroupLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"2", "10", true},
+		{"10", "2", false},
+		{"10", "10", false},
+		{"9", "dapps", true},
+		{"dapps", "9", false},
+		{"community", "dapps", true},
+	}
+	for _, tt := range tests {
+		if got := groupLess(tt.a, tt.b); got != tt.want {
+			t.Errorf("groupLess(%q, %q): got %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package vresult
+
+import "sort"
+
+// Proposal outcomes
+const (
+	OutcomeRanked   = "ranked"
+	OutcomeNoQuorum = "no_quorum"
+	OutcomePending  = "pending" // not tallied yet, the stake quorum can't be checked
+)
+
+// Rules names, reported as the rule that decided a proposal outcome
+const (
+	RuleQuorumVotes    = "quorum_votes"
+	RuleQuorumStake    = "quorum_stake"
+	RuleNetVotes       = "net_votes"
+	RuleImpactScore    = "impact_score"
+	RuleRequestedFunds = "requested_funds"
+	RuleInternalID     = "internal_id"
+	RuleUncontested    = "uncontested" // only ranked proposal of its challenge
+)
+
+// Rules to decide the proposals outcome.
+//
+// Proposals that don't reach the quorum are not ranked. The others are ranked,
+// within their challenge, by net votes (yes - no), then ties are broken by
+// higher impact score, then by lower requested funds, then by lower internal id.
+//
+// The stake quorum is checked only once the proposal tally is done,
+// before that the tallied stake is not known and the proposal is pending.
+type Rules struct {
+	QuorumVotes     uint    // minimum votes cast, 0 disabled
+	QuorumStakePct  float64 // minimum tallied stake as percentage of RegisteredStake, 0 disabled
+	RegisteredStake uint64  // total registered stake (lovelace)
+}
+
+// Outcome of a proposal and the rule that decided it.
+type Outcome struct {
+	Rank      int    `json:"rank"       csv:"rank"`
+	Outcome   string `json:"outcome"    csv:"outcome"`
+	DecidedBy string `json:"decided_by" csv:"decided_by"`
+}
+
+// NetVotes of the proposal (yes - no).
+func (pr *ProposalsResult) NetVotes() int64 {
+	return int64(pr.Tally01) - int64(pr.Tally02)
+}
+
+// TalliedStake is the stake that took part to the proposal tally (all options).
+func (pr *ProposalsResult) TalliedStake() uint64 {
+	var total uint64
+	for _, v := range pr.TallyOptions.Values() {
+		total += uint64(v)
+	}
+	return total
+}
+
+// quorum returns the outcome and the quorum rule of a proposal that can't be ranked, empty if reached.
+func (rules Rules) quorum(pr *ProposalsResult) (string, string) {
+	if rules.QuorumVotes > 0 && pr.VotesCast < rules.QuorumVotes {
+		return OutcomeNoQuorum, RuleQuorumVotes
+	}
+	if rules.QuorumStakePct > 0 {
+		switch {
+		case !pr.Tallied:
+			return OutcomePending, RuleQuorumStake
+		case float64(pr.TalliedStake())*100 < rules.QuorumStakePct*float64(rules.RegisteredStake):
+			return OutcomeNoQuorum, RuleQuorumStake
+		}
+	}
+	return "", ""
+}
+
+// compareRanking returns true if a ranks before b, along with the rule that decided it.
+func compareRanking(a *ProposalsResult, b *ProposalsResult) (bool, string) {
+	switch {
+	case a.NetVotes() != b.NetVotes():
+		return a.NetVotes() > b.NetVotes(), RuleNetVotes
+	case a.ImpactScore != b.ImpactScore:
+		return a.ImpactScore > b.ImpactScore, RuleImpactScore
+	case a.Funds != b.Funds:
+		return a.Funds < b.Funds, RuleRequestedFunds
+	default:
+		return a.InternalID < b.InternalID, RuleInternalID
+	}
+}
+
+// unranked reports whether the proposal was left out of the ranking (no quorum or pending).
+func (o Outcome) unranked() bool {
+	return o.Outcome == OutcomeNoQuorum || o.Outcome == OutcomePending
+}
+
+// rankLess orders the proposals with an outcome, ranked ones first.
+func rankLess(a *ProposalsResult, b *ProposalsResult) bool {
+	aRanked, bRanked := !a.Outcome.unranked(), !b.Outcome.unranked()
+	if aRanked != bRanked {
+		return aRanked
+	}
+	less, _ := compareRanking(a, b)
+	return less
+}
+
+// ApplyRules sets the outcome on a copy of the results, ranking the proposals within their challenge.
+func ApplyRules(results []ProposalsResult, rules Rules) []ProposalsResult {
+	ranked := make([]ProposalsResult, len(results))
+	copy(ranked, results)
+
+	challenges := make(map[uint32][]*ProposalsResult)
+	for i := range ranked {
+		if outcome, rule := rules.quorum(&ranked[i]); outcome != "" {
+			ranked[i].Outcome = Outcome{Outcome: outcome, DecidedBy: rule}
+			continue
+		}
+		challenges[ranked[i].ChallengeID] = append(challenges[ranked[i].ChallengeID], &ranked[i])
+	}
+
+	for _, members := range challenges {
+		sort.SliceStable(members, func(i, j int) bool {
+			less, _ := compareRanking(members[i], members[j])
+			return less
+		})
+
+		for i, m := range members {
+			// the rule that sets it apart from the previous one, or from the next one for the first
+			decidedBy := RuleUncontested
+			switch {
+			case i > 0:
+				_, decidedBy = compareRanking(members[i-1], m)
+			case len(members) > 1:
+				_, decidedBy = compareRanking(m, members[1])
+			}
+			m.Outcome = Outcome{Rank: i + 1, Outcome: OutcomeRanked, DecidedBy: decidedBy}
+		}
+	}
+
+	return ranked
+}
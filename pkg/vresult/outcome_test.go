@@ -0,0 +1,189 @@
+package vresult
+
+import (
+	"testing"
+
+	"github.com/input-output-hk/jorvit/internal/loader"
+)
+
+func TestCompareRanking(t *testing.T) {
+	proposal := func(internalID uint64, score loader.Score, funds loader.Lovelace, yes uint, no uint) *ProposalsResult {
+		pr := testResult(internalID, 1, "", yes+no, yes, no)
+		pr.ImpactScore = score
+		pr.Funds = funds
+		return &pr
+	}
+
+	tests := []struct {
+		name      string
+		a, b      *ProposalsResult
+		less      bool
+		decidedBy string
+	}{
+		// 3 net votes outrank 3000 impact score
+		{"more net votes", proposal(2, 100, 10, 3, 0), proposal(1, 3000, 5, 0, 0), true, RuleNetVotes},
+		{"less net votes", proposal(1, 3000, 5, 0, 0), proposal(2, 100, 10, 3, 0), false, RuleNetVotes},
+		{"net votes not yes votes", proposal(1, 400, 5, 5, 4), proposal(2, 300, 10, 3, 0), false, RuleNetVotes},
+		{"higher impact score", proposal(2, 400, 10, 5, 5), proposal(1, 300, 5, 0, 0), true, RuleImpactScore},
+		{"lower impact score", proposal(1, 300, 5, 0, 0), proposal(2, 400, 10, 5, 5), false, RuleImpactScore},
+		{"lower requested funds", proposal(2, 300, 5, 2, 0), proposal(1, 300, 10, 7, 5), true, RuleRequestedFunds},
+		{"higher requested funds", proposal(1, 300, 10, 7, 5), proposal(2, 300, 5, 2, 0), false, RuleRequestedFunds},
+		{"lower internal id", proposal(1, 300, 5, 2, 0), proposal(2, 300, 5, 7, 5), true, RuleInternalID},
+		{"higher internal id", proposal(2, 300, 5, 7, 5), proposal(1, 300, 5, 2, 0), false, RuleInternalID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			less, decidedBy := compareRanking(tt.a, tt.b)
+			if less != tt.less || decidedBy != tt.decidedBy {
+				t.Errorf("compareRanking: got (%v, %s), want (%v, %s)", less, decidedBy, tt.less, tt.decidedBy)
+			}
+		})
+	}
+}
+
+func TestRankLess(t *testing.T) {
+	ranked := testResult(1, 1, "", 0, 0, 0)
+	ranked.Outcome = Outcome{Rank: 1, Outcome: OutcomeRanked}
+	for _, outcome := range []string{OutcomeNoQuorum, OutcomePending} {
+		unranked := testResult(2, 1, "", 10, 10, 0)
+		unranked.Outcome = Outcome{Outcome: outcome}
+		if !rankLess(&ranked, &unranked) || rankLess(&unranked, &ranked) {
+			t.Errorf("rankLess: %s proposal expected after the ranked ones", outcome)
+		}
+	}
+}
+
+func TestApplyRules(t *testing.T) {
+	type want struct {
+		rank      int
+		outcome   string
+		decidedBy string
+	}
+
+	// challenge 1: ids 1-3, challenge 2: id 4, challenge 3: ids 5-6
+	results := func(tallied bool) []ProposalsResult {
+		results := []ProposalsResult{
+			testResult(1, 1, "", 10, 6, 4),
+			testResult(2, 1, "", 10, 2, 8),
+			testResult(3, 1, "", 2, 2, 0),
+			testResult(4, 2, "", 5, 5, 0),
+			testResult(5, 3, "", 3, 3, 0),
+			testResult(6, 3, "", 3, 3, 0),
+		}
+		for i, score := range []loader.Score{300, 400, 400, 100, 200, 200} {
+			results[i].ImpactScore = score
+			results[i].Tallied = tallied
+		}
+		results[2].Funds = results[1].Funds
+		if !tallied {
+			for i := range results {
+				results[i].TallyOptions = TallyOptions{}
+			}
+		}
+		return results
+	}
+
+	tests := []struct {
+		name    string
+		results []ProposalsResult
+		rules   Rules
+		want    []want
+	}{
+		{
+			name:    "no quorum rules",
+			results: results(true),
+			want: []want{
+				{2, OutcomeRanked, RuleImpactScore},
+				{3, OutcomeRanked, RuleNetVotes},
+				{1, OutcomeRanked, RuleImpactScore},
+				{1, OutcomeRanked, RuleUncontested},
+				{1, OutcomeRanked, RuleRequestedFunds},
+				{2, OutcomeRanked, RuleRequestedFunds},
+			},
+		},
+		{
+			// id 1 outranks id 2 with a lower impact score, by votes
+			name:    "quorum votes",
+			results: results(true),
+			rules:   Rules{QuorumVotes: 4},
+			want: []want{
+				{1, OutcomeRanked, RuleNetVotes},
+				{2, OutcomeRanked, RuleNetVotes},
+				{0, OutcomeNoQuorum, RuleQuorumVotes},
+				{1, OutcomeRanked, RuleUncontested},
+				{0, OutcomeNoQuorum, RuleQuorumVotes},
+				{0, OutcomeNoQuorum, RuleQuorumVotes},
+			},
+		},
+		{
+			name:    "quorum stake",
+			results: results(true),
+			rules:   Rules{QuorumStakePct: 10, RegisteredStake: 50},
+			want: []want{
+				{1, OutcomeRanked, RuleNetVotes},
+				{2, OutcomeRanked, RuleNetVotes},
+				{0, OutcomeNoQuorum, RuleQuorumStake},
+				{1, OutcomeRanked, RuleUncontested},
+				{0, OutcomeNoQuorum, RuleQuorumStake},
+				{0, OutcomeNoQuorum, RuleQuorumStake},
+			},
+		},
+		{
+			name:    "quorum stake not tallied yet",
+			results: results(false),
+			rules:   Rules{QuorumStakePct: 10, RegisteredStake: 50},
+			want: []want{
+				{0, OutcomePending, RuleQuorumStake},
+				{0, OutcomePending, RuleQuorumStake},
+				{0, OutcomePending, RuleQuorumStake},
+				{0, OutcomePending, RuleQuorumStake},
+				{0, OutcomePending, RuleQuorumStake},
+				{0, OutcomePending, RuleQuorumStake},
+			},
+		},
+		{
+			// votes cast are known before the tally
+			name:    "quorum votes and stake not tallied yet",
+			results: results(false),
+			rules:   Rules{QuorumVotes: 4, QuorumStakePct: 10, RegisteredStake: 50},
+			want: []want{
+				{0, OutcomePending, RuleQuorumStake},
+				{0, OutcomePending, RuleQuorumStake},
+				{0, OutcomeNoQuorum, RuleQuorumVotes},
+				{0, OutcomePending, RuleQuorumStake},
+				{0, OutcomeNoQuorum, RuleQuorumVotes},
+				{0, OutcomeNoQuorum, RuleQuorumVotes},
+			},
+		},
+		{
+			// no net votes before the tally, the tie-break rules only
+			name:    "not tallied yet",
+			results: results(false),
+			want: []want{
+				{3, OutcomeRanked, RuleImpactScore},
+				{1, OutcomeRanked, RuleInternalID},
+				{2, OutcomeRanked, RuleInternalID},
+				{1, OutcomeRanked, RuleUncontested},
+				{1, OutcomeRanked, RuleRequestedFunds},
+				{2, OutcomeRanked, RuleRequestedFunds},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranked := ApplyRules(tt.results, tt.rules)
+			if len(ranked) != len(tt.want) {
+				t.Fatalf("results: got %d, want %d", len(ranked), len(tt.want))
+			}
+			for i, w := range tt.want {
+				got := ranked[i].Outcome
+				if got.Rank != w.rank || got.Outcome != w.outcome || got.DecidedBy != w.decidedBy {
+					t.Errorf("proposal %d: got %+v, want %+v", ranked[i].InternalID, got, w)
+				}
+				if tt.results[i].Outcome != (Outcome{}) {
+					t.Errorf("proposal %d: input modified", tt.results[i].InternalID)
+				}
+			}
+		})
+	}
+}
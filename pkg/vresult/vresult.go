@@ -0,0 +1,231 @@
+// Package vresult provides the voteplans and proposals types, and their tally results merge.
+package vresult
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+
+	"github.com/input-output-hk/jorvit/internal/loader"
+)
+
+// ChainTime is a point in time of the blockchain, expressed as epoch and slot.
+type ChainTime struct {
+	Epoch  int64 `json:"epoch"`
+	SlotID int64 `json:"slot_id"`
+}
+
+func (ct ChainTime) String() string {
+	return strconv.FormatInt(ct.Epoch, 10) + "." + strconv.FormatInt(ct.SlotID, 10)
+}
+
+// ToChainTime converts dataTime (unix) to ChainTime, given the block0 time and slot/epoch settings.
+func ToChainTime(block0Time int64, SlotDuration uint8, SlotsPerEpoch uint32, dataTime int64) ChainTime {
+	slotsTotal := (dataTime - block0Time) / int64(SlotDuration)
+	epoch := slotsTotal / int64(SlotsPerEpoch)
+	slot := slotsTotal % int64(SlotsPerEpoch)
+
+	return ChainTime{
+		Epoch:  epoch,
+		SlotID: slot,
+	}
+}
+
+// VoteOption range of a proposal.
+type VoteOption struct {
+	Start uint8 `json:"start"`
+	End   uint8 `json:"end"`
+}
+
+// TallyResult of a proposal, keyed by payload type.
+type TallyResult map[string]struct {
+	Result struct {
+		Options VoteOption `json:"options"`
+		Results []uint     `json:"results"`
+	} `json:"result"`
+}
+
+// VoteProposal as reported by the node for each voteplan.
+type VoteProposal struct {
+	Index      uint8       `json:"index"`
+	ProposalID string      `json:"proposal_id"`
+	Options    VoteOption  `json:"options"`
+	Tally      TallyResult `json:"tally"`
+	VotesCast  uint        `json:"votes_cast"`
+}
+
+// VotePlans as reported by the node (/api/v0/vote/active/plans).
+type VotePlans struct {
+	ID                  string         `json:"id"`
+	Payload             string         `json:"payload"`
+	VoteStart           ChainTime      `json:"vote_start"`
+	VoteEnd             ChainTime      `json:"vote_end"`
+	CommitteeEnd        ChainTime      `json:"committee_end"`
+	CommitteeMemberKeys []string       `json:"committee_member_keys"`
+	Proposals           []VoteProposal `json:"proposals"`
+}
+
+// TallyOptions total 16 choices available (0-15)
+//
+// Only the blank/yes/no options used by the vit voteplans are exported,
+// the other ones are kept so any tally result can be merged and compared.
+type TallyOptions struct {
+	Tally00 uint `json:"tally_0"  csv:"tally_0_BLANK"`
+	Tally01 uint `json:"tally_1"  csv:"tally_1_YES"`
+	Tally02 uint `json:"tally_2"  csv:"tally_2_NO"`
+	Tally03 uint `json:"-"        csv:"-"`
+	Tally04 uint `json:"-"        csv:"-"`
+	Tally05 uint `json:"-"        csv:"-"`
+	Tally06 uint `json:"-"        csv:"-"`
+	Tally07 uint `json:"-"        csv:"-"`
+	Tally08 uint `json:"-"        csv:"-"`
+	Tally09 uint `json:"-"        csv:"-"`
+	Tally10 uint `json:"-"        csv:"-"`
+	Tally11 uint `json:"-"        csv:"-"`
+	Tally12 uint `json:"-"        csv:"-"`
+	Tally13 uint `json:"-"        csv:"-"`
+	Tally14 uint `json:"-"        csv:"-"`
+	Tally15 uint `json:"-"        csv:"-"`
+}
+
+// Set the tally result of the given option, out of range options are ignored.
+func (to *TallyOptions) Set(option int, value uint) {
+	switch option {
+	case 0:
+		to.Tally00 = value
+	case 1:
+		to.Tally01 = value
+	case 2:
+		to.Tally02 = value
+	case 3:
+		to.Tally03 = value
+	case 4:
+		to.Tally04 = value
+	case 5:
+		to.Tally05 = value
+	case 6:
+		to.Tally06 = value
+	case 7:
+		to.Tally07 = value
+	case 8:
+		to.Tally08 = value
+	case 9:
+		to.Tally09 = value
+	case 10:
+		to.Tally10 = value
+	case 11:
+		to.Tally11 = value
+	case 12:
+		to.Tally12 = value
+	case 13:
+		to.Tally13 = value
+	case 14:
+		to.Tally14 = value
+	case 15:
+		to.Tally15 = value
+	}
+}
+
+// Values returns the tally results as a slice indexed by option.
+func (to TallyOptions) Values() []uint {
+	return []uint{
+		to.Tally00, to.Tally01, to.Tally02, to.Tally03,
+		to.Tally04, to.Tally05, to.Tally06, to.Tally07,
+		to.Tally08, to.Tally09, to.Tally10, to.Tally11,
+		to.Tally12, to.Tally13, to.Tally14, to.Tally15,
+	}
+}
+
+// ProposalsResult is a proposal with its votes cast, tally result and outcome.
+type ProposalsResult struct {
+	loader.ProposalData
+	VotesCast uint `json:"votes_cast" csv:"votes_cast"`
+	Tallied   bool `json:"-"          csv:"-"` // tally results available
+	TallyOptions
+	Outcome
+}
+
+// Merge sets votes cast and tally results from the voteplans into a copy of the proposals.
+//
+// Proposals are matched by voteplan id, proposal index and chain proposal id.
+// Votes cast are always set, tally results only once the tally is done.
+func Merge(votePlans []VotePlans, proposals []ProposalsResult) []ProposalsResult {
+	results := make([]ProposalsResult, len(proposals))
+	copy(results, proposals)
+
+	for i := range results {
+		// proposals without a voteplan can't have results
+		if results[i].ChainVotePlan == nil {
+			continue
+		}
+
+		for x := range votePlans {
+			// skip other voteplans id
+			if results[i].VotePlanID != votePlans[x].ID {
+				continue
+			}
+
+			for y := range votePlans[x].Proposals {
+				// skip other proposals index
+				if results[i].ChainProposal.Index != votePlans[x].Proposals[y].Index {
+					continue
+				}
+				// skip other proposals id - in theory this should not never be the case since we matched index
+				if results[i].ChainProposal.ExternalID != votePlans[x].Proposals[y].ProposalID {
+					continue
+				}
+
+				// set the number of votes casted, so it is available even when no tally yet
+				results[i].VotesCast = votePlans[x].Proposals[y].VotesCast
+
+				// we should have the tally done and only one payload
+				if len(votePlans[x].Proposals[y].Tally) != 1 {
+					continue
+				}
+
+				for _, res := range votePlans[x].Proposals[y].Tally {
+					for r, tr := range res.Result.Results {
+						results[i].TallyOptions.Set(r, tr)
+					}
+				}
+				results[i].Tallied = true
+			}
+		}
+	}
+
+	return results
+}
+
+// DecodeVotePlans reads the node voteplans json.
+func DecodeVotePlans(r io.Reader) ([]VotePlans, error) {
+	var votePlans []VotePlans
+	err := json.NewDecoder(r).Decode(&votePlans)
+	return votePlans, err
+}
+
+// DecodeProposals reads the vit-servicing-station proposals json.
+func DecodeProposals(r io.Reader) ([]ProposalsResult, error) {
+	var proposals []ProposalsResult
+	err := json.NewDecoder(r).Decode(&proposals)
+	return proposals, err
+}
+
+// DecodeFund reads the vit-servicing-station fund json.
+func DecodeFund(r io.Reader) (*loader.FundData, error) {
+	var fund loader.FundData
+	err := json.NewDecoder(r).Decode(&fund)
+	return &fund, err
+}
+
+// Results decodes the voteplans and proposals json and merges them.
+func Results(votePlans io.Reader, proposals io.Reader) ([]ProposalsResult, error) {
+	vp, err := DecodeVotePlans(votePlans)
+	if err != nil {
+		return nil, err
+	}
+	pr, err := DecodeProposals(proposals)
+	if err != nil {
+		return nil, err
+	}
+	return Merge(vp, pr), nil
+}
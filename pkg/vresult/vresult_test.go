@@ -0,0 +1,174 @@
+package vresult
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testDataDir = "../../cmd/vitresult/test_data"
+
+func testData(t *testing.T) ([]VotePlans, []ProposalsResult) {
+	t.Helper()
+
+	vpFile, err := os.Open(filepath.Join(testDataDir, "public_tally_result.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vpFile.Close()
+	votePlans, err := DecodeVotePlans(vpFile)
+	if err != nil {
+		t.Fatalf("DecodeVotePlans: %v", err)
+	}
+
+	prFile, err := os.Open(filepath.Join(testDataDir, "public_proposals.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer prFile.Close()
+	proposals, err := DecodeProposals(prFile)
+	if err != nil {
+		t.Fatalf("DecodeProposals: %v", err)
+	}
+
+	return votePlans, proposals
+}
+
+func TestResults(t *testing.T) {
+	vpFile, err := os.Open(filepath.Join(testDataDir, "public_tally_result.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vpFile.Close()
+	prFile, err := os.Open(filepath.Join(testDataDir, "public_proposals.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer prFile.Close()
+
+	results, err := Results(vpFile, prFile)
+	if err != nil {
+		t.Fatalf("Results: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("results: got %d, want %d", len(results), 2)
+	}
+	if results[0].Tally01 != 1 || results[1].Tally02 != 1 {
+		t.Errorf("results tally: got %v and %v", results[0].Values()[:3], results[1].Values()[:3])
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name      string
+		modify    func(votePlans []VotePlans, proposals []ProposalsResult)
+		votesCast []uint
+		tally     [][]uint
+	}{
+		{
+			name:      "single payload tally",
+			modify:    func(votePlans []VotePlans, proposals []ProposalsResult) {},
+			votesCast: []uint{1, 1},
+			tally:     [][]uint{{0, 1, 0}, {0, 0, 1}},
+		},
+		{
+			name: "votes cast no tally yet",
+			modify: func(votePlans []VotePlans, proposals []ProposalsResult) {
+				for i := range votePlans[0].Proposals {
+					votePlans[0].Proposals[i].Tally = nil
+				}
+			},
+			votesCast: []uint{1, 1},
+			tally:     [][]uint{{0, 0, 0}, {0, 0, 0}},
+		},
+		{
+			name: "more than one tally payload",
+			modify: func(votePlans []VotePlans, proposals []ProposalsResult) {
+				tally := TallyResult{}
+				for k, v := range votePlans[0].Proposals[0].Tally {
+					tally[k] = v
+					tally["Private"] = v
+				}
+				votePlans[0].Proposals[0].Tally = tally
+			},
+			votesCast: []uint{1, 1},
+			tally:     [][]uint{{0, 0, 0}, {0, 0, 1}},
+		},
+		{
+			name: "proposal index mismatch",
+			modify: func(votePlans []VotePlans, proposals []ProposalsResult) {
+				proposals[0].Index = 5
+			},
+			votesCast: []uint{0, 1},
+			tally:     [][]uint{{0, 0, 0}, {0, 0, 1}},
+		},
+		{
+			name: "proposal id mismatch",
+			modify: func(votePlans []VotePlans, proposals []ProposalsResult) {
+				proposals[1].ExternalID = "d7fa4e00e408751319c3bdb84e95fd0dcffb81107a2561e691c33c1ae635c2cd"
+			},
+			votesCast: []uint{1, 0},
+			tally:     [][]uint{{0, 1, 0}, {0, 0, 0}},
+		},
+		{
+			name: "voteplan id mismatch",
+			modify: func(votePlans []VotePlans, proposals []ProposalsResult) {
+				votePlans[0].ID = "other"
+			},
+			votesCast: []uint{0, 0},
+			tally:     [][]uint{{0, 0, 0}, {0, 0, 0}},
+		},
+		{
+			name: "nil voteplan",
+			modify: func(votePlans []VotePlans, proposals []ProposalsResult) {
+				proposals[0].ChainVotePlan = nil
+			},
+			votesCast: []uint{0, 1},
+			tally:     [][]uint{{0, 0, 0}, {0, 0, 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			votePlans, proposals := testData(t)
+			tt.modify(votePlans, proposals)
+
+			results := Merge(votePlans, proposals)
+			if len(results) != len(proposals) {
+				t.Fatalf("results: got %d, want %d", len(results), len(proposals))
+			}
+			for i := range results {
+				if results[i].VotesCast != tt.votesCast[i] {
+					t.Errorf("proposal [%d] votes cast: got %d, want %d", i, results[i].VotesCast, tt.votesCast[i])
+				}
+				got := results[i].Values()[:len(tt.tally[i])]
+				for opt := range got {
+					if got[opt] != tt.tally[i][opt] {
+						t.Errorf("proposal [%d] tally: got %v, want %v", i, got, tt.tally[i])
+						break
+					}
+				}
+				// the input proposals are not modified
+				if proposals[i].VotesCast != 0 {
+					t.Errorf("proposal [%d] input modified", i)
+				}
+			}
+		})
+	}
+}
+
+func TestTallyOptions(t *testing.T) {
+	var to TallyOptions
+	for opt := 0; opt < 17; opt++ {
+		to.Set(opt, uint(opt+1))
+	}
+	values := to.Values()
+	if len(values) != 16 {
+		t.Fatalf("values: got %d, want %d", len(values), 16)
+	}
+	for opt, v := range values {
+		if v != uint(opt+1) {
+			t.Errorf("option %d: got %d, want %d", opt, v, opt+1)
+		}
+	}
+}